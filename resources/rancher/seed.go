@@ -0,0 +1,476 @@
+package rancher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/snapshot"
+	"github.com/rancher/shepherd/clients/rancher"
+	management "github.com/rancher/shepherd/clients/rancher/generated/management/v3"
+	"github.com/rancher/shepherd/extensions/cloudcredentials"
+	"github.com/rancher/shepherd/extensions/cloudcredentials/aws"
+	"github.com/rancher/shepherd/extensions/users"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// SeedProfile controls how many objects of each kind SeedRancherData creates.
+type SeedProfile struct {
+	Name                        string
+	Users                       int
+	GlobalRoleBindings          int
+	Projects                    int
+	RoleTemplates               int
+	ProjectRoleTemplateBindings int
+	ClusterRoleTemplateBindings int
+	Tokens                      int
+	SettingOverrides            int
+	CloudCredentials            int
+	FleetWorkspaces             int
+	GitReposPerWorkspace        int
+	Namespaces                  int
+	SecretsPerNamespace         int
+	ConfigMapsPerNamespace      int
+}
+
+// SeedProfiles are the predefined seeding profiles, selectable by name.
+var SeedProfiles = map[string]SeedProfile{
+	"small": {
+		Name: "small", Users: 2, GlobalRoleBindings: 2, Projects: 2, RoleTemplates: 2,
+		ProjectRoleTemplateBindings: 2, ClusterRoleTemplateBindings: 2, Tokens: 2, SettingOverrides: 1,
+		CloudCredentials: 1, FleetWorkspaces: 1, GitReposPerWorkspace: 1,
+		Namespaces: 2, SecretsPerNamespace: 2, ConfigMapsPerNamespace: 2,
+	},
+	"medium": {
+		Name: "medium", Users: 20, GlobalRoleBindings: 20, Projects: 10, RoleTemplates: 10,
+		ProjectRoleTemplateBindings: 20, ClusterRoleTemplateBindings: 20, Tokens: 20, SettingOverrides: 1,
+		CloudCredentials: 5, FleetWorkspaces: 2, GitReposPerWorkspace: 5,
+		Namespaces: 10, SecretsPerNamespace: 10, ConfigMapsPerNamespace: 10,
+	},
+	"large": {
+		Name: "large", Users: 100, GlobalRoleBindings: 100, Projects: 50, RoleTemplates: 50,
+		ProjectRoleTemplateBindings: 100, ClusterRoleTemplateBindings: 100, Tokens: 100, SettingOverrides: 1,
+		CloudCredentials: 20, FleetWorkspaces: 5, GitReposPerWorkspace: 10,
+		Namespaces: 50, SecretsPerNamespace: 20, ConfigMapsPerNamespace: 20,
+	},
+}
+
+const (
+	seedLabel            = "observability-e2e/seed"
+	seedGlobalRole       = "view-rancher-metrics"
+	seedProjectRole      = "project-member"
+	seedClusterRole      = "cluster-member"
+	seedGitRepoURL       = "https://github.com/rancher/fleet-test-data/"
+	seedGitRepoPath      = "qa-test-apps/nginx-app"
+	fleetWorkspaceWait   = 2 * time.Minute
+	fleetWorkspacePollIv = 5 * time.Second
+)
+
+// seedSettingNames are settings whose value only affects links in the UI, so overriding them is harmless.
+var seedSettingNames = []string{"ui-issues"}
+
+// SettingOverride records the value a setting had before the seeder changed it.
+type SettingOverride struct {
+	Name          string
+	OriginalValue string
+	SeededValue   string
+}
+
+// SeedManifest lists everything SeedRancherData created.
+type SeedManifest struct {
+	Profile   SeedProfile
+	ClusterID string
+	Objects   []snapshot.ObjectRef
+	Settings  []SettingOverride
+	Users     []*management.User
+	Projects  []*management.Project
+	Roles     []*management.RoleTemplate
+}
+
+// GetSeedProfile returns the named seeding profile.
+func GetSeedProfile(name string) (SeedProfile, error) {
+	profile, ok := SeedProfiles[strings.ToLower(name)]
+	if !ok {
+		return SeedProfile{}, fmt.Errorf("unknown seed profile %q, expected one of small, medium or large", name)
+	}
+	return profile, nil
+}
+
+// SeedProfileForObjectCount builds a profile that creates the RBAC objects of the small profile plus roughly
+// count secrets and configmaps, spread over namespaces of at most 100 objects each.
+func SeedProfileForObjectCount(count int) SeedProfile {
+	profile := SeedProfiles["small"]
+	profile.Name = fmt.Sprintf("objects-%d", count)
+	profile.Namespaces = (count + 99) / 100
+	if profile.Namespaces == 0 {
+		return profile
+	}
+	perNamespace := (count + profile.Namespaces - 1) / profile.Namespaces
+	profile.SecretsPerNamespace = perNamespace / 2
+	profile.ConfigMapsPerNamespace = perNamespace - profile.SecretsPerNamespace
+	return profile
+}
+
+var (
+	userRef         = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "User", Resource: "users"}
+	grbRef          = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "GlobalRoleBinding", Resource: "globalrolebindings"}
+	projectRef      = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "Project", Resource: "projects"}
+	roleTemplateRef = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "RoleTemplate", Resource: "roletemplates"}
+	prtbRef         = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "ProjectRoleTemplateBinding", Resource: "projectroletemplatebindings"}
+	crtbRef         = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "ClusterRoleTemplateBinding", Resource: "clusterroletemplatebindings"}
+	tokenRef        = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "Token", Resource: "tokens"}
+	settingRef      = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "Setting", Resource: "settings"}
+	workspaceRef    = snapshot.ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "FleetWorkspace", Resource: "fleetworkspaces"}
+	gitRepoRef      = snapshot.ObjectRef{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepo", Resource: "gitrepos"}
+	namespaceRef    = snapshot.ObjectRef{Version: "v1", Kind: "Namespace", Resource: "namespaces"}
+	secretRef       = snapshot.ObjectRef{Version: "v1", Kind: "Secret", Resource: "secrets"}
+	configMapRef    = snapshot.ObjectRef{Version: "v1", Kind: "ConfigMap", Resource: "configmaps"}
+)
+
+func (m *SeedManifest) add(kind snapshot.ObjectRef, namespace, name string) {
+	m.addLabeled(kind, namespace, name, nil)
+}
+
+// addLabeled records an object created with labels, so a resource set selecting by label is checked against them.
+func (m *SeedManifest) addLabeled(kind snapshot.ObjectRef, namespace, name string, labels map[string]string) {
+	kind.Namespace = namespace
+	kind.Name = name
+	kind.Labels = labels
+	m.Objects = append(m.Objects, kind)
+}
+
+// splitID splits a norman ID such as "local:p-abcde" into namespace and name.
+func splitID(id string) (string, string) {
+	if namespace, name, found := strings.Cut(id, ":"); found {
+		return namespace, name
+	}
+	return "", id
+}
+
+// SeedRancherData creates Rancher objects according to the profile and returns a manifest describing them.
+// The manifest is returned even on error so that whatever was created can still be cleaned up.
+func SeedRancherData(client *rancher.Client, clusterID string, profile SeedProfile) (*SeedManifest, error) {
+	manifest := &SeedManifest{Profile: profile, ClusterID: clusterID}
+	e2e.Logf("Seeding Rancher data with the %s profile", profile.Name)
+
+	seeders := []func(*rancher.Client, *SeedManifest) error{
+		seedUsers,
+		seedProjects,
+		seedRoleTemplates,
+		seedRoleBindings,
+		seedTokens,
+		seedSettingOverrides,
+		seedCloudCredentials,
+		seedFleet,
+		seedNamespaces,
+	}
+	for _, seed := range seeders {
+		if err := seed(client, manifest); err != nil {
+			return manifest, err
+		}
+	}
+
+	e2e.Logf("Seeded %d objects and %d setting overrides", len(manifest.Objects), len(manifest.Settings))
+	return manifest, nil
+}
+
+func seedUsers(client *rancher.Client, manifest *SeedManifest) error {
+	for i := 0; i < manifest.Profile.Users; i++ {
+		user, err := users.CreateUserWithRole(client, users.UserConfig(), "user")
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		manifest.Users = append(manifest.Users, user)
+		manifest.add(userRef, "", user.ID)
+	}
+	return nil
+}
+
+func seedProjects(client *rancher.Client, manifest *SeedManifest) error {
+	for i := 0; i < manifest.Profile.Projects; i++ {
+		project, namespace, err := CreateProjectAndNamespace(client, manifest.ClusterID)
+		if err != nil {
+			return fmt.Errorf("failed to create project: %w", err)
+		}
+		manifest.Projects = append(manifest.Projects, project)
+		projectNamespace, projectName := splitID(project.ID)
+		manifest.add(projectRef, projectNamespace, projectName)
+		manifest.add(namespaceRef, "", namespace.Name)
+	}
+	return nil
+}
+
+func seedRoleTemplates(client *rancher.Client, manifest *SeedManifest) error {
+	for i := 0; i < manifest.Profile.RoleTemplates; i++ {
+		role, err := client.Management.RoleTemplate.Create(&management.RoleTemplate{
+			Context: "cluster",
+			Name:    namegen.AppendRandomString("bro-role"),
+			Rules:   rules,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create role template: %w", err)
+		}
+		manifest.Roles = append(manifest.Roles, role)
+		manifest.add(roleTemplateRef, "", role.ID)
+	}
+	return nil
+}
+
+// seedRoleBindings spreads the bindings over the seeded users and projects.
+func seedRoleBindings(client *rancher.Client, manifest *SeedManifest) error {
+	profile := manifest.Profile
+	if len(manifest.Users) == 0 {
+		if profile.GlobalRoleBindings+profile.ProjectRoleTemplateBindings+profile.ClusterRoleTemplateBindings > 0 {
+			return errors.New("seeding role bindings requires at least one user")
+		}
+		return nil
+	}
+
+	for i := 0; i < profile.GlobalRoleBindings; i++ {
+		user := manifest.Users[i%len(manifest.Users)]
+		grb, err := client.Management.GlobalRoleBinding.Create(&management.GlobalRoleBinding{
+			GlobalRoleID: seedGlobalRole,
+			UserID:       user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create global role binding for %s: %w", user.ID, err)
+		}
+		manifest.add(grbRef, "", grb.ID)
+	}
+
+	if profile.ProjectRoleTemplateBindings > 0 && len(manifest.Projects) == 0 {
+		return errors.New("seeding project role template bindings requires at least one project")
+	}
+	for i := 0; i < profile.ProjectRoleTemplateBindings; i++ {
+		user := manifest.Users[i%len(manifest.Users)]
+		project := manifest.Projects[i%len(manifest.Projects)]
+		prtb, err := client.Management.ProjectRoleTemplateBinding.Create(&management.ProjectRoleTemplateBinding{
+			ProjectID:      project.ID,
+			RoleTemplateID: seedProjectRole,
+			UserID:         user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create project role template binding for %s: %w", user.ID, err)
+		}
+		namespace, name := splitID(prtb.ID)
+		manifest.add(prtbRef, namespace, name)
+	}
+
+	for i := 0; i < profile.ClusterRoleTemplateBindings; i++ {
+		user := manifest.Users[i%len(manifest.Users)]
+		crtb, err := client.Management.ClusterRoleTemplateBinding.Create(&management.ClusterRoleTemplateBinding{
+			ClusterID:      manifest.ClusterID,
+			RoleTemplateID: seedClusterRole,
+			UserID:         user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create cluster role template binding for %s: %w", user.ID, err)
+		}
+		namespace, name := splitID(crtb.ID)
+		manifest.add(crtbRef, namespace, name)
+	}
+	return nil
+}
+
+func seedTokens(client *rancher.Client, manifest *SeedManifest) error {
+	for i := 0; i < manifest.Profile.Tokens; i++ {
+		token, err := client.Management.Token.Create(&management.Token{
+			Description: namegen.AppendRandomString("seed-token"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		manifest.add(tokenRef, "", token.ID)
+	}
+	return nil
+}
+
+func seedSettingOverrides(client *rancher.Client, manifest *SeedManifest) error {
+	count := manifest.Profile.SettingOverrides
+	if count > len(seedSettingNames) {
+		count = len(seedSettingNames)
+	}
+	for _, name := range seedSettingNames[:count] {
+		setting, err := client.Management.Setting.ByID(name)
+		if err != nil {
+			return fmt.Errorf("failed to get setting %s: %w", name, err)
+		}
+		seededValue := fmt.Sprintf("https://example.com/%s", namegen.RandStringLower(8))
+		if _, err := client.Management.Setting.Update(setting, map[string]interface{}{"value": seededValue}); err != nil {
+			return fmt.Errorf("failed to override setting %s: %w", name, err)
+		}
+		manifest.Settings = append(manifest.Settings, SettingOverride{Name: name, OriginalValue: setting.Value, SeededValue: seededValue})
+		manifest.add(settingRef, "", name)
+	}
+	return nil
+}
+
+func seedCloudCredentials(client *rancher.Client, manifest *SeedManifest) error {
+	for i := 0; i < manifest.Profile.CloudCredentials; i++ {
+		credential, err := aws.CreateAWSCloudCredentials(client, cloudcredentials.CloudCredential{
+			AmazonEC2CredentialConfig: &cloudcredentials.AmazonEC2CredentialConfig{
+				AccessKey:     namegen.RandStringLower(20),
+				SecretKey:     namegen.RandStringLower(40),
+				DefaultRegion: "us-east-2",
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create cloud credential: %w", err)
+		}
+		manifest.add(secretRef, credential.Namespace, credential.Name)
+	}
+	return nil
+}
+
+func seedFleet(client *rancher.Client, manifest *SeedManifest) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(manifest.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	for i := 0; i < manifest.Profile.FleetWorkspaces; i++ {
+		workspaceName := namegen.AppendRandomString("seed-ws")
+		workspace := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "management.cattle.io/v3",
+			"kind":       "FleetWorkspace",
+			"metadata":   map[string]interface{}{"name": workspaceName, "labels": map[string]interface{}{seedLabel: "true"}},
+		}}
+		if _, err := dynamicClient.Resource(workspaceRef.GVR()).Create(context.TODO(), workspace, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create fleet workspace %s: %w", workspaceName, err)
+		}
+		manifest.addLabeled(workspaceRef, "", workspaceName, map[string]string{seedLabel: "true"})
+
+		// Rancher creates the backing namespace asynchronously.
+		err := wait.PollUntilContextTimeout(context.TODO(), fleetWorkspacePollIv, fleetWorkspaceWait, true, func(ctx context.Context) (bool, error) {
+			_, err := dynamicClient.Resource(NamespaceGroupVersionResource).Get(ctx, workspaceName, metav1.GetOptions{})
+			if k8serrors.IsNotFound(err) {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		if err != nil {
+			return fmt.Errorf("namespace for fleet workspace %s was not created: %w", workspaceName, err)
+		}
+
+		for j := 0; j < manifest.Profile.GitReposPerWorkspace; j++ {
+			repoName := namegen.AppendRandomString("seed-repo")
+			gitRepo := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "fleet.cattle.io/v1alpha1",
+				"kind":       "GitRepo",
+				"metadata":   map[string]interface{}{"name": repoName, "namespace": workspaceName, "labels": map[string]interface{}{seedLabel: "true"}},
+				"spec": map[string]interface{}{
+					"repo":   seedGitRepoURL,
+					"branch": "master",
+					"paths":  []interface{}{seedGitRepoPath},
+				},
+			}}
+			if _, err := dynamicClient.Resource(gitRepoRef.GVR()).Namespace(workspaceName).Create(context.TODO(), gitRepo, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create gitrepo %s: %w", repoName, err)
+			}
+			manifest.addLabeled(gitRepoRef, workspaceName, repoName, map[string]string{seedLabel: "true"})
+		}
+	}
+	return nil
+}
+
+func seedNamespaces(client *rancher.Client, manifest *SeedManifest) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(manifest.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	for i := 0; i < manifest.Profile.Namespaces; i++ {
+		namespace, err := CreateNamespace(client, manifest.ClusterID, "", namegen.AppendRandomString("seed-ns"), "", map[string]string{seedLabel: "true"}, nil)
+		if err != nil {
+			return err
+		}
+		manifest.addLabeled(namespaceRef, "", namespace.Name, map[string]string{seedLabel: "true"})
+
+		for j := 0; j < manifest.Profile.SecretsPerNamespace; j++ {
+			name := namegen.AppendRandomString("seed-secret")
+			secret := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": name, "labels": map[string]interface{}{seedLabel: "true"}},
+				"type":       "Opaque",
+				"stringData": map[string]interface{}{"value": namegen.RandStringLower(32)},
+			}}
+			if _, err := dynamicClient.Resource(secretRef.GVR()).Namespace(namespace.Name).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create secret %s/%s: %w", namespace.Name, name, err)
+			}
+			manifest.addLabeled(secretRef, namespace.Name, name, map[string]string{seedLabel: "true"})
+		}
+
+		for j := 0; j < manifest.Profile.ConfigMapsPerNamespace; j++ {
+			name := namegen.AppendRandomString("seed-cm")
+			configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": name, "labels": map[string]interface{}{seedLabel: "true"}},
+				"data":       map[string]interface{}{"value": namegen.RandStringLower(32)},
+			}}
+			if _, err := dynamicClient.Resource(configMapRef.GVR()).Namespace(namespace.Name).Create(context.TODO(), configMap, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create configmap %s/%s: %w", namespace.Name, name, err)
+			}
+			manifest.addLabeled(configMapRef, namespace.Name, name, map[string]string{seedLabel: "true"})
+		}
+	}
+	return nil
+}
+
+// VerifySettings checks that every overridden setting still carries its seeded value.
+func (m *SeedManifest) VerifySettings(client *rancher.Client) error {
+	var errs []error
+	for _, override := range m.Settings {
+		setting, err := client.Management.Setting.ByID(override.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get setting %s: %w", override.Name, err))
+			continue
+		}
+		if setting.Value != override.SeededValue {
+			errs = append(errs, fmt.Errorf("setting %s has value %q, expected %q", override.Name, setting.Value, override.SeededValue))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteSeededData removes the seeded objects in reverse creation order and reverts overridden settings.
+func DeleteSeededData(client *rancher.Client, manifest *SeedManifest) error {
+	if manifest == nil {
+		return nil
+	}
+	dynamicClient, err := client.GetDownStreamClusterClient(manifest.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	var errs []error
+	for _, override := range manifest.Settings {
+		setting, err := client.Management.Setting.ByID(override.Name)
+		if err == nil {
+			_, err = client.Management.Setting.Update(setting, map[string]interface{}{"value": override.OriginalValue})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to revert setting %s: %w", override.Name, err))
+		}
+	}
+
+	for i := len(manifest.Objects) - 1; i >= 0; i-- {
+		ref := manifest.Objects[i]
+		if ref.Kind == settingRef.Kind {
+			continue
+		}
+		err := dynamicClient.Resource(ref.GVR()).Namespace(ref.Namespace).Delete(context.TODO(), ref.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", ref, err))
+		}
+	}
+	return errors.Join(errs...)
+}
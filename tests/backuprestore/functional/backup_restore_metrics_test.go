@@ -15,6 +15,7 @@ limitations under the License.
 package backuprestore
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -22,8 +23,10 @@ import (
 	. "github.com/onsi/gomega"
 	bv1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	resources "github.com/rancher/observability-e2e/resources/rancher"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
-	localkubectl "github.com/rancher/observability-e2e/tests/helper/kubectl"
+	"github.com/rancher/observability-e2e/tests/helper/grafana"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
 	"github.com/rancher/observability-e2e/tests/helper/promclient"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	"github.com/rancher/shepherd/clients/rancher/catalog"
@@ -47,16 +50,11 @@ var _ = DescribeTable("Test: Rancher backup and restore metrics tests",
 		if params.StorageType == "s3" && skipS3Tests {
 			Skip("Skipping S3 tests as the access key is empty.")
 		}
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		By("Creating a client session")
 		clientWithSession, err := client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 
 		By("Installing or checking for existing Monitoring Chart with 'rancherBackupMonitoring' enabled")
@@ -184,19 +182,24 @@ var _ = DescribeTable("Test: Rancher backup and restore metrics tests",
 		e2e.Logf("Recurring backup completed successfully")
 
 		// create a invalid Backup object using wrong bucket name
-		_, err = localkubectl.Execute("apply", "-f", utils.GetYamlPath("tests/helper/yamls/invalidBackupCreate.yaml"))
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		invalidBackupPath := utils.GetYamlPath("tests/helper/yamls/invalidBackupCreate.template.yaml")
+		invalidBackup := utils.NewFixture("invalid-backup")
+		err = utils.DeployYamlTemplate(clientWithSession, invalidBackupPath, invalidBackup, "")
 		Expect(err).NotTo(HaveOccurred(), "Failed to create an invalid backup with bucket name")
+		DeferCleanup(func() {
+			Expect(utils.DeleteYamlTemplate(clientWithSession, invalidBackupPath, invalidBackup, "")).To(Succeed())
+		})
 
 		// assert that invalid backup was created
 		time.Sleep(2 * time.Minute)
 		Eventually(func() string {
-			out, err := localkubectl.Execute(
-				"get", "backup", "invalid-backup",
-				"-o", "jsonpath={.status.conditions[?(@.reason==\"Error\")].message}",
-			)
+			backup, err := kubeClient.Get(context.TODO(), bv1.SchemeGroupVersion.WithResource("backups"), "", invalidBackup.Name)
 			if err != nil {
 				return "" // conditions not ready yet
 			}
+			out, _ := kube.JSONPath(backup, `{.status.conditions[?(@.reason=="Error")].message}`)
 			return out
 		}, 2*time.Minute, 5*time.Second).Should(
 			ContainSubstring("failed to check if s3 bucket"),
@@ -210,6 +213,12 @@ var _ = DescribeTable("Test: Rancher backup and restore metrics tests",
 		Expect(err).ToNot(HaveOccurred())
 		time.Sleep(2 * time.Minute)
 
+		By("Verifying that Prometheus scrapes the rancher-backup ServiceMonitor and every other monitor of its namespace")
+		Eventually(func() ([]string, error) {
+			return promClient.CheckNamespaceMonitors(context.TODO(), kubeClient, []string{charts.RancherBackupRestoreNamespace},
+				promclient.ServiceMonitor(charts.RancherBackupRestoreNamespace, charts.RancherBackupRestoreName))
+		}, 2*time.Minute, 15*time.Second).Should(BeEmpty(), "rancher-backup metrics are not scraped")
+
 		By("Executing Prometheus queries to validate backup and restore metrics")
 		queries := map[string]float64{
 			`sum(rancher_restore_count)`:                        1.0,
@@ -230,10 +239,42 @@ var _ = DescribeTable("Test: Rancher backup and restore metrics tests",
 					fmt.Sprintf("unexpected value for query: %s", q))
 			}
 		}
+
+		By("Checking the Grafana datasources")
+		grafanaClient := grafana.NewClient(grafana.RancherMonitoringURL(clientWithSession.RancherConfig.Host, project.ClusterID), clientWithSession.RancherConfig.AdminToken)
+		datasources, err := grafanaClient.Datasources()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(datasources).NotTo(BeEmpty())
+		for _, datasource := range datasources {
+			Expect(grafanaClient.CheckDatasourceHealth(datasource.UID)).To(Succeed(), "datasource %s is not healthy", datasource.Name)
+		}
+
+		By("Verifying the backup and restore dashboards return data")
+		dashboards, err := grafanaClient.SearchDashboards("backup")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dashboards).NotTo(BeEmpty(), "rancherBackupMonitoring dashboards are not loaded in Grafana")
+		for _, ref := range dashboards {
+			dashboard, err := grafanaClient.Dashboard(ref.UID)
+			Expect(err).NotTo(HaveOccurred())
+
+			var broken []string
+			withData := 0
+			for _, result := range grafana.CheckPanels(promClient, dashboard) {
+				e2e.Logf("Grafana panel %s", result)
+				switch result.Status {
+				case grafana.PanelError:
+					broken = append(broken, result.String())
+				case grafana.PanelOK:
+					withData++
+				}
+			}
+			Expect(broken).To(BeEmpty(), "dashboard %s has panels with broken queries", dashboard.Title)
+			Expect(withData).To(BeNumerically(">", 0), "no panel of dashboard %s returns data", dashboard.Title)
+		}
 	},
 
 	charts.QaseEntry("[QASE-8273] (without encryption)",
-		[]interface{}{Label("LEVEL0", "metrics", "s3", "backup-restore")},
+		[]interface{}{Label("LEVEL0", "metrics", "s3", "backup-restore"), capabilities.Label(capabilities.BackupServiceMonitor)},
 		MetricsParams{
 			StorageType: "s3",
 			BackupOptions: charts.BackupOptions{
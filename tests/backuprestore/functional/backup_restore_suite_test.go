@@ -27,11 +27,12 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
-	. "github.com/rancher-sandbox/qase-ginkgo"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/observability-e2e/resources"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
 	localConfig "github.com/rancher/observability-e2e/tests/helper/config"
+	"github.com/rancher/observability-e2e/tests/helper/reporting"
 	localTerraform "github.com/rancher/observability-e2e/tests/helper/terraform"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	"github.com/rancher/rancher/tests/v2/actions/pipeline"
@@ -47,6 +48,7 @@ import (
 
 var (
 	client              *rancher.Client
+	capabilityEnv       *capabilities.Environment
 	sess                *session.Session
 	project             *management.Project
 	cluster             *clusters.ClusterMeta
@@ -82,20 +84,20 @@ var envTerraformVarMap = map[string]string{
 	"RANCHER_REPO_URL":     "rancher_repo_url",
 }
 
-var testCaseIDs []int64
+// Every spec is reported to the Qase run of the environment, once for each of its [QASE-<id>] tags and labels.
+var _ = reporting.ReportAfterEach()
 
-var _ = ReportAfterEach(func(report SpecReport) {
-	if len(testCaseIDs) == 0 {
-		return
-	}
+// The JUnit and HTML reports of the suite are written to RESULTS_DIR.
+var _ = reporting.ReportAfterSuite()
 
-	// Add result in Qase for each ID
-	for _, id := range testCaseIDs {
-		Qase(id, report)
-	}
+// A failed spec gets a diagnostics bundle of the backup-restore operator namespace, before its cleanup runs.
+var _ = JustAfterEach(func() {
+	reporting.CollectDiagnostics(client, cluster, charts.RancherBackupRestoreNamespace)
+})
 
-	// Reset after each test
-	testCaseIDs = nil
+// Skip specs whose required capabilities are not provided by the Rancher under test
+var _ = BeforeEach(func() {
+	capabilities.SkipUnsupported(capabilityEnv)
 })
 
 func FailWithReport(message string, callerSkip ...int) {
@@ -116,6 +118,10 @@ func TestE2E(t *testing.T) {
 		suiteConfig.LabelFilter = "LEVEL0"
 	}
 
+	// Every spec restores Rancher backups and the suite provisions its own Rancher, so specs can not share one.
+	if suiteConfig.ParallelTotal > 1 {
+		t.Fatal("this suite restores the whole Rancher and runs serially, run it without -p")
+	}
 	e2e.Logf("Executing tests with label '%v'", suiteConfig.LabelFilter)
 	RunSpecs(t, "Backup and Restore End-To-End Test Suite", suiteConfig, reporterConfig)
 }
@@ -177,6 +183,10 @@ var _ = BeforeSuite(func() {
 	client, err = rancher.NewClient("", testSession)
 	Expect(err).NotTo(HaveOccurred())
 
+	By("Detecting the Rancher capabilities")
+	capabilityEnv, err = capabilities.Detect(client)
+	Expect(err).NotTo(HaveOccurred())
+
 	By("Retrieving cluster metadata")
 	clusterName := client.RancherConfig.ClusterName
 	Expect(clusterName).NotTo(BeEmpty(), "Cluster name is not set")
@@ -246,6 +256,9 @@ var _ = BeforeSuite(func() {
 // AfterSuite: global teardown
 // -------------------------
 var _ = AfterSuite(func() {
+	By("Recording the Rancher and chart versions for the suite reports")
+	reporting.RecordEnvironment(client, cluster)
+
 	if BackupRestoreConfig.AccessKey != "" {
 		By("Deleting the S3 bucket")
 		err := s3Client.DeleteBucket(BackupRestoreConfig.S3BucketName)
@@ -24,6 +24,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	resources "github.com/rancher/observability-e2e/resources/rancher"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	rancher "github.com/rancher/shepherd/clients/rancher"
@@ -42,16 +43,11 @@ var _ = DescribeTable("BackupTests: ",
 			clientWithSession *rancher.Client
 			err               error
 		)
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		By("Creating a client session")
 		clientWithSession, err = client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 		By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
@@ -170,23 +166,10 @@ var _ = DescribeTable("Backup Resource Set Tests : ",
 			clientWithSession *rancher.Client
 			err               error
 		)
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		By("Creating a client session")
 		clientWithSession, err = client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		rancherVersion, err := utils.GetRancherVersion(clientWithSession)
-		Expect(err).NotTo(HaveOccurred())
-		ok, err := charts.IsVersionAtLeast(rancherVersion, 2, 11)
-		Expect(err).NotTo(HaveOccurred())
-		if !ok {
-			Skip("Skipping test as this needs rancher 2.11 or above")
-		}
-
 		By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
 		// Check if the chart is already installed
@@ -292,7 +275,7 @@ var _ = DescribeTable("Backup Resource Set Tests : ",
 	},
 
 	charts.QaseEntry("[QASE-8279] Test Rancher Backup with Basic Resource Set (should not backup secrets)",
-		[]interface{}{Label("LEVEL1", "resource-set", "basic", "backup-restore")},
+		[]interface{}{Label("LEVEL1", "resource-set", "basic", "backup-restore"), capabilities.Label(capabilities.ResourceSetBasic)},
 		charts.BackupParams{
 			StorageType: "s3",
 			BackupOptions: charts.BackupOptions{
@@ -307,7 +290,7 @@ var _ = DescribeTable("Backup Resource Set Tests : ",
 		}),
 
 	charts.QaseEntry("[QASE-8280] Test Rancher Backup with Full Resource Set (should backup secrets)",
-		[]interface{}{Label("LEVEL1", "resource-set", "full", "backup-restore")},
+		[]interface{}{Label("LEVEL1", "resource-set", "full", "backup-restore"), capabilities.Label(capabilities.ResourceSetFull)},
 		charts.BackupParams{
 			StorageType: "s3",
 			BackupOptions: charts.BackupOptions{
@@ -0,0 +1,188 @@
+/*
+Copyright © 2024 - 2025 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	bv1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	resources "github.com/rancher/observability-e2e/resources/rancher"
+	"github.com/rancher/observability-e2e/tests/helper/benchmark"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+type BenchmarkParams struct {
+	StorageType              string
+	BackupOptions            charts.BackupOptions
+	EncryptionConfigFilePath string
+}
+
+var _ = DescribeTable("Test: Rancher backup and restore benchmark",
+	func(params BenchmarkParams) {
+		if params.StorageType == "s3" && skipS3Tests {
+			Skip("Skipping S3 tests as the access key is empty.")
+		}
+
+		By("Creating a client session")
+		clientWithSession, err := client.WithSession(sess)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
+		Expect(err).NotTo(HaveOccurred())
+
+		By(fmt.Sprintf("Configuring resources for storage type: %s", params.StorageType))
+		secretName, err := charts.CreateStorageResources(params.StorageType, clientWithSession, BackupRestoreConfig)
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			By("Uninstalling the rancher backup-restore chart")
+			err := charts.UninstallBackupRestoreChart(clientWithSession, project.ClusterID, charts.RancherBackupRestoreNamespace)
+			Expect(err).NotTo(HaveOccurred())
+
+			By(fmt.Sprintf("Deleting storage resources for: %s", params.StorageType))
+			err = charts.DeleteStorageResources(params.StorageType, clientWithSession, BackupRestoreConfig)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		monitoringChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherMonitoringNamespace, charts.RancherMonitoringName)
+		Expect(err).NotTo(HaveOccurred())
+
+		installParams := charts.BackupChartInstallParams{
+			StorageType:      params.StorageType,
+			SecretName:       secretName,
+			BackupConfig:     BackupRestoreConfig,
+			ChartVersion:     utils.GetEnvOrDefault("BACKUP_RESTORE_CHART_VERSION", ""),
+			EnableMonitoring: monitoringChart.IsAlreadyInstalled,
+		}
+		By("Installing the backup and restore chart")
+		_, err = charts.InstallLatestBackupRestoreChart(clientWithSession, project, cluster, &installParams)
+		Expect(err).NotTo(HaveOccurred())
+
+		if params.BackupOptions.EncryptionConfigSecretName != "" {
+			By("Creating the encryption config secret")
+			existingSecret, err := client.Steve.SteveType("secret").ByID("cattle-resources-system/encryptionconfig")
+			if err == nil {
+				err = client.Steve.SteveType("secret").Delete(existingSecret)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, err = charts.CreateEncryptionConfigSecret(client.Steve, params.EncryptionConfigFilePath,
+				params.BackupOptions.EncryptionConfigSecretName, charts.RancherBackupRestoreNamespace)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		seedProfile, err := resources.GetSeedProfile(utils.GetEnvOrDefault("SEED_PROFILE", "medium"))
+		Expect(err).NotTo(HaveOccurred())
+		if objectCount := utils.GetEnvOrDefault("BENCHMARK_OBJECT_COUNT", ""); objectCount != "" {
+			count, err := strconv.Atoi(objectCount)
+			Expect(err).NotTo(HaveOccurred(), "BENCHMARK_OBJECT_COUNT must be a number")
+			seedProfile = resources.SeedProfileForObjectCount(count)
+		}
+
+		By(fmt.Sprintf("Seeding Rancher data with the %s profile", seedProfile.Name))
+		seedManifest, err := resources.SeedRancherData(clientWithSession, project.ClusterID, seedProfile)
+		DeferCleanup(func() {
+			By("Deleting the seeded Rancher data")
+			err := resources.DeleteSeededData(client, seedManifest)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		result := &benchmark.Result{
+			ChartVersion: installParams.ChartVersion,
+			StorageType:  params.StorageType,
+			Encrypted:    params.BackupOptions.EncryptionConfigSecretName != "",
+			SeedProfile:  seedProfile.Name,
+			ObjectCount:  len(seedManifest.Objects),
+			StartedAt:    time.Now(),
+		}
+
+		var sampler *benchmark.Sampler
+		if monitoringChart.IsAlreadyInstalled {
+			promClient, err := promclient.NewClient(promclient.RancherMonitoringURL(clientWithSession.RancherConfig.Host, project.ClusterID), clientWithSession.RancherConfig.AdminToken)
+			Expect(err).NotTo(HaveOccurred())
+			sampler = benchmark.NewSampler(promClient, charts.RancherBackupRestoreNamespace, 15*time.Second)
+			sampler.Start("backup")
+			// Stop is a no-op once the samples were taken, it only ends sampling of a failed entry
+			DeferCleanup(func() {
+				sampler.Stop()
+			})
+		} else {
+			e2e.Logf("rancher-monitoring is not installed, operator CPU and memory will not be sampled")
+		}
+
+		By("Measuring the backup")
+		backupTiming, err := benchmark.MeasureBackup(clientWithSession, params.BackupOptions)
+		Expect(err).NotTo(HaveOccurred())
+		result.Durations.BackupReadyMs = backupTiming.Ready.Milliseconds()
+		result.Durations.ArtifactUploadMs = backupTiming.Upload.Milliseconds()
+
+		By("Measuring the restore")
+		if sampler != nil {
+			sampler.SetPhase("restore")
+		}
+		restore := bv1.NewRestore("", "", charts.SetRestoreObject(params.BackupOptions.Name, true, params.BackupOptions.EncryptionConfigSecretName))
+		restore.Spec.BackupFilename = backupTiming.Filename
+		restoreDuration, err := benchmark.MeasureRestore(clientWithSession, restore)
+		Expect(err).NotTo(HaveOccurred())
+		result.Durations.RestoreReadyMs = restoreDuration.Milliseconds()
+
+		By("Measuring the time until Rancher is ready again")
+		if sampler != nil {
+			sampler.SetPhase("rancher-ready")
+		}
+		rancherReady, err := benchmark.WaitForRancherReady(clientWithSession, 15*time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		result.Durations.RancherReadyMs = rancherReady.Milliseconds()
+
+		if sampler != nil {
+			result.SetSamples(sampler.Stop())
+		}
+
+		reportPath := utils.GetEnvOrDefault("BENCHMARK_REPORT", "backup-restore-benchmark.json")
+		By(fmt.Sprintf("Writing the benchmark result to %s", reportPath))
+		err = benchmark.WriteResult(reportPath, result)
+		Expect(err).NotTo(HaveOccurred())
+		e2e.Logf("Benchmark %s: %+v", result.Key(), result.Durations)
+	},
+
+	Entry("(without encryption)", Label("benchmark", "s3"),
+		BenchmarkParams{
+			StorageType: "s3",
+			BackupOptions: charts.BackupOptions{
+				Name:           namegen.AppendRandomString("benchmark"),
+				RetentionCount: 10,
+			},
+		}),
+
+	Entry("(with encryption)", Label("benchmark", "s3"),
+		BenchmarkParams{
+			StorageType: "s3",
+			BackupOptions: charts.BackupOptions{
+				Name:                       namegen.AppendRandomString("benchmark"),
+				RetentionCount:             10,
+				EncryptionConfigSecretName: "encryptionconfig",
+			},
+			EncryptionConfigFilePath: charts.EncryptionConfigFilePath,
+		}),
+)
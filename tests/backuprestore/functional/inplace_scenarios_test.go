@@ -22,6 +22,7 @@ import (
 	bv1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	resources "github.com/rancher/observability-e2e/resources/rancher"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/snapshot"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	rancher "github.com/rancher/shepherd/clients/rancher"
 	extencharts "github.com/rancher/shepherd/extensions/charts"
@@ -39,8 +40,6 @@ type InplaceParams struct {
 	EncryptionConfigFilePath string
 }
 
-var clusterName string
-
 var _ = DescribeTable("Test: Rancher inplace backup and restore test.",
 	func(params InplaceParams) {
 		if params.StorageType == "s3" && skipS3Tests {
@@ -50,17 +49,13 @@ var _ = DescribeTable("Test: Rancher inplace backup and restore test.",
 		var (
 			clientWithSession *rancher.Client
 			err               error
+			clusterName       string
 		)
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		By("Creating a client session")
 		clientWithSession, err = client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 		By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
@@ -136,6 +131,21 @@ var _ = DescribeTable("Test: Rancher inplace backup and restore test.",
 			e2e.Logf("Successfully created encryption config secret: %s", secretName)
 		}
 
+		seedProfile, err := resources.GetSeedProfile(utils.GetEnvOrDefault("SEED_PROFILE", "small"))
+		Expect(err).NotTo(HaveOccurred())
+		By(fmt.Sprintf("Seeding Rancher data with the %s profile", seedProfile.Name))
+		seedManifest, err := resources.SeedRancherData(clientWithSession, project.ClusterID, seedProfile)
+		DeferCleanup(func() {
+			By("Deleting the seeded Rancher data")
+			err := resources.DeleteSeededData(client, seedManifest)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By(fmt.Sprintf("Capturing a snapshot of the objects covered by resource set %s", params.BackupOptions.ResourceSetName))
+		preBackupSnapshot, err := snapshot.Capture(clientWithSession, project.ClusterID, params.BackupOptions.ResourceSetName)
+		Expect(err).NotTo(HaveOccurred())
+
 		_, filename, err := charts.CreateRancherBackupAndVerifyCompleted(clientWithSession, params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(filename).To(ContainSubstring(params.BackupOptions.Name))
@@ -151,6 +161,15 @@ var _ = DescribeTable("Test: Rancher inplace backup and restore test.",
 		userListPostBackup, projListPostBackup, roleListPostBackup, err := resources.CreateRancherResources(clientWithSession, project.ClusterID, "cluster")
 		Expect(err).NotTo(HaveOccurred())
 
+		By("Creating prune sentinels inside and outside the resource set")
+		sentinels, err := snapshot.CreatePruneSentinels(clientWithSession, project.ClusterID, params.BackupOptions.ResourceSetName)
+		DeferCleanup(func() {
+			By("Deleting the prune sentinels")
+			err := snapshot.DeletePruneSentinels(client, sentinels)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(err).NotTo(HaveOccurred())
+
 		By(fmt.Sprintf("Creating a restore using backup file: %v", filename))
 		restoreTemplate := bv1.NewRestore("", "", charts.SetRestoreObject(params.BackupOptions.Name, params.Prune, params.BackupOptions.EncryptionConfigSecretName))
 		restoreTemplate.Spec.BackupFilename = filename
@@ -171,6 +190,24 @@ var _ = DescribeTable("Test: Rancher inplace backup and restore test.",
 		err = charts.VerifyRancherResources(client, userList, projList, roleList)
 		Expect(err).NotTo(HaveOccurred())
 
+		By("Validating the seeded Rancher data was restored")
+		err = snapshot.VerifyPresent(client, project.ClusterID, params.BackupOptions.ResourceSetName, seedManifest.Objects)
+		Expect(err).NotTo(HaveOccurred())
+		err = seedManifest.VerifySettings(client)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Comparing the post-restore snapshot with the pre-backup snapshot")
+		postRestoreSnapshot, err := snapshot.Capture(client, project.ClusterID, params.BackupOptions.ResourceSetName)
+		Expect(err).NotTo(HaveOccurred())
+		report, err := snapshot.Compare(preBackupSnapshot, postRestoreSnapshot, snapshot.DefaultIgnoreRules)
+		Expect(err).NotTo(HaveOccurred())
+		e2e.Logf("Restore verification report:\n%s", report)
+		Expect(report.Missing()).To(BeEmpty(), "objects present before the backup are missing after the restore")
+		Expect(report.Changed()).To(BeEmpty(), "objects differ between the backup and the restore")
+		if params.Prune {
+			Expect(report.Extra()).To(BeEmpty(), "objects created after the backup survived a restore with prune enabled")
+		}
+
 		By("Validate that rancher resources create after the backup are also exists (works if Prune is false) ")
 		err = charts.VerifyRancherResources(client, userListPostBackup, projListPostBackup, roleListPostBackup)
 		if params.Prune == true {
@@ -178,6 +215,11 @@ var _ = DescribeTable("Test: Rancher inplace backup and restore test.",
 		} else {
 			Expect(err).NotTo(HaveOccurred())
 		}
+
+		By(fmt.Sprintf("Validating the prune sentinels match prune=%t", params.Prune))
+		err = snapshot.VerifyPruneSemantics(client, sentinels, params.Prune)
+		Expect(err).NotTo(HaveOccurred())
+
 		if params.CreateCluster == true {
 			By("Validating downstream clusters are in an Active status...")
 			err = resources.VerifyCluster(client, clusterName)
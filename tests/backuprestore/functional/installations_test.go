@@ -38,16 +38,11 @@ var _ = Describe("Parameterized Backup and Restore Chart Installation Tests", fu
 				clientWithSession *rancher.Client
 				err               error
 			)
-			testName := CurrentSpecReport().LeafNodeText
-			for _, id := range charts.ExtractQaseIDs(testName) {
-				testCaseIDs = append(testCaseIDs, int64(id))
-			}
-
 			By("Creating a client session")
 			clientWithSession, err = client.WithSession(sess)
 			Expect(err).NotTo(HaveOccurred())
 
-			err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+			err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 			Expect(err).NotTo(HaveOccurred())
 			By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
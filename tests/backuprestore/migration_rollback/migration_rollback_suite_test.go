@@ -22,11 +22,12 @@ import (
 	"testing"
 	"time"
 
-	. "github.com/rancher-sandbox/qase-ginkgo"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/observability-e2e/resources"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
 	localConfig "github.com/rancher/observability-e2e/tests/helper/config"
+	"github.com/rancher/observability-e2e/tests/helper/reporting"
 	localTerraform "github.com/rancher/observability-e2e/tests/helper/terraform"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	"github.com/rancher/rancher/tests/v2/actions/pipeline"
@@ -71,6 +72,7 @@ var (
 	skipS3Tests         bool
 	CloudCredentialName string
 	CredentialConfig    *cloudcredentials.AmazonEC2CredentialConfig
+	capabilityEnv       *capabilities.Environment
 )
 
 const (
@@ -78,20 +80,15 @@ const (
 	providerName          = "aws"
 )
 
-var testCaseIDs []int64
+// Every spec is reported to the Qase run of the environment, once for each of its [QASE-<id>] tags and labels.
+var _ = reporting.ReportAfterEach()
 
-var _ = ReportAfterEach(func(report SpecReport) {
-	if len(testCaseIDs) == 0 {
-		return
-	}
-
-	// Add result in Qase for each ID
-	for _, id := range testCaseIDs {
-		Qase(id, report)
-	}
+// The JUnit and HTML reports of the suite are written to RESULTS_DIR.
+var _ = reporting.ReportAfterSuite()
 
-	// Reset after each test
-	testCaseIDs = nil
+// A failed spec gets a diagnostics bundle of the backup-restore operator namespace, before its cleanup runs.
+var _ = JustAfterEach(func() {
+	reporting.CollectDiagnostics(client, cluster, charts.RancherBackupRestoreNamespace)
 })
 
 func FailWithReport(message string, callerSkip ...int) {
@@ -111,6 +108,10 @@ func TestE2E(t *testing.T) {
 	} else {
 		suiteConfig.LabelFilter = "LEVEL0"
 	}
+	// Every spec restores Rancher backups and the suite provisions its own Rancher, so specs can not share one.
+	if suiteConfig.ParallelTotal > 1 {
+		t.Fatal("this suite restores the whole Rancher and runs serially, run it without -p")
+	}
 	e2e.Logf("Executing tests with label '%v'", suiteConfig.LabelFilter)
 	RunSpecs(t, "Rancher Migration/Rollback Test Suite", suiteConfig, reporterConfig)
 }
@@ -159,6 +160,13 @@ var _ = BeforeEach(func() {
 	client, err = rancher.NewClient("", testSession)
 	Expect(err).NotTo(HaveOccurred())
 
+	// Every spec provisions the same Rancher version, so its capabilities are detected once for the suite
+	if capabilityEnv == nil {
+		By("Detecting the Rancher capabilities")
+		capabilityEnv, err = capabilities.Detect(client)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
 	By("Retrieving cluster metadata")
 	clusterName := client.RancherConfig.ClusterName
 	Expect(clusterName).NotTo(BeEmpty(), "Cluster name to install is not set")
@@ -221,7 +229,16 @@ var _ = BeforeEach(func() {
 	}
 })
 
+// Skip specs whose required capabilities are not provided by the Rancher under test. The BeforeEach above provisions
+// the Rancher and detects its capabilities, so this one is declared after it.
+var _ = BeforeEach(func() {
+	capabilities.SkipUnsupported(capabilityEnv)
+})
+
 var _ = AfterSuite(func() {
+	By("Recording the Rancher and chart versions for the suite reports")
+	reporting.RecordEnvironment(client, cluster)
+
 	By("Destroying Terraform infrastructure")
 	if tfCtx != nil {
 		_, err := tfCtx.DestroyTarget("module.ec2.aws_instance.rke2_node")
@@ -18,7 +18,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -28,6 +27,7 @@ import (
 	"github.com/rancher/observability-e2e/tests/helper/helm"
 	localkubectl "github.com/rancher/observability-e2e/tests/helper/kubectl"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/observability-e2e/tests/helper/version"
 	"github.com/rancher/rancher/tests/v2/actions/pipeline"
 	"github.com/rancher/shepherd/clients/rancher"
 	extencharts "github.com/rancher/shepherd/extensions/charts"
@@ -53,27 +53,21 @@ const (
 	AppName  = "nginx-keep" // Name of the deployment
 )
 
-var clusterNameMigration string
-
 var _ = DescribeTable("Test: Validate the Backup and Restore Migration Scenario from RKE2 to RKE2",
 	func(params MigrationParams) {
 		By("Checking that the Terraform context is valid")
 		Expect(tfCtx).ToNot(BeNil())
 
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		var (
-			clientWithSession *rancher.Client
-			err               error
+			clientWithSession    *rancher.Client
+			err                  error
+			clusterNameMigration string
 		)
 		By("Creating a client session")
 		clientWithSession, err = client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 		By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
@@ -112,11 +106,11 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Migration Scenario
 		})
 		// use fleet to add the workload on the downstream cluster and verify it added successfully
 		By("Applying the Fleet GitRepo yaml")
-		_, err = localkubectl.Execute("apply", "-f", utils.GetYamlPath("tests/helper/yamls/fleetGitRepos.yaml"))
+		err = utils.DeployYamlResource(clientWithSession, utils.GetYamlPath("tests/helper/yamls/fleetGitRepos.yaml"), "")
 		Expect(err).NotTo(HaveOccurred(), "Failed to create fleet git repos.")
 
 		// We use our helper here to ensure everything synced correctly the first time
-		charts.VerifyFleetState(RepoName, FleetNS, AppName, AppNS)
+		charts.VerifyFleetState(clientWithSession, RepoName, FleetNS, AppName, AppNS)
 
 		// Get the latest version of the backup restore chart
 		By("Update the rancher to use the latest backup and restore chart")
@@ -187,7 +181,9 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Migration Scenario
 
 		e2e.Logf("%s", "rancher Version "+rancherVersion)
 		e2e.Logf("%s", "terraform rancher Version "+tfctRancherVersion)
-		branch := "dev-" + strings.Join(strings.Split(rancherVersion, ".")[:2], ".")
+		parsedRancherVersion, err := version.Parse(rancherVersion)
+		Expect(err).NotTo(HaveOccurred())
+		branch := "dev-v" + parsedRancherVersion.MajorMinor()
 		chartDir, err := charts.DownloadAndExtractRancherCharts(branch)
 		Expect(err).NotTo(HaveOccurred(), "Failed to download and extract repo")
 		e2e.Logf("Extracted charts directory: %s\n", chartDir)
@@ -263,7 +259,7 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Migration Scenario
 		Expect(err).NotTo(HaveOccurred(), "Downstream Cluster is not getting Active. ")
 
 		By("Verify that GitRepo was restored AND Fleet controller reconciled it again.")
-		charts.VerifyFleetState(RepoName, FleetNS, AppName, AppNS)
+		charts.VerifyFleetState(clientWithSession, RepoName, FleetNS, AppName, AppNS)
 	},
 
 	// **Test Case: Rancher inplace backup and restore test scenarios
@@ -18,7 +18,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -28,6 +27,7 @@ import (
 	"github.com/rancher/observability-e2e/tests/helper/helm"
 	localkubectl "github.com/rancher/observability-e2e/tests/helper/kubectl"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/observability-e2e/tests/helper/version"
 	"github.com/rancher/rancher/tests/v2/actions/pipeline"
 	"github.com/rancher/shepherd/clients/rancher"
 	extencharts "github.com/rancher/shepherd/extensions/charts"
@@ -46,27 +46,21 @@ type UpgradeRollbackMigrationParams struct {
 	EncryptionConfigFilePath string
 }
 
-var clusterNameRollbackMigration string
-var rollbackChartVersion string
-
 var _ = DescribeTable("Test: Validate the Backup and Restore Upgrade and Rollback Scenario from RKE2 to RKE2",
 	func(params UpgradeRollbackMigrationParams) {
 		By("Checking that the Terraform context is valid")
 		Expect(tfCtx).ToNot(BeNil())
 		var (
-			clientWithSession *rancher.Client
-			err               error
+			clientWithSession            *rancher.Client
+			err                          error
+			clusterNameRollbackMigration string
+			rollbackChartVersion         string
 		)
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		By("Creating a client session")
 		clientWithSession, err = client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 		By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
@@ -89,7 +83,7 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Upgrade and Rollbac
 		Expect(err).NotTo(HaveOccurred())
 
 		utils.SafeCleanup("Deleting the downstream clusters as part of cleanup", func() {
-			err := resources.DeleteCluster(client, clusterNameMigration)
+			err := resources.DeleteCluster(client, clusterNameRollbackMigration)
 			Expect(err).NotTo(HaveOccurred())
 		})
 		if params.CreateCluster == true {
@@ -215,10 +209,9 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Upgrade and Rollbac
 
 		// Todo Add the way to fetch the rancher version pass to install it
 		By("Checkout the charts repo based on the rancher upstream version ")
-		branch := "dev-" + strings.Join(strings.Split(upgradeRancherVersion, ".")[:2], ".")
-		if !strings.HasPrefix(branch, "dev-v") {
-			branch = strings.Replace(branch, "dev-", "dev-v", 1)
-		}
+		parsedUpgradeVersion, err := version.Parse(upgradeRancherVersion)
+		Expect(err).NotTo(HaveOccurred())
+		branch := "dev-v" + parsedUpgradeVersion.MajorMinor()
 		chartDir, err := charts.DownloadAndExtractRancherCharts(branch)
 		Expect(err).NotTo(HaveOccurred(), "Failed to download and extract repo")
 		e2e.Logf("Extracted charts directory: %s\n", chartDir)
@@ -44,8 +44,6 @@ type UpgradeRollbackParams struct {
 	EncryptionConfigFilePath string
 }
 
-var clusterName string
-
 var _ = DescribeTable("Test: Validate the Backup and Restore Upgrade and Rollback Scenario from RKE2 to RKE2",
 	func(params UpgradeRollbackParams) {
 		By("Checking that the Terraform context is valid")
@@ -53,17 +51,13 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Upgrade and Rollbac
 		var (
 			clientWithSession *rancher.Client
 			err               error
+			clusterName       string
 		)
-		testName := CurrentSpecReport().LeafNodeText
-		for _, id := range charts.ExtractQaseIDs(testName) {
-			testCaseIDs = append(testCaseIDs, int64(id))
-		}
-
 		By("Creating a client session")
 		clientWithSession, err = client.WithSession(sess)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = charts.SelectResourceSetName(clientWithSession, &params.BackupOptions)
+		err = charts.SelectResourceSetName(capabilityEnv, &params.BackupOptions)
 		Expect(err).NotTo(HaveOccurred())
 		By(fmt.Sprintf("Installing Backup Restore Chart with %s", params.StorageType))
 
@@ -86,7 +80,7 @@ var _ = DescribeTable("Test: Validate the Backup and Restore Upgrade and Rollbac
 		Expect(err).NotTo(HaveOccurred())
 
 		utils.SafeCleanup("Deleting the downstream clusters as part of cleanup", func() {
-			err := resources.DeleteCluster(client, clusterNameMigration)
+			err := resources.DeleteCluster(client, clusterName)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -15,16 +15,21 @@ limitations under the License.
 package e2e_test
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/monitoring"
 	rancher "github.com/rancher/shepherd/clients/rancher"
 	catalog "github.com/rancher/shepherd/clients/rancher/catalog"
 	extencharts "github.com/rancher/shepherd/extensions/charts"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
-var _ = Describe("Observability Upgrade Test Suite", func() {
+var _ = Describe("Observability Upgrade Test Suite", Serial, func() {
 	var clientWithSession *rancher.Client
 	var err error
 
@@ -35,7 +40,6 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 	It("[QASE-3891] Upgrade monitoring chart to the Latest Version", Label("monitoring", "afterUpgrade"), func() {
-		testCaseID = 3891
 		By("Checking if the monitoring chart is already installed")
 		initialMonitoringChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherMonitoringNamespace, charts.RancherMonitoringName)
 		Expect(err).NotTo(HaveOccurred())
@@ -62,6 +66,8 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 				Etcd:              true,
 				Proxy:             true,
 				Scheduler:         true,
+				// Keeps the Prometheus storage the beforeUpgrade spec installed the chart with
+				PrometheusStorageClass: charts.LocalStorageClassName,
 			}
 			e2e.Logf("Retrieved latest monitoring chart version to install: %v", latestMonitoringVersion)
 
@@ -70,13 +76,26 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 			if err != nil {
 				e2e.Failf("Failed to upgrade the monitoring chart. Error: %v", err)
 			}
+
+			By("Checking the monitoring data created before the upgrade")
+			fixture, err := monitoring.LoadContinuityFixture(continuityFixturePath())
+			if errors.Is(err, os.ErrNotExist) {
+				Fail(fmt.Sprintf("No continuity fixture at %s, run the beforeUpgrade monitoring spec first or set MONITORING_CONTINUITY_FIXTURE", continuityFixturePath()))
+			}
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				By("Deleting the monitoring continuity fixture")
+				Expect(fixture.Cleanup(clientWithSession)).To(Succeed())
+				Expect(os.Remove(continuityFixturePath())).To(Succeed())
+			})
+			err = fixture.AfterUpgrade(clientWithSession)
+			Expect(err).NotTo(HaveOccurred())
 		} else {
 			Skip("Monitoring is not installed. Execute the pre-upgrade installation test before attempting the upgrade")
 		}
 	})
 
 	It("[QASE-8322] Upgrade prometheus federator chart to the Latest Version", Label("promfed", "afterUpgrade"), func() {
-		testCaseID = 8322
 		By("Checking if the prometheus federator chart is already installed")
 		prometheusFederatorChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.PrometheusFederatorNamespace, charts.PrometheusFederatorName)
 		Expect(err).NotTo(HaveOccurred())
@@ -116,7 +135,6 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 	It("[QASE-3898] Upgrade logging chart to the Latest Version", Label("logging", "afterUpgrade"), func() {
-		testCaseID = 3898
 		By("Checking if the logging chart is already installed")
 		loggingChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherLoggingNamespace, charts.RancherLoggingName)
 		Expect(err).NotTo(HaveOccurred())
@@ -155,7 +173,6 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 	It("[QASE-9138] Upgrade Rancher Alert chart to the Latest Version", Label("rancher-alert", "afterUpgrade"), func() {
-		testCaseID = 9138
 
 		By("Checking if the Rancher Alert chart is already installed")
 		initialAlertChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherAlertingNamespace, charts.RancherAlertingName)
@@ -197,4 +214,38 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 		}
 	})
 
+	It("Upgrade Rancher Alert chart with the upgrade action and verify the deployed version", Label("rancher-alert", "afterUpgrade"), func() {
+
+		By("Checking if the Rancher Alert chart is already installed")
+		initialAlertChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherAlertingNamespace, charts.RancherAlertingName)
+		Expect(err).NotTo(HaveOccurred())
+		if !initialAlertChart.IsAlreadyInstalled {
+			Skip("Rancher Alert is not installed. Execute the pre-upgrade installation test before attempting the upgrade")
+		}
+
+		By("Getting the latest Rancher Alert chart version")
+		latestAlertVersion, err := clientWithSession.Catalog.GetLatestChartVersion(charts.RancherAlertingName, catalog.RancherChartRepo)
+		Expect(err).NotTo(HaveOccurred())
+
+		alertInstallOptions := &charts.InstallOptions{
+			Cluster:   cluster,
+			Version:   latestAlertVersion,
+			ProjectID: project.ID,
+		}
+		alertOpts := &charts.RancherAlertingOpts{
+			SMS:   true,
+			Teams: false,
+		}
+
+		By(fmt.Sprintf("Upgrading Rancher Alert chart to %s with the upgrade action", latestAlertVersion))
+		err = charts.UpgradeRancherAlertingChart(clientWithSession, alertInstallOptions, alertOpts)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Verifying the deployed Rancher Alert chart version")
+		alertChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherAlertingNamespace, charts.RancherAlertingName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(alertChart.IsAlreadyInstalled).To(BeTrue())
+		Expect(alertChart.ChartDetails.Spec.Chart.Metadata.Version).To(Equal(latestAlertVersion))
+	})
+
 })
@@ -16,17 +16,20 @@ package e2e_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/monitoring"
 	rancher "github.com/rancher/shepherd/clients/rancher"
 	catalog "github.com/rancher/shepherd/clients/rancher/catalog"
 	extencharts "github.com/rancher/shepherd/extensions/charts"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
-var _ = Describe("Observability Upgrade Test Suite", func() {
+var _ = Describe("Observability Upgrade Test Suite", Serial, func() {
 	var clientWithSession *rancher.Client
 	var err error
 
@@ -62,7 +65,6 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 	It("[QASE-3889] Install an older version of the Monitoring Chart", Label("monitoring", "beforeUpgrade"), func() {
-		testCaseID = 3889
 		e2e.Logf("Getting Monitoring Older Version")
 		monitoringVersionChartList, err := clientWithSession.Catalog.GetListChartVersions(charts.RancherMonitoringName, catalog.RancherChartRepo)
 		Expect(err).NotTo(HaveOccurred())
@@ -85,18 +87,36 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 			Etcd:              true,
 			Proxy:             true,
 			Scheduler:         true,
+			// The samples of the continuity fixture must survive the restart of Prometheus during the upgrade
+			PrometheusStorageClass: charts.LocalStorageClassName,
 		}
 		e2e.Logf("Retrieved monitoring chart version to install: %v", monitoringVersion)
 
+		By("Creating the local storage for the Prometheus TSDB")
+		err = charts.DeployLocalStorage(clientWithSession)
+		Expect(err).NotTo(HaveOccurred())
+
 		By(fmt.Sprintf("Installing monitoring chart with an %v version", monitoringVersion))
 		err = charts.InstallRancherMonitoringChart(clientWithSession, monitoringInstOpts, monitoringOpts)
 		if err != nil {
 			e2e.Failf("Failed to install the monitoring chart. Error: %v", err)
 		}
+
+		By("Creating the monitoring data that must survive the upgrade")
+		fixture, err := monitoring.BeforeUpgrade(clientWithSession, project.ClusterID)
+		// The fixture is kept for the afterUpgrade run, unless this spec fails before saving it
+		DeferCleanup(func() {
+			if fixture != nil && CurrentSpecReport().Failed() {
+				By("Deleting the monitoring continuity fixture of the failed spec")
+				Expect(fixture.Cleanup(clientWithSession)).To(Succeed())
+			}
+		})
+		Expect(err).NotTo(HaveOccurred())
+		err = fixture.Save(continuityFixturePath())
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	It("[QASE-8321] Install an older version of the prometheus federator chart", Label("promfed", "beforeUpgrade"), func() {
-		testCaseID = 8321
 		e2e.Logf("Getting prometheus federator Older Version")
 		promFedChartList, err := clientWithSession.Catalog.GetListChartVersions(charts.PrometheusFederatorName, catalog.RancherChartRepo)
 
@@ -128,7 +148,6 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 	It("[QASE-3896] Install an older version of the Logging chart", Label("logging", "beforeUpgrade"), func() {
-		testCaseID = 3896
 
 		e2e.Logf("Getting Logging Older Version")
 		LoggingVersionChartList, err := clientWithSession.Catalog.GetListChartVersions(charts.RancherLoggingName, catalog.RancherChartRepo)
@@ -160,7 +179,6 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 	It(" [QASE-9137] Install an older version of the Rancher Alert Chart", Label("rancher-alert", "beforeUpgrade"), func() {
-		testCaseID = 9137
 		e2e.Logf("Getting Rancher Alert Older Version")
 		alertVersionChartList, err := clientWithSession.Catalog.GetListChartVersions(charts.RancherAlertingName, catalog.RancherChartRepo)
 		Expect(err).NotTo(HaveOccurred())
@@ -193,3 +211,12 @@ var _ = Describe("Observability Upgrade Test Suite", func() {
 	})
 
 })
+
+// continuityFixturePath is where the before upgrade run leaves the monitoring continuity fixture for the after
+// upgrade run. MONITORING_CONTINUITY_FIXTURE overrides it when the two runs do not share a temp directory.
+func continuityFixturePath() string {
+	if path := os.Getenv("MONITORING_CONTINUITY_FIXTURE"); path != "" {
+		return path
+	}
+	return filepath.Join(os.TempDir(), "monitoring-continuity.json")
+}
@@ -33,7 +33,7 @@ const (
 	syslogResourceYamlPath = "../helper/yamls/syslogResources.yaml"
 )
 
-var _ = Describe("Observability Installation Test Suite", func() {
+var _ = Describe("Observability Installation Test Suite", Serial, func() {
 	var clientWithSession *rancher.Client
 	var err error
 
@@ -44,7 +44,6 @@ var _ = Describe("Observability Installation Test Suite", func() {
 	})
 
 	It("[QASE-3909] Install monitoring chart", Label("LEVEL0", "monitoring", "installation"), func() {
-		testCaseID = 3909
 		By("Checking if the monitoring chart is already installed")
 		initialMonitoringChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherMonitoringNamespace, charts.RancherMonitoringName)
 		Expect(err).NotTo(HaveOccurred())
@@ -123,7 +122,6 @@ var _ = Describe("Observability Installation Test Suite", func() {
 	})
 
 	It("[QASE-3912] Install Alerting chart", Label("LEVEL0", "alerting", "installation"), func() {
-		testCaseID = 3912
 		alertingChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherAlertingNamespace, charts.RancherAlertingName)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -181,7 +179,6 @@ var _ = Describe("Observability Installation Test Suite", func() {
 	})
 
 	It("[QASE-3484] Install Logging chart", Label("LEVEL0", "logging", "installation"), func() {
-		testCaseID = 3484
 		loggingChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherLoggingNamespace, charts.RancherLoggingName)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -252,7 +249,6 @@ var _ = Describe("Observability Installation Test Suite", func() {
 	})
 
 	It("[QASE-3486] Install Syslog resources to capture rancher logging logs", Label("LEVEL0", "Syslog", "installation"), func() {
-		testCaseID = 3486
 		By("1) Deploying syslog deployment/service/config map resources")
 		deploySyslogError := utils.DeploySyslogResources(clientWithSession, syslogResourceYamlPath)
 		if deploySyslogError != nil {
@@ -264,7 +260,6 @@ var _ = Describe("Observability Installation Test Suite", func() {
 	})
 
 	It("[QASE-5582] Install Prometheus Federator chart", Label("LEVEL0", "promfed", "installation"), func() {
-		testCaseID = 5582
 		By("1) verify if prometheus federator chart is already installed")
 		prometheusFederatorChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.PrometheusFederatorNamespace, charts.PrometheusFederatorName)
 		Expect(err).NotTo(HaveOccurred())
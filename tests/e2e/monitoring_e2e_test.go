@@ -1,50 +1,40 @@
 package e2e_test
 
 import (
+	"context"
 	"encoding/json"
-	"regexp"
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/rancher/observability-e2e/tests/helper/alertmanager"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/monitoring"
+	"github.com/rancher/observability-e2e/tests/helper/probe"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	rancher "github.com/rancher/shepherd/clients/rancher"
-	"github.com/rancher/shepherd/extensions/kubectl"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
-// Define the struct for the Alert
-type Alert struct {
-	Annotations  map[string]string `json:"annotations"`
-	EndsAt       string            `json:"endsAt"`
-	Fingerprint  string            `json:"fingerprint"`
-	Receivers    []Receiver        `json:"receivers"`
-	StartsAt     string            `json:"startsAt"`
-	Status       AlertStatus       `json:"status"`
-	UpdatedAt    string            `json:"updatedAt,omitempty"`
-	GeneratorURL string            `json:"generatorURL,omitempty"`
-	Labels       map[string]string `json:"labels"`
-}
-
-// Define the struct for Receiver
-type Receiver struct {
-	Name string `json:"name"`
-}
-
-// Define the struct for AlertStatus
-type AlertStatus struct {
-	InhibitedBy []string `json:"inhibitedBy"`
-	SilencedBy  []string `json:"silencedBy"`
-	State       string   `json:"state"`
-}
-
 const (
 	defaultRandStringLength  = 5
 	prometheusRulesSteveType = "monitoring.coreos.com.prometheusrule"
-	prometheusRuleFilePath   = "../helper/yamls/createPrometheusRule.yaml"
+	prometheusRuleFilePath   = "../helper/yamls/createPrometheusRule.template.yaml"
+	monitoringNamespace      = "cattle-monitoring-system"
+	alertmanagerAlertsURL    = "http://rancher-monitoring-alertmanager.cattle-monitoring-system:9093/api/v2/alerts"
 )
 
+var prometheusRuleGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules"}
+
 var _ = Describe("Observability Monitoring E2E Test Suite", func() {
 	var clientWithSession *rancher.Client //RancherConfig *Config
 
@@ -55,41 +45,36 @@ var _ = Describe("Observability Monitoring E2E Test Suite", func() {
 	})
 
 	It("[QASE-3911] Test : Verify Creating prometheus rule using kubectl", Label("LEVEL1", "monitoring", "E2E", "PromFed"), func() {
-		testCaseID = 3911
 		By("1) Apply yaml to create prometheus rule")
-		prometheusError := utils.DeployPrometheusRule(clientWithSession, prometheusRuleFilePath)
-		Expect(prometheusError).To(BeNil(), "Failed to deploy Prometheus rule")
+		rule := deployPrometheusRule(clientWithSession)
 
 		By("2) Fetch all the prometheus rule")
-		fetchPrometheusRules := []string{"kubectl", "get", "prometheusRule", "test-prometheus-rule", "-n", "cattle-monitoring-system"}
-		verifyPetchPrometheusRules, err := kubectl.Command(clientWithSession, nil, "local", fetchPrometheusRules, "")
-		Expect(err).NotTo(HaveOccurred(), "Failed to fetch PrometheusRule 'test-prometheus-rule'. Error: %v", err)
-		Expect(verifyPetchPrometheusRules).NotTo(BeEmpty(), "Failed to fetch PrometheusRule: expected non-empty response")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		prometheusRule, err := kubeClient.Get(context.TODO(), prometheusRuleGVR, monitoringNamespace, rule.Name)
+		Expect(err).NotTo(HaveOccurred(), "Failed to fetch PrometheusRule '%s'. Error: %v", rule.Name, err)
+		Expect(prometheusRule.Object).NotTo(BeEmpty(), "Failed to fetch PrometheusRule: expected non-empty response")
 	})
 
 	It("[QASE-6825] Test : Verify default Watchdog alert is present", Label("LEVEL1", "monitoring", "E2E"), func() {
-		testCaseID = 6825
-		By("1) Create a container to access curl")
-		creatContainer := []string{"kubectl", "run", "test", "--image=ranchertest/mytestcontainer", "-n", "default"}
-		_, err := kubectl.Command(clientWithSession, nil, "local", creatContainer, "")
-		Expect(err).NotTo(HaveOccurred(), "Failed to create container")
-
-		time.Sleep(30 * time.Second)
+		By("1) Create a probe pod to access Alertmanager")
+		probePod, err := probe.Start(clientWithSession, kube.LocalCluster, probe.Options{Namespace: spec.Namespace(), NamePrefix: "alerts-probe"})
+		Expect(err).NotTo(HaveOccurred(), "Failed to create probe pod")
 
-		By("2) Fetching alerts via Curl request")
-		curl := []string{"kubectl", "exec", "test", "-n", "default", "--", "curl", "-s", "http://rancher-monitoring-alertmanager.cattle-monitoring-system:9093/api/v2/alerts"}
-		output, err := kubectl.Command(clientWithSession, nil, "local", curl, "")
-		Expect(err).NotTo(HaveOccurred(), "Failed to get curl response")
-		output = strings.TrimSpace(output)
-		Expect(output).NotTo(BeEmpty(), "Received empty curl response")
+		By("2) Fetching alerts via HTTP request")
+		response, err := probePod.HTTPGet(alertmanagerAlertsURL)
+		Expect(err).NotTo(HaveOccurred(), "Failed to get HTTP response")
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+		output := strings.TrimSpace(string(response.Body))
+		Expect(output).NotTo(BeEmpty(), "Received empty HTTP response")
 
 		By("3) Unmarshalling json output response")
-		var alerts []Alert
+		var alerts []alertmanager.GettableAlert
 		err = json.Unmarshal([]byte(output), &alerts)
 		Expect(err).NotTo(HaveOccurred(), "Failed to unmarshal JSON response")
 
 		By("4) Search for the Watchdog alert")
-		var watchdogAlert *Alert
+		var watchdogAlert *alertmanager.GettableAlert
 		for _, alert := range alerts {
 			if alert.Labels["alertname"] == "Watchdog" {
 				watchdogAlert = &alert
@@ -99,129 +84,83 @@ var _ = Describe("Observability Monitoring E2E Test Suite", func() {
 
 		By("5)Assert if the Watchdog alert was found")
 		Expect(watchdogAlert).NotTo(BeNil(), "Expected 'Watchdog' alert not found in response")
-
-		defer func() {
-			By("6) Deleting the container")
-			deleteContainer := []string{"kubectl", "delete", "pod", "test", "-n", "default"}
-			deleteConfirm, err := kubectl.Command(clientWithSession, nil, "local", deleteContainer, "")
-			Expect(err).NotTo(HaveOccurred(), "Failed to delete container")
-			Expect(deleteConfirm).To(ContainSubstring("pod \"test\" deleted"), "Failed to verify container is deleted")
-		}()
 	})
 
 	It("[QASE-6826] Test : Verify status of rancher-monitoring pods using kubectl", Label("LEVEL1", "monitoring", "E2E"), func() {
-		testCaseID = 6826
 		By("0) Fetch all the pods belongs to rancher-monitoring")
-		fetchPods := []string{"kubectl", "get", "pods", "-n", "cattle-monitoring-system", "--no-headers"}
-		rancherMonitoringPods, err := kubectl.Command(clientWithSession, nil, "local", fetchPods, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		pods, err := kubeClient.Clientset.CoreV1().Pods(monitoringNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get pods")
 
 		By("1) Read all the pods and verify the status of rancher-monitoring-Pods")
-		pods := strings.Split(rancherMonitoringPods, "\n")
-		for _, pod := range pods {
-			if pod == "" {
-				continue
-			}
-
-			fields := strings.Fields(pod) // Split the line into pod name and its current status
-			Expect(len(fields)).To(BeNumerically(">", 2), "Unexpected output format for pod: %s", pod)
-
-			podName := fields[0]
-			podStatus := fields[2]
-
-			Expect(podStatus).To(Equal("Running"), "Pod %s is not in 'Running' state, current state: %s", podName, podStatus)
+		for _, pod := range pods.Items {
+			Expect(pod.Status.Phase).To(Equal(corev1.PodRunning), "Pod %s is not in 'Running' state, current state: %s", pod.Name, pod.Status.Phase)
 		}
 	})
 
 	It("[QASE-6827] Test : Verify status of rancher-monitoring Deployments using kubectl", Label("LEVEL1", "monitoring", "E2E"), func() {
-		testCaseID = 6827
 		By("0) Fetch all the deployments belonging to rancher-monitoring")
-		fetchDeployments := []string{"kubectl", "get", "deployments", "-n", "cattle-monitoring-system", "--no-headers"}
-		rancherMonitoringDeployments, err := kubectl.Command(clientWithSession, nil, "local", fetchDeployments, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		deployments, err := kubeClient.Clientset.AppsV1().Deployments(monitoringNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get deployments")
 
 		By("1) Read all the deployments and verify the status of rancher-monitoring deployments")
-		deployments := strings.Split(rancherMonitoringDeployments, "\n")
-		for _, deployment := range deployments {
-			if deployment == "" {
-				continue
-			}
-
-			fields := strings.Fields(deployment) // Split the line into deployment name and its current status
-			Expect(len(fields)).To(BeNumerically(">", 3), "Unexpected output format for deployment: %s", deployment)
-
-			deploymentName := fields[0]
-			readyReplicas := fields[1]
-			availableReplicas := fields[3]
+		for _, deployment := range deployments.Items {
+			desiredCount := *deployment.Spec.Replicas
 
-			readyCount := strings.Split(readyReplicas, "/")[0]
-			desiredCount := strings.Split(readyReplicas, "/")[1]
+			Expect(deployment.Status.AvailableReplicas).To(Equal(desiredCount), "Deployment %s is not fully available. Desired: %d, Available: %d", deployment.Name, desiredCount, deployment.Status.AvailableReplicas)
 
-			Expect(availableReplicas).To(Equal(desiredCount), "Deployment %s is not fully available. Desired: %s, Available: %s", deploymentName, desiredCount, availableReplicas)
-
-			Expect(readyCount).To(Equal(desiredCount), "Deployment %s is not fully ready. Desired: %s, Ready: %s", deploymentName, desiredCount, readyCount)
+			Expect(deployment.Status.ReadyReplicas).To(Equal(desiredCount), "Deployment %s is not fully ready. Desired: %d, Ready: %d", deployment.Name, desiredCount, deployment.Status.ReadyReplicas)
 		}
 	})
 
 	It("[QASE-6830] Test : Verify status of rancher-monitoring DaemonSets using kubectl", Label("LEVEL1", "monitoring", "E2E"), func() {
-		testCaseID = 6830
 		By("0) Fetch all the daemon sets belongs to rancher-monitoring")
-		fetchPods := []string{"kubectl", "get", "daemonsets", "-n", "cattle-monitoring-system", "--no-headers"}
-		rancherMonitoringDaemonSets, err := kubectl.Command(clientWithSession, nil, "local", fetchPods, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		daemonSets, err := kubeClient.Clientset.AppsV1().DaemonSets(monitoringNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get daemonsets")
 
 		By("1) Read all the daemonSet and verify the status of rancher-monitoring-daemonSets")
-		daemonSets := strings.Split(rancherMonitoringDaemonSets, "\n")
-		for _, daemonSet := range daemonSets {
-			if daemonSet == "" {
-				continue
-			}
+		for _, daemonSet := range daemonSets.Items {
+			desiredPods := daemonSet.Status.DesiredNumberScheduled
 
-			fields := strings.Fields(daemonSet) // Split the line into pod name and its current status
-			Expect(len(fields)).To(BeNumerically(">", 5), "Unexpected output format for daemonSet: %s", daemonSet)
+			Expect(daemonSet.Status.NumberAvailable).To(Equal(desiredPods), "DaemonSet %s is not fully available. Desired: %d, Available: %d", daemonSet.Name, desiredPods, daemonSet.Status.NumberAvailable)
 
-			daemonSetName := fields[0]
-			desiredPods := fields[1]
-			readyPods := fields[3]
-			availablePods := fields[5]
-
-			Expect(availablePods).To(Equal(desiredPods), "DaemonSet %s is not fully available. Desired: %s, Available: %s", daemonSetName, desiredPods, availablePods)
-
-			Expect(readyPods).To(Equal(desiredPods), "DaemonSet %s is not fully ready. Desired: %s, Ready: %s", daemonSetName, desiredPods, readyPods)
+			Expect(daemonSet.Status.NumberReady).To(Equal(desiredPods), "DaemonSet %s is not fully ready. Desired: %d, Ready: %d", daemonSet.Name, desiredPods, daemonSet.Status.NumberReady)
 		}
 	})
 
 	It("[QASE-6829] Test: Verify newly created Prometheus rule alert is present", Label("LEVEL1", "monitoring", "E2E", "PromFed"), func() {
-		testCaseID = 6829
-		By("1) Creating a container for curl access")
-		createContainerCommand := []string{"kubectl", "run", "curl-container", "--image=ranchertest/mytestcontainer", "-n", "default"}
-		_, err := kubectl.Command(clientWithSession, nil, "local", createContainerCommand, "")
-		Expect(err).NotTo(HaveOccurred(), "Failed to create container")
-
-		// Wait for the container to be ready
-		var prometheusRuleAlert *Alert
-		var maxRetries = 4
-		var retryInterval = 20 * time.Second
+		By("1) Creating a prometheus rule with an alert that always fires")
+		rule := deployPrometheusRule(clientWithSession)
+
+		By("2) Creating a probe pod to access Alertmanager")
+		probePod, err := probe.Start(clientWithSession, kube.LocalCluster, probe.Options{Namespace: spec.Namespace(), NamePrefix: "alerts-probe"})
+		Expect(err).NotTo(HaveOccurred(), "Failed to create probe pod")
+
+		// The pod is ready, so only the alert needs time to fire
+		var prometheusRuleAlert *alertmanager.GettableAlert
+		var maxRetries = 6
+		var retryInterval = 50 * time.Second
 		var attempt = 0
 
 		for attempt < maxRetries {
-			time.Sleep(30 * time.Second)
-			By("2) Fetching alerts using curl request")
-			curlCommand := []string{"kubectl", "exec", "curl-container", "-n", "default", "--", "curl", "-s", "http://rancher-monitoring-alertmanager.cattle-monitoring-system:9093/api/v2/alerts"}
-			curlResponse, err := kubectl.Command(clientWithSession, nil, "local", curlCommand, "")
-			Expect(err).NotTo(HaveOccurred(), "Failed to get curl response")
-			curlResponse = strings.TrimSpace(curlResponse)
-
-			By("3) Unmarshalling JSON response")
-			var alerts []Alert
+			By("3) Fetching alerts using HTTP request")
+			response, err := probePod.HTTPGet(alertmanagerAlertsURL)
+			Expect(err).NotTo(HaveOccurred(), "Failed to get HTTP response")
+			curlResponse := strings.TrimSpace(string(response.Body))
+
+			By("4) Unmarshalling JSON response")
+			var alerts []alertmanager.GettableAlert
 			err = json.Unmarshal([]byte(curlResponse), &alerts)
 			Expect(err).NotTo(HaveOccurred(), "Failed to unmarshal JSON response")
 
-			alertNamePattern := regexp.MustCompile("test-qa")
-
-			By("4) Searching for the newly created Prometheus rule alert")
+			By("5) Searching for the newly created Prometheus rule alert")
 			for _, alert := range alerts {
-				if alertNamePattern.MatchString(alert.Labels["alertname"]) {
+				if alert.Labels["alertname"] == rule.Name {
 					prometheusRuleAlert = &alert
 					break
 				}
@@ -240,16 +179,102 @@ var _ = Describe("Observability Monitoring E2E Test Suite", func() {
 			}
 		}
 
-		By("5) Verifying if the Prometheus rule alert was found")
+		By("6) Verifying if the Prometheus rule alert was found")
 		Expect(prometheusRuleAlert).NotTo(BeNil(), "Expected Prometheus rule alert not found in the response")
-
-		defer func() {
-			By("6) Deleting the test container")
-			deleteContainerCommand := []string{"kubectl", "delete", "pod", "curl-container", "-n", "default"}
-			deleteResponse, err := kubectl.Command(clientWithSession, nil, "local", deleteContainerCommand, "")
-			Expect(err).NotTo(HaveOccurred(), "Failed to delete container")
-			Expect(deleteResponse).To(ContainSubstring("pod \"curl-container\" deleted"), "Failed to verify container is deleted")
-		}()
 	})
 
+	DescribeTable("Test : Verify control plane exporters of the cluster provider",
+		func(disabled string) {
+			exporters, err := monitoring.Exporters(cluster.Provider)
+			if err != nil {
+				Skip(fmt.Sprintf("Cluster %s has provider %q, hosted and generic imported clusters run no control plane exporters", cluster.Name, cluster.Provider))
+			}
+
+			By("1) Reading the exporter options from the installed rancher-monitoring app")
+			monitoringChart, err := extencharts.GetChartStatus(clientWithSession, cluster.ID, charts.RancherMonitoringNamespace, charts.RancherMonitoringName)
+			Expect(err).NotTo(HaveOccurred())
+			if !monitoringChart.IsAlreadyInstalled {
+				Skip("rancher-monitoring is not installed, run the installation specs first")
+			}
+			app := monitoringChart.ChartDetails
+			monitoringOpts := charts.RancherMonitoringOptsFromValues(cluster.Provider, app.Spec.Values)
+			e2e.Logf("rancher-monitoring %s exporter options: %+v", app.Spec.Chart.Metadata.Version, *monitoringOpts)
+
+			if disabled != "" {
+				exporter, ok := exporterOf(exporters, disabled)
+				if !ok {
+					Skip(fmt.Sprintf("%s clusters have no %s exporter", cluster.Provider, disabled))
+				}
+				if exporter.Shared {
+					Skip(fmt.Sprintf("%s is scraped with other components under job %s on %s clusters, so it can not be told apart when disabled", disabled, exporter.Job, cluster.Provider))
+				}
+
+				installOptions := &charts.InstallOptions{
+					Cluster:   cluster,
+					Version:   app.Spec.Chart.Metadata.Version,
+					ProjectID: project.ID,
+				}
+				installedOpts := *monitoringOpts
+				monitoringOpts = withExporter(monitoringOpts, disabled, false)
+
+				By(fmt.Sprintf("Upgrading rancher-monitoring with the %s exporter disabled", disabled))
+				Expect(charts.UpgradeRancherMonitoringChart(clientWithSession, installOptions, monitoringOpts)).To(Succeed())
+				DeferCleanup(func() {
+					By(fmt.Sprintf("Restoring the %s exporter of rancher-monitoring", disabled))
+					Expect(charts.UpgradeRancherMonitoringChart(clientWithSession, installOptions, &installedOpts)).To(Succeed())
+				})
+			}
+
+			By("2) Creating a Prometheus client")
+			promClient, err := promclient.NewClient(promclient.RancherMonitoringURL(clientWithSession.RancherConfig.Host, cluster.ID), clientWithSession.RancherConfig.AdminToken)
+			Expect(err).NotTo(HaveOccurred())
+
+			By(fmt.Sprintf("3) Verifying the %s control plane exporters are scraped and emit their metrics", cluster.Provider))
+			Eventually(func() ([]string, error) {
+				return monitoring.VerifyExporters(promClient, cluster.Provider, monitoringOpts)
+			}, 5*time.Minute, 30*time.Second).Should(BeEmpty(), "control plane exporters do not match the chart values")
+		},
+		Label("LEVEL1", "monitoring", "E2E", "exporters"),
+
+		Entry("as installed", ""),
+		// Reconfiguring rancher-monitoring affects every spec using it, so this entry runs on its own
+		Entry("with the scheduler exporter disabled", Serial, "scheduler"),
+	)
+
 })
+
+// exporterOf returns the exporter of a component.
+func exporterOf(exporters []monitoring.Exporter, component string) (monitoring.Exporter, bool) {
+	for _, exporter := range exporters {
+		if exporter.Component == component {
+			return exporter, true
+		}
+	}
+	return monitoring.Exporter{}, false
+}
+
+// withExporter returns a copy of opts with the exporter of a component, named as its json field, switched on or off.
+func withExporter(opts *charts.RancherMonitoringOpts, component string, enabled bool) *charts.RancherMonitoringOpts {
+	data, err := json.Marshal(opts)
+	Expect(err).NotTo(HaveOccurred())
+	values := map[string]bool{}
+	Expect(json.Unmarshal(data, &values)).To(Succeed())
+	Expect(values).To(HaveKey(component))
+	values[component] = enabled
+	data, err = json.Marshal(values)
+	Expect(err).NotTo(HaveOccurred())
+	updated := &charts.RancherMonitoringOpts{}
+	Expect(json.Unmarshal(data, updated)).To(Succeed())
+	return updated
+}
+
+// deployPrometheusRule deploys the prometheus rule fixture under a name of its own, which is also the name of its
+// alert, and deletes it when the spec ends.
+func deployPrometheusRule(clientWithSession *rancher.Client) *utils.Fixture {
+	rule := utils.NewFixture("test-prometheus-rule")
+	Expect(utils.DeployPrometheusRule(clientWithSession, prometheusRuleFilePath, rule)).To(Succeed(), "Failed to deploy Prometheus rule")
+	DeferCleanup(func() {
+		Expect(utils.DeleteYamlTemplate(clientWithSession, prometheusRuleFilePath, rule, "")).To(Succeed())
+	})
+	return rule
+}
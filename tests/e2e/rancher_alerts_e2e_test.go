@@ -1,43 +1,29 @@
 package e2e_test
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/rancher/observability-e2e/tests/helper/alerting"
+	"github.com/rancher/observability-e2e/tests/helper/alertmanager"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	rancher "github.com/rancher/shepherd/clients/rancher"
-	"github.com/rancher/shepherd/extensions/kubectl"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
-// Define the struct for the Alert
-type RancherAlert struct {
-	Annotations  map[string]string  `json:"annotations"`
-	EndsAt       string             `json:"endsAt"`
-	Fingerprint  string             `json:"fingerprint"`
-	Receivers    []RancherReceiver  `json:"receivers"`
-	StartsAt     string             `json:"startsAt"`
-	Status       RancherAlertStatus `json:"status"`
-	UpdatedAt    string             `json:"updatedAt,omitempty"`
-	GeneratorURL string             `json:"generatorURL,omitempty"`
-	Labels       map[string]string  `json:"labels"`
-}
-
-// Define the struct for Receiver
-type RancherReceiver struct {
-	Name string `json:"name"`
-}
-
-// Define the struct for AlertStatus
-type RancherAlertStatus struct {
-	InhibitedBy []string `json:"inhibitedBy"`
-	SilencedBy  []string `json:"silencedBy"`
-	State       string   `json:"state"`
-}
-
 const (
-	alertmanagerConfigFilePath = "../helper/yamls/alertManagerConfig.yaml"
+	alertmanagerConfigFilePath = "../helper/yamls/alertManagerConfig.template.yaml"
 )
 
 var _ = Describe("Observability Alerting E2E Test Suite", func() {
@@ -50,64 +36,55 @@ var _ = Describe("Observability Alerting E2E Test Suite", func() {
 	})
 
 	It("[QASE-6831] Test : Verify status of rancher-alert Deployments using kubectl", Label("LEVEL1", "alerts", "E2E"), func() {
-		testCaseID = 6831
 		By("1) Fetch all the deployments belonging to rancher-alerts")
-		fetchDeployments := []string{"kubectl", "get", "deployments", "-n", "cattle-monitoring-system", "--no-headers"}
-		rancherAlertsDeployments, err := kubectl.Command(clientWithSession, nil, "local", fetchDeployments, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		deployments, err := kubeClient.List(context.TODO(), kube.DeploymentsGVR, monitoringNamespace, metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get deployments")
 
 		By("2) Read all the deployments and verify the status of rancher-alerts deployments")
 		foundRancherAlerting := false
-		deployments := strings.Split(rancherAlertsDeployments, "\n")
 		for _, deployment := range deployments {
-			if deployment == "" {
+			deploymentName := deployment.GetName()
+			if !strings.HasPrefix(deploymentName, "rancher-alerting") {
 				continue
 			}
+			foundRancherAlerting = true
 
-			fields := strings.Fields(deployment)
-			Expect(len(fields)).To(BeNumerically(">=", 4), "Unexpected output format for deployment: %s", deployment)
-
-			deploymentName := fields[0]
-			readyReplicas := fields[1]
-			availableReplicas := fields[3]
-
-			readyCount := strings.Split(readyReplicas, "/")[0]
-			desiredCount := strings.Split(readyReplicas, "/")[1]
-
-			if strings.HasPrefix(deploymentName, "rancher-alerting") {
-				foundRancherAlerting = true
-				Expect(availableReplicas).To(Equal(desiredCount), "Deployment %s is not fully available. Desired: %s, Available: %s", deploymentName, desiredCount, availableReplicas)
-				Expect(readyCount).To(Equal(desiredCount), "Deployment %s pods are not fully ready. Desired: %s, Ready: %s", deploymentName, desiredCount, readyCount)
+			desiredCount, found, err := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+			Expect(err).NotTo(HaveOccurred())
+			if !found {
+				desiredCount = 1
 			}
+			availableCount, _, _ := unstructured.NestedInt64(deployment.Object, "status", "availableReplicas")
+			readyCount, _, _ := unstructured.NestedInt64(deployment.Object, "status", "readyReplicas")
+			Expect(availableCount).To(Equal(desiredCount), "Deployment %s is not fully available. Desired: %d, Available: %d", deploymentName, desiredCount, availableCount)
+			Expect(readyCount).To(Equal(desiredCount), "Deployment %s pods are not fully ready. Desired: %d, Ready: %d", deploymentName, desiredCount, readyCount)
 		}
 		Expect(foundRancherAlerting).To(BeTrue(), "No deployments found starting with 'rancher-alerting'")
 	})
 
 	It("[QASE-6832] Test : Verify status of rancher-alerts pods using kubectl", Label("LEVEL1", "alerts", "E2E"), func() {
-		testCaseID = 6832
 		By("1) Fetch all the pods belongs to rancher-alerts")
-		fetchPods := []string{"kubectl", "get", "pods", "-n", "cattle-monitoring-system", "--no-headers"}
-		rancherAlertsPods, err := kubectl.Command(clientWithSession, nil, "local", fetchPods, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		pods, err := kubeClient.List(context.TODO(), kube.PodsGVR, monitoringNamespace, metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get pods")
 
 		By("2) Read all the pods and verify the status of rancher-alerts-Pods")
 		rancherAlertingFoundPod := false
 		alertmanagerFoundPod := false
-		pods := strings.Split(rancherAlertsPods, "\n")
 		for _, pod := range pods {
-			if pod == "" {
+			podName := pod.GetName()
+			podPhase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+			if podPhase != string(corev1.PodRunning) {
 				continue
 			}
-			fields := strings.Fields(pod) // Split the line into pod name and its current status
-			Expect(len(fields)).To(BeNumerically(">=", 3), "Unexpected output format for pod: %s", pod)
-
-			podName := fields[0]
-			podStatus := fields[2]
 
-			if strings.HasPrefix(podName, "rancher-alerting") && podStatus == "Running" {
+			if strings.HasPrefix(podName, "rancher-alerting") {
 				rancherAlertingFoundPod = true
 			}
-			if strings.HasPrefix(podName, "alertmanager") && podStatus == "Running" {
+			if strings.HasPrefix(podName, "alertmanager") {
 				alertmanagerFoundPod = true
 			}
 		}
@@ -117,19 +94,316 @@ var _ = Describe("Observability Alerting E2E Test Suite", func() {
 	})
 
 	It("[QASE-6833] Test : Verify Creating alert manager config using kubectl", Label("LEVEL1", "alerts", "E2E", "AMC"), func() {
-		testCaseID = 6833
 		By("1) Apply yaml to create alert manager config")
-		alertManagerConfigError := utils.DeployAlertManagerConfig(clientWithSession, alertmanagerConfigFilePath)
+		amc := utils.NewFixture("amc")
+		alertManagerConfigError := utils.DeployAlertManagerConfig(clientWithSession, alertmanagerConfigFilePath, amc)
 		if alertManagerConfigError != nil {
 			e2e.Logf("Failed to deploy AMC rule: %v", alertManagerConfigError)
 		}
+		DeferCleanup(func() {
+			Expect(utils.DeleteYamlTemplate(clientWithSession, alertmanagerConfigFilePath, amc, "")).To(Succeed())
+		})
 
 		By("2) Fetch all the AMC")
-		fetchAlertManagerConfig := []string{"kubectl", "get", "AlertmanagerConfig", "amc", "-n", "cattle-monitoring-system"}
-		verifyAlertManagerConfig, err := kubectl.Command(clientWithSession, nil, "local", fetchAlertManagerConfig, "")
-		Expect(err).NotTo(HaveOccurred(), "Failed to fetch alert manager config 'amc'")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		verifyAlertManagerConfig, err := kubeClient.Get(context.TODO(), alertmanager.AlertmanagerConfigGVR, monitoringNamespace, amc.Name)
+		Expect(err).NotTo(HaveOccurred(), "Failed to fetch alert manager config '%s'", amc.Name)
+
+		e2e.Logf("Successfully fetched AMC: %v", verifyAlertManagerConfig.GetName())
+	})
+
+	It("Test : Verify SMS and Teams notifications are delivered by the alerting drivers", Label("LEVEL1", "alerts", "E2E", "delivery"), Serial, func() {
+		alertingChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherAlertingNamespace, charts.RancherAlertingName)
+		Expect(err).NotTo(HaveOccurred())
+		if !alertingChart.IsAlreadyInstalled {
+			Skip("rancher-alerting-drivers is not installed")
+		}
+		installOptions := &charts.InstallOptions{
+			Cluster:   cluster,
+			Version:   alertingChart.ChartDetails.Spec.Chart.Metadata.Version,
+			ProjectID: project.ID,
+		}
+		installedOpts := charts.RancherAlertingOptsFromValues(alertingChart.ChartDetails.Spec.Values)
 
-		e2e.Logf("Successfully fetched AMC: %v", verifyAlertManagerConfig)
+		By("1) Deploying the SMS and Teams recorder")
+		recorder, err := alerting.DeployRecorder(clientWithSession, cluster.ID, alerting.Namespace, namegen.AppendRandomString("e2e-notification-recorder"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			Expect(recorder.Delete(clientWithSession)).To(Succeed())
+		})
+
+		By("2) Pointing sachet and prom2teams at the recorder")
+		deliveryName := namegen.AppendRandomString(alerting.DeliveryPrefix)
+		smsValues, teamsValues := alerting.DriverValues(recorder, deliveryName)
+		err = charts.UpgradeRancherAlertingChart(clientWithSession, installOptions, &charts.RancherAlertingOpts{
+			SMS:         true,
+			Teams:       true,
+			SMSValues:   smsValues,
+			TeamsValues: teamsValues,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			By("Restoring the alerting drivers options the chart was installed with")
+			Expect(charts.UpgradeRancherAlertingChart(clientWithSession, installOptions, installedOpts)).To(Succeed())
+		})
+		Expect(extencharts.WatchAndWaitDeployments(clientWithSession, cluster.ID, charts.RancherAlertingNamespace, metav1.ListOptions{})).To(Succeed())
+
+		By("3) Firing a test alert routed to both drivers")
+		alertName := "E2EDriverDelivery" + namegen.RandStringLower(5)
+		summary := "Alerting drivers delivery check " + alertName
+		Expect(alerting.CreateDelivery(clientWithSession, cluster.ID, deliveryName, alertName, summary)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(alerting.DeleteDelivery(clientWithSession, cluster.ID, deliveryName)).To(Succeed())
+		})
+
+		By("4) Verifying the firing notifications")
+		Eventually(func() error {
+			records, err := recorder.Records()
+			if err != nil {
+				return err
+			}
+			if err := alerting.VerifySMS(records, alertName, alerting.Firing); err != nil {
+				return err
+			}
+			return alerting.VerifyTeams(records, summary, false)
+		}, 5*time.Minute, 15*time.Second).Should(Succeed())
+
+		By("5) Resolving the alert and verifying the resolved notifications")
+		Expect(alerting.ResolveDelivery(clientWithSession, cluster.ID, deliveryName)).To(Succeed())
+		Eventually(func() error {
+			records, err := recorder.Records()
+			if err != nil {
+				return err
+			}
+			if err := alerting.VerifySMS(records, alertName, alerting.Resolved); err != nil {
+				return err
+			}
+			return alerting.VerifyTeams(records, summary, true)
+		}, 5*time.Minute, 15*time.Second).Should(Succeed())
 	})
 
+	It("Test : Verify AlertmanagerConfig routing with synthetic alerts", Label("LEVEL1", "alerts", "E2E", "AMC", "routing"), func() {
+		By("1) Creating a copy of the AlertmanagerConfig fixture")
+		manifest, err := utils.RenderYAMLTemplate(alertmanagerConfigFilePath, utils.NewFixture("amc-routing"))
+		Expect(err).NotTo(HaveOccurred())
+		config, err := alertmanager.ParseAlertmanagerConfig(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Create(clientWithSession, cluster.ID)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(config.Delete(clientWithSession, cluster.ID)).To(Succeed())
+		})
+
+		By("2) Building synthetic alerts for each routing case")
+		id := namegen.RandStringLower(5)
+		alert := func(name string, labels map[string]string) alertmanager.Alert {
+			labels["alertname"] = fmt.Sprintf("E2ERouting%s%s", name, id)
+			return alertmanager.Alert{
+				Labels:      labels,
+				Annotations: map[string]string{"summary": "AlertmanagerConfig routing check"},
+				EndsAt:      time.Now().Add(10 * time.Minute),
+			}
+		}
+		alerts := []alertmanager.Alert{
+			alert("GroupOne", map[string]string{"namespace": config.Namespace, "team": "qa", "qa": "one"}),
+			alert("GroupTwo", map[string]string{"namespace": config.Namespace, "team": "qa", "qa": "two"}),
+			alert("OtherTeam", map[string]string{"namespace": config.Namespace, "team": "dev", "qa": "one"}),
+			// Matches the route, but the operator restricts the config to alerts of its own namespace.
+			alert("OtherNamespace", map[string]string{"namespace": "default", "team": "qa", "qa": "one"}),
+		}
+		for _, a := range alerts {
+			e2e.Logf("%s is expected at %+v", a.Labels["alertname"], config.Simulate(a.Labels))
+		}
+		Expect(config.Simulate(alerts[0].Labels)).NotTo(BeEmpty(), "the fixture route does not match the routed alert")
+
+		By("3) Pushing the alerts and verifying the receivers and group keys")
+		am := alertmanager.NewClient(alertmanager.RancherMonitoringURL(clientWithSession.RancherConfig.Host, cluster.ID), clientWithSession.RancherConfig.AdminToken)
+		// The alerts are re-posted on each attempt, as Alertmanager routes them with the configuration loaded at
+		// the time they arrive and the operator takes a moment to reload it.
+		Eventually(func() ([]string, error) {
+			if err := am.PostAlerts(alerts...); err != nil {
+				return nil, err
+			}
+			return alertmanager.VerifyRouting(am, config, alerts)
+		}, 5*time.Minute, 20*time.Second).Should(BeEmpty(), "alerts were not routed as the AlertmanagerConfig defines")
+	})
+
+	It("Test : Verify a silence suppresses notifications until it expires", Label("LEVEL1", "alerts", "E2E", "silence"), func() {
+		caseID := namegen.RandStringLower(5)
+		am, recorder := deployWebhookReceiver(clientWithSession, caseID)
+
+		By("1) Firing the test alert and waiting for its notification")
+		target := suppressionAlert("E2ESilenceTarget"+caseID, caseID, "warning")
+		Expect(am.PostAlerts(target)).To(Succeed())
+		DeferCleanup(resolveAlerts, am, target)
+		waitForNotifications(recorder, target.Labels["alertname"], 1)
+
+		By("2) Silencing the alert and verifying it is suppressed")
+		silenceID, err := am.CreateSilence(map[string]string{"alertname": target.Labels["alertname"]}, 4*time.Minute, "observability-e2e silence check")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			_ = am.ExpireSilence(silenceID)
+		})
+		Eventually(func() ([]string, error) {
+			alert, err := am.Alert(target.Labels["alertname"])
+			if err != nil {
+				return nil, err
+			}
+			return alert.Status.SilencedBy, nil
+		}, 2*time.Minute, 10*time.Second).Should(ContainElement(silenceID))
+		Expect(am.AlertState(target.Labels["alertname"])).To(Equal(alertmanager.StateSuppressed))
+
+		By("3) Verifying the webhook receiver gets no notification while the silence is active")
+		expectNoNewNotifications(recorder, target.Labels["alertname"])
+
+		By("4) Waiting for the silence to expire and the notifications to resume")
+		Eventually(func() (string, error) {
+			silence, err := am.Silence(silenceID)
+			if err != nil {
+				return "", err
+			}
+			return silence.State(), nil
+		}, 4*time.Minute, 15*time.Second).Should(Equal(alertmanager.SilenceExpired))
+		Eventually(func() (string, error) {
+			return am.AlertState(target.Labels["alertname"])
+		}, time.Minute, 10*time.Second).Should(Equal(alertmanager.StateActive))
+		delivered := countNotifications(recorder, target.Labels["alertname"])
+		waitForNotifications(recorder, target.Labels["alertname"], delivered+1)
+	})
+
+	It("Test : Verify an inhibit rule suppresses notifications while the source alert fires", Label("LEVEL1", "alerts", "E2E", "inhibition"), func() {
+		caseID := namegen.RandStringLower(5)
+		am, recorder := deployWebhookReceiver(clientWithSession, caseID)
+
+		By("1) Firing the warning alert and waiting for its notification")
+		target := suppressionAlert("E2EInhibitTarget"+caseID, caseID, "warning")
+		Expect(am.PostAlerts(target)).To(Succeed())
+		DeferCleanup(resolveAlerts, am, target)
+		waitForNotifications(recorder, target.Labels["alertname"], 1)
+
+		By("2) Firing the critical source alert and verifying the warning alert is inhibited")
+		source := suppressionAlert("E2EInhibitSource"+caseID, caseID, "critical")
+		Expect(am.PostAlerts(source)).To(Succeed())
+		DeferCleanup(resolveAlerts, am, source)
+		Eventually(func() ([]string, error) {
+			alert, err := am.Alert(target.Labels["alertname"])
+			if err != nil {
+				return nil, err
+			}
+			return alert.Status.InhibitedBy, nil
+		}, 2*time.Minute, 10*time.Second).ShouldNot(BeEmpty())
+		Expect(am.AlertState(target.Labels["alertname"])).To(Equal(alertmanager.StateSuppressed))
+
+		By("3) Verifying the webhook receiver gets no notification while the source alert fires")
+		expectNoNewNotifications(recorder, target.Labels["alertname"])
+
+		By("4) Resolving the source alert and verifying the notifications resume")
+		resolveAlerts(am, source)
+		Eventually(func() (string, error) {
+			return am.AlertState(target.Labels["alertname"])
+		}, 2*time.Minute, 10*time.Second).Should(Equal(alertmanager.StateActive))
+		delivered := countNotifications(recorder, target.Labels["alertname"])
+		waitForNotifications(recorder, target.Labels["alertname"], delivered+1)
+	})
 })
+
+// suppressionRepeatInterval is the repeat interval of the suppression receiver, so an active alert is notified
+// again well within the waits of the silence and inhibition specs.
+const (
+	suppressionRepeatInterval       = time.Minute
+	suppressionRepeatIntervalConfig = "1m"
+)
+
+// deployWebhookReceiver deploys a recorder and an AlertmanagerConfig sending the alerts of the case to it. The
+// config inhibits warning alerts of the case while a critical alert of the same case fires.
+func deployWebhookReceiver(clientWithSession *rancher.Client, caseID string) (*alertmanager.Client, *alerting.Recorder) {
+	By("Deploying a webhook receiver and an AlertmanagerConfig routing the test alerts to it")
+	recorder, err := alerting.DeployRecorder(clientWithSession, cluster.ID, alerting.Namespace, "e2e-webhook-"+caseID)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() {
+		Expect(recorder.Delete(clientWithSession)).To(Succeed())
+	})
+
+	caseMatcher := alertmanager.Matcher{Name: "e2e_case", Value: caseID, MatchType: "="}
+	config := &alertmanager.AlertmanagerConfig{
+		Name:      "e2e-suppression-" + caseID,
+		Namespace: alerting.Namespace,
+		Route: alertmanager.Route{
+			Receiver:       "webhook",
+			GroupBy:        []string{"alertname"},
+			GroupWait:      "5s",
+			GroupInterval:  "10s",
+			RepeatInterval: suppressionRepeatIntervalConfig,
+			Matchers:       []alertmanager.Matcher{caseMatcher},
+		},
+		InhibitRules: []alertmanager.InhibitRule{{
+			SourceMatch: []alertmanager.Matcher{caseMatcher, {Name: "severity", Value: "critical", MatchType: "="}},
+			TargetMatch: []alertmanager.Matcher{caseMatcher, {Name: "severity", Value: "warning", MatchType: "="}},
+			Equal:       []string{"e2e_case"},
+		}},
+		Receivers: []map[string]interface{}{{
+			"name": "webhook",
+			"webhookConfigs": []interface{}{map[string]interface{}{
+				"url":          recorder.URL(alerting.WebhookPath),
+				"sendResolved": false,
+			}},
+		}},
+	}
+	Expect(config.Create(clientWithSession, cluster.ID)).To(Succeed())
+	DeferCleanup(func() {
+		Expect(config.Delete(clientWithSession, cluster.ID)).To(Succeed())
+	})
+
+	am := alertmanager.NewClient(alertmanager.RancherMonitoringURL(clientWithSession.RancherConfig.Host, cluster.ID), clientWithSession.RancherConfig.AdminToken)
+	return am, recorder
+}
+
+// suppressionAlert returns a synthetic alert of the case that stays active for the length of the spec.
+func suppressionAlert(alertName, caseID, severity string) alertmanager.Alert {
+	return alertmanager.Alert{
+		Labels: map[string]string{
+			"alertname": alertName,
+			"namespace": alerting.Namespace,
+			"e2e_case":  caseID,
+			"severity":  severity,
+		},
+		Annotations: map[string]string{"summary": "Alertmanager suppression check"},
+		EndsAt:      time.Now().Add(30 * time.Minute),
+	}
+}
+
+// resolveAlerts ends the alerts now.
+func resolveAlerts(am *alertmanager.Client, alerts ...alertmanager.Alert) {
+	for i := range alerts {
+		alerts[i].EndsAt = time.Now()
+	}
+	Expect(am.PostAlerts(alerts...)).To(Succeed())
+}
+
+func countNotifications(recorder *alerting.Recorder, alertName string) int {
+	records, err := recorder.Records()
+	Expect(err).NotTo(HaveOccurred())
+	return alerting.WebhookNotifications(records, alertName)
+}
+
+// waitForNotifications waits until the receiver got at least count notifications of the alert. The config only
+// reaches Alertmanager after the operator reloaded it, so the first one may take a while.
+func waitForNotifications(recorder *alerting.Recorder, alertName string, count int) {
+	Eventually(func() (int, error) {
+		records, err := recorder.Records()
+		if err != nil {
+			return 0, err
+		}
+		return alerting.WebhookNotifications(records, alertName), nil
+	}, 5*time.Minute, 10*time.Second).Should(BeNumerically(">=", count), "%s was not notified", alertName)
+}
+
+// expectNoNewNotifications checks that a suppressed alert is not notified again for longer than the repeat
+// interval, after letting a notification already in flight arrive.
+func expectNoNewNotifications(recorder *alerting.Recorder, alertName string) {
+	time.Sleep(15 * time.Second)
+	suppressed := countNotifications(recorder, alertName)
+	Consistently(func() int {
+		return countNotifications(recorder, alertName)
+	}, suppressionRepeatInterval+30*time.Second, 15*time.Second).Should(Equal(suppressed), "%s was notified while suppressed", alertName)
+}
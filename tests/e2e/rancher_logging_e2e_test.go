@@ -1,20 +1,32 @@
 package e2e_test
 
 import (
-	"os"
+	"context"
+	"regexp"
 	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/probe"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	rancher "github.com/rancher/shepherd/clients/rancher"
-	"github.com/rancher/shepherd/extensions/kubectl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
 const (
-	loggingResourceYamlPath = "../helper/yamls/clusterOutputandClusterFlow.yaml"
+	loggingResourceYamlPath = "../helper/yamls/clusterOutputandClusterFlow.template.yaml"
+	loggingNamespace        = "cattle-logging-system"
+	syslogPort              = 514
+)
+
+var (
+	clusterOutputGVR = schema.GroupVersionResource{Group: "logging.banzaicloud.io", Version: "v1beta1", Resource: "clusteroutputs"}
+	clusterFlowGVR   = schema.GroupVersionResource{Group: "logging.banzaicloud.io", Version: "v1beta1", Resource: "clusterflows"}
 )
 
 var _ = Describe("Observability Logging E2E Test Suite", func() {
@@ -27,61 +39,38 @@ var _ = Describe("Observability Logging E2E Test Suite", func() {
 	})
 
 	It("[QASE-6834] Test : Verify status of rancher-logging Deployments using kubectl", Label("LEVEL1", "Logging", "E2E"), func() {
-		testCaseID = 6834
 		By("0) Fetch all the deployments belonging to rancher-logging")
-		fetchDeployments := []string{"kubectl", "get", "deployments", "-n", "cattle-logging-system", "--no-headers"}
-		rancherLoggingDeployments, err := kubectl.Command(clientWithSession, nil, "local", fetchDeployments, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		deployments, err := kubeClient.Clientset.AppsV1().Deployments(loggingNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get deployments.")
 
 		By("1) Read all the deployments and verify the status of rancher-logging deployments")
 		foundRancherLogging := false
-		deployments := strings.Split(rancherLoggingDeployments, "\n")
-		for _, deployment := range deployments {
-			if deployment == "" {
+		for _, deployment := range deployments.Items {
+			if !strings.HasPrefix(deployment.Name, "rancher-logging") {
 				continue
 			}
+			foundRancherLogging = true
 
-			fields := strings.Fields(deployment)
-			Expect(len(fields)).To(BeNumerically(">=", 4), "Unexpected output format for deployment: %s", deployment)
-
-			deploymentName := fields[0]
-			readyReplicas := fields[1]
-			availableReplicas := fields[3]
-
-			readyCount := strings.Split(readyReplicas, "/")[0]
-			desiredCount := strings.Split(readyReplicas, "/")[1]
-
-			if strings.HasPrefix(deploymentName, "rancher-logging") {
-				foundRancherLogging = true
-
-				Expect(availableReplicas).To(Equal(desiredCount), "Failure: Deployment %s is not fully available. Desired: %s, Available: %s", deploymentName, desiredCount, availableReplicas)
-				Expect(readyCount).To(Equal(desiredCount), "Failure: Deployment %s pods are not fully ready. Desired: %s, Ready: %s", deploymentName, desiredCount, readyCount)
-			}
+			desiredCount := *deployment.Spec.Replicas
+			Expect(deployment.Status.AvailableReplicas).To(Equal(desiredCount), "Failure: Deployment %s is not fully available. Desired: %d, Available: %d", deployment.Name, desiredCount, deployment.Status.AvailableReplicas)
+			Expect(deployment.Status.ReadyReplicas).To(Equal(desiredCount), "Failure: Deployment %s pods are not fully ready. Desired: %d, Ready: %d", deployment.Name, desiredCount, deployment.Status.ReadyReplicas)
 		}
 		Expect(foundRancherLogging).To(BeTrue(), "No deployments found starting with 'rancher-logging'")
 	})
 
 	It("[QASE-6835] Test : Verify status of rancher-logging pods using kubectl", Label("LEVEL1", "Logging", "E2E"), func() {
-		testCaseID = 6835
 		By("0) Fetch all the pods belongs to rancher-logging")
-		fetchPods := []string{"kubectl", "get", "pods", "-n", "cattle-logging-system", "--no-headers"}
-		rancherLoggingPods, err := kubectl.Command(clientWithSession, nil, "local", fetchPods, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		pods, err := kubeClient.Clientset.CoreV1().Pods(loggingNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get pods.")
 
 		By("1) Read all the pods and verify the status of rancher-logging-Pods")
 		rancherLoggingPodFound := false
-		pods := strings.Split(rancherLoggingPods, "\n")
-		for _, pod := range pods {
-			if pod == "" {
-				continue
-			}
-			fields := strings.Fields(pod)
-			Expect(len(fields)).To(BeNumerically(">=", 3), "Unexpected output format for pod: %s", pod)
-
-			podName := fields[0]
-			podStatus := fields[2]
-
-			if strings.HasPrefix(podName, "rancher-logging") && (podStatus == "Running" || podStatus == "Completed") {
+		for _, pod := range pods.Items {
+			if strings.HasPrefix(pod.Name, "rancher-logging") && (pod.Status.Phase == corev1.PodRunning || pod.Status.Phase == corev1.PodSucceeded) {
 				rancherLoggingPodFound = true
 			}
 		}
@@ -89,167 +78,126 @@ var _ = Describe("Observability Logging E2E Test Suite", func() {
 	})
 
 	It("[QASE-6836] Test : Verify status of rancher-logging DaemonSets using kubectl", Label("LEVEL1", "Logging", "E2E"), func() {
-		testCaseID = 6836
 		By("0) Fetch all the daemon sets belongs to rancher-logging")
-		fetchDaemonSets := []string{"kubectl", "get", "daemonsets", "-n", "cattle-logging-system", "--no-headers"}
-		rancherLoggingDaemonSets, err := kubectl.Command(clientWithSession, nil, "local", fetchDaemonSets, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		daemonSets, err := kubeClient.Clientset.AppsV1().DaemonSets(loggingNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get daemonsets.")
 
 		By("1) Read all the daemonSet and verify the status of rancher-logging-daemonSets")
-		daemonSets := strings.Split(rancherLoggingDaemonSets, "\n")
-		for _, daemonSet := range daemonSets {
-			if daemonSet == "" {
-				continue
-			}
-
-			fields := strings.Fields(daemonSet)
-			Expect(len(fields)).To(BeNumerically(">=", 6), "Unexpected output format for daemonSet: %s", daemonSet)
-
-			daemonSetName := fields[0]
-			desiredPods := fields[1]
-			readyPods := fields[3]
-			availablePods := fields[5]
-
-			Expect(availablePods).To(Equal(desiredPods), "DaemonSet %s is not fully available. Desired: %s, Available: %s", daemonSetName, desiredPods, availablePods)
-			Expect(readyPods).To(Equal(desiredPods), "DaemonSet %s is not fully ready. Desired: %s, Ready: %s", daemonSetName, desiredPods, readyPods)
+		for _, daemonSet := range daemonSets.Items {
+			desiredPods := daemonSet.Status.DesiredNumberScheduled
+			Expect(daemonSet.Status.NumberAvailable).To(Equal(desiredPods), "DaemonSet %s is not fully available. Desired: %d, Available: %d", daemonSet.Name, desiredPods, daemonSet.Status.NumberAvailable)
+			Expect(daemonSet.Status.NumberReady).To(Equal(desiredPods), "DaemonSet %s is not fully ready. Desired: %d, Ready: %d", daemonSet.Name, desiredPods, daemonSet.Status.NumberReady)
 		}
 	})
 
 	It("[QASE-6837] Test : Verify status of rancher-logging StatefulSets using kubectl", Label("LEVEL1", "Logging", "E2E"), func() {
-		testCaseID = 6837
 		By("0) Fetch all the StatefulSets belongs to rancher-logging")
-		fetchStatefulsets := []string{"kubectl", "get", "statefulsets", "-n", "cattle-logging-system", "--no-headers"}
-		rancherLoggingStatefulsets, err := kubectl.Command(clientWithSession, nil, "local", fetchStatefulsets, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		statefulsets, err := kubeClient.Clientset.AppsV1().StatefulSets(loggingNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get statefulsets.")
 
 		By("1) Read all the statefulsets and verify the status of rancher-logging-statefulsets")
-		statefulsets := strings.Split(rancherLoggingStatefulsets, "\n")
-		for _, statefulset := range statefulsets {
-			if statefulset == "" {
+		for _, statefulset := range statefulsets.Items {
+			if !strings.HasPrefix(statefulset.Name, "rancher-logging") {
 				continue
 			}
-
-			fields := strings.Fields(statefulset)
-			Expect(len(fields)).To(BeNumerically(">=", 3), "Unexpected output format for statefulSet: %s", statefulset)
-
-			statefulsetsName := fields[0]
-			readyReplicas := fields[1]
-
-			readyCount := strings.Split(readyReplicas, "/")[0]
-			desiredCount := strings.Split(readyReplicas, "/")[1]
-
-			if strings.HasPrefix(statefulsetsName, "rancher-logging") {
-				Expect(readyCount).To(Equal(desiredCount), "Failure: Deployment %s pods are not fully ready. Desired: %s, Ready: %s", statefulsetsName, desiredCount, readyCount)
-			}
+			desiredCount := *statefulset.Spec.Replicas
+			Expect(statefulset.Status.ReadyReplicas).To(Equal(desiredCount), "Failure: Deployment %s pods are not fully ready. Desired: %d, Ready: %d", statefulset.Name, desiredCount, statefulset.Status.ReadyReplicas)
 		}
 	})
 
-	It("[QASE-6838] Test: Verify creation of Rancher cluster output and cluster flow", Label("LEVEL1", "Logging", "E2E"), func() {
-		testCaseID = 6838
+	It("[QASE-6838] Test: Verify creation of Rancher cluster output and cluster flow", Label("LEVEL1", "Logging", "E2E"), Serial, func() {
 		By("1) Fetching syslog service IP for cluster output host")
-		syslogServiceCmd := []string{"kubectl", "get", "svc", "syslog-ng-service", "-n", "cattle-logging-system", "--no-headers"}
-		syslogServiceOutput, err := kubectl.Command(clientWithSession, nil, "local", syslogServiceCmd, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		syslogService, err := kubeClient.Clientset.CoreV1().Services(loggingNamespace).Get(context.TODO(), "syslog-ng-service", metav1.GetOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get syslog service")
 
-		serviceFields := strings.Fields(syslogServiceOutput)
-		var serviceIP string
-		Expect(len(serviceFields)).To(BeNumerically(">", 2), "Failed to extract service IP from syslog service output")
-		serviceIP = serviceFields[2]
+		serviceIP := syslogService.Spec.ClusterIP
+		Expect(serviceIP).NotTo(BeEmpty(), "Failed to extract service IP from syslog service")
 		e2e.Logf("Syslog service IP: %s", serviceIP)
 
-		By("2) Updating cluster flow and output manifest file")
-		yamlContent, err := os.ReadFile(loggingResourceYamlPath)
-		Expect(err).NotTo(HaveOccurred(), "Error reading YAML file")
-
-		yamlStr := string(yamlContent)
-		updatedYamlStr := strings.Replace(yamlStr, "<syslog service IP>", serviceIP, -1)
-
-		err = os.WriteFile(loggingResourceYamlPath, []byte(updatedYamlStr), 0644)
-		Expect(err).NotTo(HaveOccurred(), "Error writing updated YAML file")
+		By("2) Naming the cluster flow and output of the spec and pointing them at the syslog service")
+		loggingFixture := utils.NewFixture("testclusteroutput")
+		loggingFixture.Values["host"] = serviceIP
 
 		By("3) Deploying cluster output and cluster flow")
-		deployLoggingResourcesError := utils.DeployLoggingClusterOutputAndClusterFlow(clientWithSession, loggingResourceYamlPath)
+		flowDeployedAt := metav1.Now()
+		deployLoggingResourcesError := utils.DeployLoggingClusterOutputAndClusterFlow(clientWithSession, loggingResourceYamlPath, loggingFixture)
 		Expect(deployLoggingResourcesError).NotTo(HaveOccurred(), "Failed to deploy cluster output and flow")
+		DeferCleanup(func() {
+			Expect(utils.DeleteYamlTemplate(clientWithSession, loggingResourceYamlPath, loggingFixture, "")).To(Succeed())
+		})
 
 		By("4) Fetching cluster output")
-		clusterOutputCmd := []string{"kubectl", "get", "clusteroutput", "testclusteroutput", "-n", "cattle-logging-system"}
-		clusterOutput, err := kubectl.Command(clientWithSession, nil, "local", clusterOutputCmd, "")
+		clusterOutput, err := kubeClient.Get(context.TODO(), clusterOutputGVR, loggingNamespace, loggingFixture.Name)
 		Expect(err).NotTo(HaveOccurred(), "Failed to fetch cluster output")
-		Expect(clusterOutput).NotTo(BeEmpty(), "Cluster output is empty")
+		Expect(clusterOutput.Object).NotTo(BeEmpty(), "Cluster output is empty")
 
 		By("5) Fetching cluster flow")
-		clusterFlowCmd := []string{"kubectl", "get", "clusterflow", "testclusterflow", "-n", "cattle-logging-system"}
-		clusterFlow, err := kubectl.Command(clientWithSession, nil, "local", clusterFlowCmd, "")
+		clusterFlow, err := kubeClient.Get(context.TODO(), clusterFlowGVR, loggingNamespace, loggingFixture.Name)
 		Expect(err).NotTo(HaveOccurred(), "Failed to fetch cluster flow")
-		Expect(clusterFlow).NotTo(BeEmpty(), "Cluster flow is empty")
+		Expect(clusterFlow.Object).NotTo(BeEmpty(), "Cluster flow is empty")
 
 		By("6) Scaling Rancher Logging Deployment")
-		scaleRancherLogging := []string{"kubectl", "scale", "deployment", "rancher-logging", "-n", "cattle-logging-system", "--replicas=4"}
-		fetchRancherLogging := []string{"kubectl", "get", "pods", "-n", "cattle-logging-system"}
-		scaleDeploymentOutput, err := kubectl.Command(clientWithSession, nil, "local", scaleRancherLogging, "")
+		scale, err := kubeClient.Clientset.AppsV1().Deployments(loggingNamespace).GetScale(context.TODO(), "rancher-logging", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred(), "Failed to get the scale of the Rancher logging deployment")
+		scale.Spec.Replicas = 4
+		_, err = kubeClient.Clientset.AppsV1().Deployments(loggingNamespace).UpdateScale(context.TODO(), "rancher-logging", scale, metav1.UpdateOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to scale Rancher logging deployment")
-		e2e.Logf("Successfully scaled Rancher logging deployment:\n %v", scaleDeploymentOutput)
+		e2e.Logf("Successfully scaled Rancher logging deployment to %d replicas", scale.Spec.Replicas)
 
-		fetchRancherLoggingOutput, err := kubectl.Command(clientWithSession, nil, "local", fetchRancherLogging, "")
+		loggingPods, err := kubeClient.Clientset.CoreV1().Pods(loggingNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to fetch Rancher logging pods")
-		e2e.Logf("Successfully fetched Rancher logging pods:\n %v", fetchRancherLoggingOutput)
-
-		var maxRetries = 5
-		var retryInterval = 60 * time.Second
-		var attempt = 0
+		for _, pod := range loggingPods.Items {
+			e2e.Logf("Rancher logging pod %s is %s", pod.Name, pod.Status.Phase)
+		}
 
 		By("7) Verifying Rancher logs via syslog")
-		time.Sleep(30 * time.Second)
-		syslogPodsCmd := []string{"kubectl", "get", "pods", "-n", "cattle-logging-system", "--no-headers"}
-		syslogPodsOutput, err := kubectl.Command(clientWithSession, nil, "local", syslogPodsCmd, "")
+		syslogPods, err := kubeClient.Clientset.CoreV1().Pods(loggingNamespace).List(context.TODO(), metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to fetch syslog pods")
-
-		syslogPods := strings.Split(syslogPodsOutput, "\n")
-		Expect(syslogPods).NotTo(BeEmpty(), "No syslog pods found")
-
-		for _, pod := range syslogPods {
-			if strings.Contains(pod, "syslog-ng-deployment") {
-				podFields := strings.Fields(pod)
-				Expect(len(podFields)).To(BeNumerically(">", 0), "Invalid pod format")
-
-				podName := podFields[0]
-				e2e.Logf("Fetching logs for syslog pod: %v", podName)
-				var syslogLogsOutput string
-				var err error
-
-				for attempt < maxRetries {
-					syslogLogsCmd := []string{"kubectl", "logs", podName, "-n", "cattle-logging-system"}
-					syslogLogsOutput, err = kubectl.Command(clientWithSession, nil, "local", syslogLogsCmd, "")
-					Expect(err).NotTo(HaveOccurred(), "Error fetching logs for pod %s", podName)
-
-					if syslogLogsOutput == "" || syslogLogsOutput == "null" {
-						e2e.Logf("Logs are empty or null. Retrying... (Attempt %d/%d)\n", attempt+1, maxRetries)
-						attempt++
-						if attempt < maxRetries {
-							time.Sleep(retryInterval)
-						}
-					} else {
-						logLines := strings.Split(syslogLogsOutput, "\n")
-						startIdx := max(0, len(logLines)-5)
-						e2e.Logf("Syslog found:\n%s", strings.Join(logLines[startIdx:], "\n"))
-						break
-					}
-				}
-
-				Expect(attempt).Should(BeNumerically("<", maxRetries), "Failed to fetch valid logs for pod after max retries")
-				Expect(syslogLogsOutput).To(
-					Or(
-						ContainSubstring("testclusteroutput"), ContainSubstring("cattle-logging-system"), ContainSubstring("syslog-ng"),
-					),
-					"Logs for pod %s did not contain any of the expected substrings: 'testclusteroutput', 'cattle-logging-system', or 'cattle-monitoring-system'", podName)
-
-				attempt = 0
+		Expect(syslogPods.Items).NotTo(BeEmpty(), "No syslog pods found")
+
+		// Only records forwarded since the flow was created count, so older lines are not read again.
+		syslogRecord := regexp.MustCompile(regexp.QuoteMeta(loggingFixture.Name) + `|cattle-logging-system|syslog-ng`)
+		for _, pod := range syslogPods.Items {
+			if strings.Contains(pod.Name, "syslog-ng-deployment") {
+				podName := pod.Name
+				e2e.Logf("Following logs of syslog pod: %v", podName)
+
+				syslogLine, err := kubeClient.WaitForLog(context.TODO(), loggingNamespace, podName, corev1.PodLogOptions{SinceTime: &flowDeployedAt}, syslogRecord, 5*time.Minute)
+				Expect(err).NotTo(HaveOccurred(), "Logs for pod %s did not contain any of the expected substrings: '%s', 'cattle-logging-system', or 'syslog-ng'", podName, loggingFixture.Name)
+				e2e.Logf("Syslog found:\n%s", syslogLine)
 			}
 		}
+	})
 
-		resetOriginalYaml := strings.Replace(yamlStr, serviceIP, "<syslog service IP>", -1)
-		err = os.WriteFile(loggingResourceYamlPath, []byte(resetOriginalYaml), 0644)
-		Expect(err).NotTo(HaveOccurred(), "Error writing reset YAML file")
+	It("Test: Verify the syslog service receives a UDP syslog message from a probe pod", Label("LEVEL1", "Logging", "E2E"), func() {
+		By("1) Fetching the syslog service IP")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		syslogService, err := kubeClient.Clientset.CoreV1().Services(loggingNamespace).Get(context.TODO(), "syslog-ng-service", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred(), "Failed to get syslog service")
+		serviceIP := syslogService.Spec.ClusterIP
+		Expect(serviceIP).NotTo(BeEmpty(), "Failed to extract service IP from syslog service")
+
+		By("2) Sending a syslog message to the syslog service from a probe pod")
+		probePod, err := probe.Start(clientWithSession, kube.LocalCluster, probe.Options{Namespace: spec.Namespace(), NamePrefix: "syslog-probe"})
+		Expect(err).NotTo(HaveOccurred(), "Failed to create probe pod")
+		marker := "observability-e2e " + probePod.Name
+		sentAt := metav1.Now()
+		err = probePod.DialUDP(serviceIP, syslogPort, []byte("<14>"+marker))
+		Expect(err).NotTo(HaveOccurred(), "Failed to send a syslog message")
+
+		By("3) Verifying the syslog pod received the message")
+		syslogNgPods, err := kubeClient.DeploymentPods(context.TODO(), loggingNamespace, "syslog-ng-deployment")
+		Expect(err).NotTo(HaveOccurred(), "Failed to fetch syslog pods")
+		Expect(syslogNgPods).NotTo(BeEmpty(), "No syslog pods found")
+		_, err = kubeClient.WaitForLog(context.TODO(), loggingNamespace, syslogNgPods[0].Name, corev1.PodLogOptions{SinceTime: &sentAt}, regexp.MustCompile(regexp.QuoteMeta(marker)), 2*time.Minute)
+		Expect(err).NotTo(HaveOccurred(), "The syslog message of the probe pod was not received")
 	})
 
 })
@@ -3,17 +3,19 @@ package e2e_test
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/monitoring"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
 	"github.com/rancher/rancher/tests/v2/actions/namespaces"
 	rancher "github.com/rancher/shepherd/clients/rancher"
 	management "github.com/rancher/shepherd/clients/rancher/generated/management/v3"
-	"github.com/rancher/shepherd/extensions/kubectl"
-	"k8s.io/apimachinery/pkg/util/wait"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
@@ -27,74 +29,132 @@ var _ = Describe("Observability Prometheus Federator e2e Test Suite", func() {
 	})
 
 	It("[QASE-6839] Test : Verify status of rancher prometheus-federator (deployment + pod) using kubectl", Label("LEVEL0", "promfed", "E2E"), func() {
-		testCaseID = 6839
 		By("Step 1) Checking the 'prometheus-federator' deployment in cattle-monitoring-system")
-		fetchDeployment := []string{
-			"kubectl", "get", "deploy", "prometheus-federator", "-n", "cattle-monitoring-system", "--no-headers",
-		}
-		deployOutput, err := kubectl.Command(clientWithSession, nil, "local", fetchDeployment, "")
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		deployment, err := kubeClient.Clientset.AppsV1().Deployments(charts.PrometheusFederatorNamespace).Get(context.TODO(), "prometheus-federator", metav1.GetOptions{})
 		if err != nil {
 			e2e.Failf("Failed to get the prometheus-federator deployment. Error: %v", err)
 		}
-		Expect(deployOutput).To(MatchRegexp("prometheus-federator.*1/1"), fmt.Sprintf("Expected 'prometheus-federator' deployment to be 1/1, but got: %s", deployOutput))
+		Expect(deployment.Status.ReadyReplicas).To(Equal(int32(1)), fmt.Sprintf("Expected 'prometheus-federator' deployment to be 1/1, but got: %d/%d", deployment.Status.ReadyReplicas, *deployment.Spec.Replicas))
 
 		By("Step 2) Checking the 'prometheus-federator' pod in cattle-monitoring-system")
-		fetchPods := []string{
-			"kubectl", "get", "pods", "-n", "cattle-monitoring-system", "-l", "release=prometheus-federator",
-		}
-		podsOutput, err := kubectl.Command(clientWithSession, nil, "local", fetchPods, "")
+		pods, err := kubeClient.Clientset.CoreV1().Pods(charts.PrometheusFederatorNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "release=prometheus-federator"})
 		if err != nil {
 			e2e.Failf("Failed to get pods in 'cattle-monitoring-system'. Error: %v", err)
 		}
-		Expect(podsOutput).To(MatchRegexp("prometheus.*1/1.*Running"), fmt.Sprintf("Expected 'prometheus-federator' pod to be running, but got: %s", podsOutput))
+		Expect(pods.Items).NotTo(BeEmpty(), "Expected a 'prometheus-federator' pod")
+		for _, pod := range pods.Items {
+			Expect(kube.PodReady(&pod)).To(BeTrue(), fmt.Sprintf("Expected 'prometheus-federator' pod %s to be running, but it is %s", pod.Name, pod.Status.Phase))
+		}
 	})
 
 	It("[QASE-6840] Test : Project Monitoring for test-promfed-monitoring", Label("LEVEL0", "promfed", "E2E", "Fedtest"), func() {
-		testCaseID = 6840
 		e2e.Logf("Creating new project for Project Monitoring")
+		projectName := namegen.AppendRandomString("test-promfed-monitoring")
 		projectConfig := &management.Project{
 			ClusterID: cluster.ID,
-			Name:      "test-promfed-monitoring",
+			Name:      projectName,
 		}
 
-		project, err = client.Management.Project.Create(projectConfig)
+		promfedProject, err := client.Management.Project.Create(projectConfig)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(project.Name).To(Equal("test-promfed-monitoring"))
+		Expect(promfedProject.Name).To(Equal(projectName))
+		DeferCleanup(func() {
+			Expect(client.Management.Project.Delete(promfedProject)).To(Succeed())
+		})
 
 		e2e.Logf("Creating namespace to deploy Project Monitoring")
-		namespace, err := namespaces.CreateNamespace(client, "promfed-monitoring-test-ns", "{}", map[string]string{}, map[string]string{}, project)
+		namespaceName := projectName + "-ns"
+		namespace, err := namespaces.CreateNamespace(client, namespaceName, "{}", map[string]string{}, map[string]string{}, promfedProject)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(namespace.Name).To(Equal("promfed-monitoring-test-ns"))
-		resourceNamespace := "cattle-project-" + strings.TrimPrefix(project.ID, "local:")
+		Expect(namespace.Name).To(Equal(namespaceName))
+		resourceNamespace := charts.ProjectRegistrationNamespace(promfedProject.ID)
 
 		By("Deploying Project Monitoring chart in the newly created project")
-		defer func() {
-			depMonResError := utils.DeleteYamlResource(clientWithSession, "../helper/yamls/projectMonitoringChart.yaml", resourceNamespace)
-			Expect(depMonResError).To(BeNil(), "Failed to delete Project Monitoring resource")
-		}()
-		depMonResError := utils.DeployYamlResource(clientWithSession, "../helper/yamls/projectMonitoringChart.yaml", resourceNamespace)
-		Expect(depMonResError).To(BeNil(), "Failed to deploy Project Monitoring resource")
-
-		By("Check Project Monitoring resource is Deployed")
-		ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
-		defer cancel()
-
-		err = wait.PollUntilContextTimeout(ctx, 10*time.Second, 90*time.Second, true, func(ctx context.Context) (bool, error) {
-			getResource := []string{"kubectl", "get", "ProjectHelmChart", "-n", resourceNamespace, "project-monitoring", "-o", "jsonpath={.status.status}"}
-			resourceOutput, cmdErr := kubectl.Command(clientWithSession, nil, cluster.Name, getResource, "")
-			if cmdErr != nil {
-				return false, cmdErr
-			}
+		projectMonitoring, err := charts.LoadProjectHelmChart("../helper/yamls/projectMonitoringChart.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		projectMonitoring.Namespace = resourceNamespace
 
-			if strings.Contains(resourceOutput, "Deployed") {
-				e2e.Logf("Project Monitoring Resource is: %s", resourceOutput)
-				return true, nil
+		DeferCleanup(func() {
+			if _, err := charts.GetProjectHelmChartStatus(clientWithSession, cluster.ID, resourceNamespace, projectMonitoring.Name); err == nil {
+				Expect(charts.DeleteProjectHelmChart(clientWithSession, cluster.ID, resourceNamespace, projectMonitoring.Name)).To(Succeed())
 			}
-			e2e.Logf("Project Monitoring resource is not yet Deployed, retrying...")
-			return false, nil
-		},
-		)
+		})
+		status, err := charts.CreateProjectHelmChart(clientWithSession, cluster.ID, projectMonitoring)
 		Expect(err).To(BeNil(), "Project Monitoring resource failed to reach 'Deployed' status")
-		e2e.Logf("Project Monitoring deployed successfully")
+		e2e.Logf("Project Monitoring dashboards: %v", status.DashboardValues)
+
+		By("Verifying the project Prometheus, Alertmanager and Grafana are ready")
+		Expect(charts.VerifyProjectMonitoringWorkloads(clientWithSession, cluster.ID, projectMonitoring, status)).To(Succeed())
+
+		By("Disabling the project Grafana")
+		projectMonitoring.Values["grafana"] = map[string]interface{}{"enabled": false}
+		status, err = charts.UpdateProjectHelmChartValues(clientWithSession, cluster.ID, resourceNamespace, projectMonitoring.Name, projectMonitoring.Values)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(charts.VerifyProjectMonitoringWorkloads(clientWithSession, cluster.ID, projectMonitoring, status)).To(Succeed())
+
+		By("Deleting Project Monitoring and verifying its workloads are removed")
+		Expect(charts.DeleteProjectHelmChart(clientWithSession, cluster.ID, resourceNamespace, projectMonitoring.Name)).To(Succeed())
+		e2e.Logf("Project Monitoring lifecycle verified successfully")
+	})
+	It("Test : Verify project monitoring isolation and federation", Label("LEVEL1", "promfed", "E2E", "isolation"), func() {
+		type projectMonitoring struct {
+			project   *management.Project
+			namespace string
+			marker    string
+			status    *charts.ProjectHelmChartStatus
+		}
+
+		var projects []*projectMonitoring
+		for i := 0; i < 2; i++ {
+			name := namegen.AppendRandomString("promfed-isolation")
+			By(fmt.Sprintf("Creating project %s with a metrics workload", name))
+			project, err := client.Management.Project.Create(&management.Project{ClusterID: cluster.ID, Name: name})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				Expect(client.Management.Project.Delete(project)).To(Succeed())
+			})
+
+			namespace, err := namespaces.CreateNamespace(client, name+"-ns", "{}", map[string]string{}, map[string]string{}, project)
+			Expect(err).NotTo(HaveOccurred())
+			pm := &projectMonitoring{project: project, namespace: namespace.Name, marker: name}
+			Expect(monitoring.DeployMetricsWorkload(clientWithSession, cluster.ID, pm.namespace, pm.marker)).To(Succeed())
+
+			By(fmt.Sprintf("Deploying Project Monitoring in project %s", name))
+			projectChart, err := charts.LoadProjectHelmChart("../helper/yamls/projectMonitoringChart.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			projectChart.Namespace = charts.ProjectRegistrationNamespace(project.ID)
+			DeferCleanup(func() {
+				Expect(charts.DeleteProjectHelmChart(clientWithSession, cluster.ID, projectChart.Namespace, projectChart.Name)).To(Succeed())
+			})
+			pm.status, err = charts.CreateProjectHelmChart(clientWithSession, cluster.ID, projectChart)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(charts.VerifyProjectMonitoringWorkloads(clientWithSession, cluster.ID, projectChart, pm.status)).To(Succeed())
+
+			projects = append(projects, pm)
+		}
+
+		for _, pm := range projects {
+			allowed, err := monitoring.ProjectNamespaces(clientWithSession, cluster.ID, pm.project.ID)
+			Expect(err).NotTo(HaveOccurred())
+			allowed = append(allowed, pm.status.ReleaseNamespace)
+
+			// The project Prometheus is reached both through the service proxy and through the URL Prometheus
+			// Federator publishes in the dashboard values.
+			urls := []string{charts.ProjectPrometheusURL(clientWithSession.RancherConfig.Host, cluster.ID, pm.status)}
+			if dashboardURL, ok := pm.status.DashboardValues["prometheusURL"].(string); ok && dashboardURL != "" {
+				urls = append(urls, dashboardURL)
+			}
+
+			for _, url := range urls {
+				By(fmt.Sprintf("Verifying the Prometheus of project %s only sees its namespaces at %s", pm.project.Name, url))
+				promClient, err := promclient.NewClient(url, clientWithSession.RancherConfig.AdminToken)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(func() ([]string, error) {
+					return monitoring.VerifyProjectIsolation(promClient, pm.namespace, pm.marker, allowed)
+				}, 5*time.Minute, 30*time.Second).Should(BeEmpty(), "project %s is not isolated", pm.project.Name)
+			}
+		}
 	})
 })
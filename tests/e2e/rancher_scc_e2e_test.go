@@ -15,7 +15,7 @@ limitations under the License.
 package e2e_test
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -24,13 +24,30 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	rancher "github.com/rancher/shepherd/clients/rancher"
-	"github.com/rancher/shepherd/extensions/kubectl"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
-var _ = Describe("SCC E2E Test Suite", func() {
+const (
+	sccNamespace       = "cattle-scc-system"
+	sccRegistrationCRD = "registrations.scc.cattle.io"
+)
+
+var (
+	sccRegistrationGVR = schema.GroupVersionResource{Group: "scc.cattle.io", Version: "v1", Resource: "registrations"}
+	settingGVR         = schema.GroupVersionResource{Group: "management.cattle.io", Version: "v3", Resource: "settings"}
+)
+
+var _ = Describe("SCC E2E Test Suite", Serial, func() {
 	var clientWithSession *rancher.Client
 
 	JustBeforeEach(func() {
@@ -39,8 +56,7 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 
-	It("[QASE-18052] Test : Verify SCC registration status for unregistered Prime cluster", Label("LEVEL0", "SCC", "E2E"), func() {
-		testCaseID = 18052
+	It("[QASE-18052] Test : Verify SCC registration status for unregistered Prime cluster", Label("LEVEL0", "SCC", "E2E"), capabilities.Label(capabilities.SCC), func() {
 		By("1) Check if Rancher cluster is Prime by verifying deployment image registry")
 		isPrime, imageRegistry := utils.CheckIfRancherIsPrime(clientWithSession)
 		e2e.Logf("Rancher is Prime: %v, Image Registry: %s", isPrime, imageRegistry)
@@ -50,24 +66,20 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		}
 
 		By("2) Verify registration CRD exists")
-		checkRegistrationCRD := []string{"kubectl", "get", "crd", "registrations.scc.cattle.io"}
-		crdOutput, err := kubectl.Command(clientWithSession, nil, "local", checkRegistrationCRD, "")
-
-		// Check if CRD exists - kubectl returns error message in output if not found
-		if err != nil || strings.Contains(crdOutput, "NotFound") || strings.Contains(crdOutput, "not found") {
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		crdExists, err := kubeClient.Exists(context.TODO(), kube.CRDsGVR, "", sccRegistrationCRD)
+		if err != nil || !crdExists {
 			Fail("Failed SCC test: Rancher Prime Registration CRD not found.")
 		}
-		Expect(crdOutput).NotTo(BeEmpty(), "Registration CRD not found")
-		e2e.Logf("Registration CRD exists: %s", strings.TrimSpace(crdOutput))
+		e2e.Logf("Registration CRD exists: %s", sccRegistrationCRD)
 
 		By("3) Verify registration resource list is empty for unregistered cluster")
-		getRegistrations := []string{"kubectl", "get", "registrations.scc.cattle.io", "-o", "json"}
-		registrationOutput, err := kubectl.Command(clientWithSession, nil, "local", getRegistrations, "")
+		registrations, err := kubeClient.List(context.TODO(), sccRegistrationGVR, "", metav1.ListOptions{})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get registration resources")
-		Expect(registrationOutput).NotTo(BeEmpty(), "Registration output is empty")
 
 		// Verify the output contains empty items list
-		Expect(registrationOutput).To(ContainSubstring(`"items": []`), "Expected empty registration items for unregistered cluster")
+		Expect(registrations).To(BeEmpty(), "Expected empty registration items for unregistered cluster")
 		e2e.Logf("Registration resources are empty as expected for unregistered cluster")
 
 		By("4) Verify SCC settings page is accessible via dashboard endpoint")
@@ -87,8 +99,7 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		e2e.Logf("Successfully verified: Rancher Prime cluster shows unregistered SCC status")
 	})
 
-	It("[QASE-18061] Test : Register SCC and verify registration status", Label("LEVEL0", "SCC", "E2E"), func() {
-		testCaseID = 18061
+	It("[QASE-18061] Test : Register SCC and verify registration status", Label("LEVEL0", "SCC", "E2E"), capabilities.Label(capabilities.SCC), func() {
 		By("1) Check if Rancher cluster is Prime")
 		isPrime, imageRegistry := utils.CheckIfRancherIsPrime(clientWithSession)
 		e2e.Logf("Rancher is Prime: %v, Image Registry: %s", isPrime, imageRegistry)
@@ -98,10 +109,10 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		}
 
 		By("2) Verify registration CRD exists")
-		checkRegistrationCRD := []string{"kubectl", "get", "crd", "registrations.scc.cattle.io"}
-		crdOutput, err := kubectl.Command(clientWithSession, nil, "local", checkRegistrationCRD, "")
-
-		if err != nil || strings.Contains(crdOutput, "NotFound") || strings.Contains(crdOutput, "not found") {
+		kubeClient, err := kube.NewClient(clientWithSession, kube.LocalCluster)
+		Expect(err).NotTo(HaveOccurred())
+		crdExists, err := kubeClient.Exists(context.TODO(), kube.CRDsGVR, "", sccRegistrationCRD)
+		if err != nil || !crdExists {
 			Fail("Failed SCC test: Rancher Prime Registration CRD not found.")
 		}
 
@@ -119,12 +130,8 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		if !strings.HasPrefix(rancherHostname, "http://") && !strings.HasPrefix(rancherHostname, "https://") {
 			rancherHostname = "https://" + rancherHostname
 		}
-		patchServerURL := []string{
-			"kubectl", "patch", "setting", "server-url",
-			"--type=merge",
-			"-p", fmt.Sprintf("{\"value\":\"%s\"}", rancherHostname),
-		}
-		_, err = kubectl.Command(clientWithSession, nil, "local", patchServerURL, "")
+		patchServerURL := fmt.Sprintf("{\"value\":\"%s\"}", rancherHostname)
+		_, err = kubeClient.Resource(settingGVR, "").Patch(context.TODO(), "server-url", types.MergePatchType, []byte(patchServerURL), metav1.PatchOptions{})
 		if err != nil {
 			e2e.Logf("Warning: Failed to patch server-url setting: %v", err)
 		} else {
@@ -136,14 +143,15 @@ var _ = Describe("SCC E2E Test Suite", func() {
 
 		By("5) Create SCC registration secret")
 		// Create secret with type 'secret' and correct field 'registrationType' instead of 'mode'
-		createSecret := []string{
-			"kubectl", "create", "secret", "generic", "scc-registration",
-			"-n", "cattle-scc-system",
-			"--type=secret",
-			"--from-literal=registrationType=online",
-			fmt.Sprintf("--from-literal=regCode=%s", regCode),
+		createSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "scc-registration", Namespace: sccNamespace},
+			Type:       "secret",
+			StringData: map[string]string{
+				"registrationType": "online",
+				"regCode":          regCode,
+			},
 		}
-		_, err = kubectl.Command(clientWithSession, nil, "local", createSecret, "")
+		_, err = kubeClient.Clientset.CoreV1().Secrets(sccNamespace).Create(context.TODO(), createSecret, metav1.CreateOptions{})
 		if err != nil {
 			Fail(fmt.Sprintf("Failed to create SCC registration secret: %v", err))
 		}
@@ -153,12 +161,13 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		DeferCleanup(func() {
 			By("Cleanup: Delete SCC registration resources")
 			// Delete registration resources first
-			deleteRegs := []string{"kubectl", "delete", "registrations.scc.cattle.io", "--all", "-n", "cattle-scc-system", "--ignore-not-found=true"}
-			_, _ = kubectl.Command(clientWithSession, nil, "local", deleteRegs, "")
+			_ = kubeClient.Resource(sccRegistrationGVR, "").DeleteCollection(context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{})
 
 			// Delete secret
-			deleteSecret := []string{"kubectl", "delete", "secret", "scc-registration", "-n", "cattle-scc-system", "--ignore-not-found=true"}
-			_, deleteErr := kubectl.Command(clientWithSession, nil, "local", deleteSecret, "")
+			deleteErr := kubeClient.Clientset.CoreV1().Secrets(sccNamespace).Delete(context.TODO(), "scc-registration", metav1.DeleteOptions{})
+			if k8serrors.IsNotFound(deleteErr) {
+				deleteErr = nil
+			}
 			if deleteErr != nil {
 				e2e.Logf("Warning: Failed to delete SCC registration secret: %v", deleteErr)
 			} else {
@@ -167,41 +176,21 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		})
 
 		By("6) Wait for registration resource to be created and fully processed")
-		var items []interface{}
+		var items []unstructured.Unstructured
 
 		// Poll with Eventually to handle timing differences between local and CI environments
 		Eventually(func() error {
-			getRegistrations := []string{"kubectl", "get", "registrations.scc.cattle.io", "-n", "cattle-scc-system", "-o", "json"}
-			output, err := kubectl.Command(clientWithSession, nil, "local", getRegistrations, "")
+			tempItems, err := kubeClient.List(context.TODO(), sccRegistrationGVR, "", metav1.ListOptions{})
 			if err != nil {
 				return fmt.Errorf("failed to get registration resources: %w", err)
 			}
-			if output == "" {
-				return fmt.Errorf("registration output is empty")
-			}
-
-			// Parse JSON output
-			var tempList map[string]interface{}
-			err = json.Unmarshal([]byte(output), &tempList)
-			if err != nil {
-				return fmt.Errorf("failed to parse registration JSON output: %w", err)
-			}
-
-			tempItems, ok := tempList["items"].([]interface{})
-			if !ok {
-				return fmt.Errorf("items field not found or invalid type in registration output")
-			}
 
 			if len(tempItems) == 0 {
 				return fmt.Errorf("no registration resources found yet, still waiting...")
 			}
 
 			// Check if registration has status (meaning it's been processed)
-			registration, ok := tempItems[0].(map[string]interface{})
-			if !ok {
-				return fmt.Errorf("registration item is not a valid object")
-			}
-
+			registration := tempItems[0].Object
 			status, hasStatus := registration["status"].(map[string]interface{})
 			if !hasStatus {
 				return fmt.Errorf("registration resource exists but status not yet populated")
@@ -221,7 +210,7 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		By("7) Verify registration resource is created and processed")
 		Expect(len(items)).To(BeNumerically(">", 0), "No registration resources found")
 
-		registration := items[0].(map[string]interface{})
+		registration := items[0].Object
 		spec := registration["spec"].(map[string]interface{})
 		status := registration["status"].(map[string]interface{})
 
@@ -247,8 +236,11 @@ var _ = Describe("SCC E2E Test Suite", func() {
 		e2e.Logf("Activation status verified successfully")
 
 		By("12) Verify rancher-scc-operator logs show successful registration")
-		getOperatorLogs := []string{"kubectl", "logs", "deployments/rancher-scc-operator", "scc-operator", "-n", "cattle-scc-system", "--tail=10"}
-		operatorLogs, err := kubectl.Command(clientWithSession, nil, "local", getOperatorLogs, "")
+		operatorPods, err := kubeClient.DeploymentPods(context.TODO(), sccNamespace, "rancher-scc-operator")
+		Expect(err).NotTo(HaveOccurred(), "Failed to get rancher-scc-operator pods")
+		Expect(operatorPods).NotTo(BeEmpty(), "No rancher-scc-operator pods found")
+		tailLines := int64(10)
+		operatorLogs, err := kubeClient.Logs(context.TODO(), sccNamespace, operatorPods[0].Name, corev1.PodLogOptions{Container: "scc-operator", TailLines: &tailLines})
 		Expect(err).NotTo(HaveOccurred(), "Failed to get rancher-scc-operator logs")
 		Expect(operatorLogs).NotTo(BeEmpty(), "Operator logs are empty")
 
@@ -0,0 +1,59 @@
+package e2e_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/scenario"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
+)
+
+// Run every scenario of a chart, or a directory of your own with SCENARIOS_DIR:
+// TEST_LABEL_FILTER="scenario && monitoring" go test -timeout 30m github.com/rancher/observability-e2e/tests/e2e -v -count=1 -ginkgo.v
+var _ = DescribeTable("Chart scenarios",
+	func(c scenarioCase) {
+		Expect(c.loadErr).NotTo(HaveOccurred(), "failed to load the chart scenarios")
+		s := c.scenario
+
+		clientWithSession, err := client.WithSession(sess)
+		Expect(err).NotTo(HaveOccurred())
+
+		installed, err := s.ChartInstalled(clientWithSession, cluster.ID)
+		Expect(err).NotTo(HaveOccurred())
+		if !installed {
+			Skip(fmt.Sprintf("%s is not installed", s.Chart))
+		}
+
+		By(fmt.Sprintf("Verifying scenario %s from %s", s.Name, s.File))
+		Eventually(func() ([]string, error) {
+			return s.Verify(clientWithSession, cluster.ID)
+		}, s.RetryTimeout(), 15*time.Second).Should(BeEmpty(), "scenario %s is not met", s.Name)
+	},
+	scenarioEntries(),
+)
+
+// scenarioCase is the parameter of a generated scenario entry.
+type scenarioCase struct {
+	scenario *scenario.Scenario
+	loadErr  error
+}
+
+// scenarioEntries generates one table entry per scenario file, labelled as the file says. A broken file turns into
+// a failing entry rather than aborting the whole suite.
+func scenarioEntries() []TableEntry {
+	dir := utils.GetEnvOrDefault("SCENARIOS_DIR", utils.GetYamlPath(scenario.DefaultDir))
+	scenarios, err := scenario.LoadDir(dir)
+	if err != nil {
+		return []TableEntry{Entry(fmt.Sprintf("Loading chart scenarios from %s", dir), Label("scenario"), scenarioCase{loadErr: err})}
+	}
+
+	entries := make([]TableEntry, 0, len(scenarios))
+	for _, s := range scenarios {
+		labels := []interface{}{Label(s.Labels...)}
+		entries = append(entries, charts.QaseEntry(s.Description(), labels, scenarioCase{scenario: s}))
+	}
+	return entries
+}
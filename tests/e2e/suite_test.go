@@ -15,39 +15,94 @@ limitations under the License.
 package e2e_test
 
 import (
+	"context"
 	"os"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	. "github.com/rancher-sandbox/qase-ginkgo"
 	"github.com/rancher/norman/types"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/reporting"
 	rancher "github.com/rancher/shepherd/clients/rancher"
 	management "github.com/rancher/shepherd/clients/rancher/generated/management/v3"
 	clusters "github.com/rancher/shepherd/extensions/clusters"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
 	session "github.com/rancher/shepherd/pkg/session"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
 var (
 	client          *rancher.Client
+	capabilityEnv   *capabilities.Environment
 	sess            *session.Session
 	project         *management.Project
 	cluster         *clusters.ClusterMeta
 	registrySetting *management.Setting
 	err             error
-	testCaseID      int64
+	// setupSess owns the suite wide objects the first parallel process creates for all of them.
+	setupSess *session.Session
+	// spec is the state of the running spec, see specState.
+	spec *specState
 )
 
-var _ = ReportAfterEach(func(report SpecReport) {
-	if testCaseID == 0 {
-		return
+// specState is what a single spec owns. Every spec gets a fresh one, so nothing leaks from one spec into the next,
+// and specs running in parallel processes only share the suite wide values above, which are read-only once the
+// suite is set up.
+type specState struct {
+	namespace string
+}
+
+// specNamespaceLabel marks the namespaces of specs, so leftovers can be found with
+// `-l observability-e2e/spec=true`.
+const specNamespaceLabel = "observability-e2e/spec"
+
+// Namespace returns the namespace of the spec on the local cluster, for the objects of the spec that do not have to
+// live in a chart namespace, such as probe pods. It is created on first use and deleted when the spec ends.
+func (s *specState) Namespace() string {
+	if s.namespace != "" {
+		return s.namespace
 	}
-	// Add result in Qase if asked
-	Qase(testCaseID, report)
+	kubeClient, err := kube.NewClient(client, kube.LocalCluster)
+	Expect(err).NotTo(HaveOccurred())
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   namegen.AppendRandomString("e2e-spec"),
+		Labels: map[string]string{specNamespaceLabel: "true"},
+	}}
+	_, err = kubeClient.Clientset.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred(), "Failed to create the namespace of the spec")
+	DeferCleanup(func() {
+		err := kubeClient.Clientset.CoreV1().Namespaces().Delete(context.TODO(), namespace.Name, metav1.DeleteOptions{})
+		if !k8serrors.IsNotFound(err) {
+			Expect(err).NotTo(HaveOccurred(), "Failed to delete the namespace of the spec")
+		}
+	})
+
+	s.namespace = namespace.Name
+	return s.namespace
+}
+
+// Every spec is reported to the Qase run of the environment, once for each of its [QASE-<id>] tags and labels.
+var _ = reporting.ReportAfterEach()
 
-	// This prevents the ID from "leaking" into the next test's ReportAfterEach execution.
-	testCaseID = 0
+// The JUnit and HTML reports of the suite are written to RESULTS_DIR.
+var _ = reporting.ReportAfterSuite()
+
+// A failed spec gets a diagnostics bundle of the chart namespaces and its own namespace, before its cleanup runs.
+var _ = JustAfterEach(func() {
+	reporting.CollectDiagnostics(client, cluster, charts.RancherMonitoringNamespace, charts.RancherLoggingNamespace, spec.namespace)
+})
+
+// Skip specs whose required capabilities are not provided by the Rancher under test
+var _ = BeforeEach(func() {
+	spec = &specState{}
+	capabilities.SkipUnsupported(capabilityEnv)
 })
 
 func FailWithReport(message string, callerSkip ...int) {
@@ -71,16 +126,48 @@ func TestE2E(t *testing.T) {
 	RunSpecs(t, "Observability End-To-End Test Suite", suiteConfig, reporterConfig)
 }
 
-// This setup will run once for the entire test suite
-var _ = BeforeSuite(func() {
-	project = nil
+// The first process finds or creates the project of the suite, so parallel processes do not race to create it.
+// Every process then sets up its own client and looks the project up.
+var _ = SynchronizedBeforeSuite(func() []byte {
+	setupSess = session.NewSession()
+	setupClient, err := rancher.NewClient("", setupSess)
+	Expect(err).NotTo(HaveOccurred())
+
+	setupCluster, err := clusters.NewClusterMeta(setupClient, setupClient.RancherConfig.ClusterName)
+	Expect(err).NotTo(HaveOccurred())
+
+	projectsList, err := setupClient.Management.Project.List(&types.ListOpts{
+		Filters: map[string]interface{}{
+			"clusterId": setupCluster.ID,
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	for i := range projectsList.Data {
+		if projectsList.Data[i].Name == exampleAppProjectName {
+			return []byte(projectsList.Data[i].ID)
+		}
+	}
 
+	projectConfig := &management.Project{
+		ClusterID: setupCluster.ID,
+		Name:      exampleAppProjectName,
+	}
+	created, err := setupClient.Management.Project.Create(projectConfig)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(created.Name).To(Equal(exampleAppProjectName))
+	return []byte(created.ID)
+}, func(projectID []byte) {
 	testSession := session.NewSession()
 	sess = testSession
 
 	client, err = rancher.NewClient("", testSession)
 	Expect(err).NotTo(HaveOccurred())
 
+	By("Detecting the Rancher capabilities")
+	capabilityEnv, err = capabilities.Detect(client)
+	Expect(err).NotTo(HaveOccurred())
+
 	// Get clusterName from config yaml
 	clusterName := client.RancherConfig.ClusterName
 	Expect(clusterName).NotTo(BeEmpty(), "Cluster name to install is not set")
@@ -93,35 +180,15 @@ var _ = BeforeSuite(func() {
 	registrySetting, err = client.Management.Setting.ByID("system-default-registry")
 	Expect(err).NotTo(HaveOccurred())
 
-	projectsList, err := client.Management.Project.List(&types.ListOpts{
-		Filters: map[string]interface{}{
-			"clusterId": cluster.ID,
-		},
-	})
+	project, err = client.Management.Project.ByID(string(projectID))
 	Expect(err).NotTo(HaveOccurred())
-
-	for i := range projectsList.Data {
-		p := &projectsList.Data[i]
-		if p.Name == exampleAppProjectName {
-			project = p
-			break
-		}
-	}
-
-	// Check if project was found
-	if project == nil {
-		projectConfig := &management.Project{
-			ClusterID: cluster.ID,
-			Name:      exampleAppProjectName,
-		}
-
-		project, err = client.Management.Project.Create(projectConfig)
-		Expect(err).NotTo(HaveOccurred())
-		Expect(project.Name).To(Equal(exampleAppProjectName))
-	}
 })
 
-// This teardown will run once after all the tests in the suite are done
-var _ = AfterSuite(func() {
+// Every process cleans up its own session, and the first one the suite wide objects once all processes are done.
+var _ = SynchronizedAfterSuite(func() {
 	sess.Cleanup()
+}, func() {
+	// The first process ends last, when every chart under test is installed
+	reporting.RecordEnvironment(client, cluster)
+	setupSess.Cleanup()
 })
@@ -0,0 +1,84 @@
+/*
+Copyright © 2024 - 2025 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/upgrade"
+	"github.com/rancher/observability-e2e/tests/helper/version"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// Run the matrix for one chart, choosing the hops with UPGRADE_HOPS (n-1, n-2, patches):
+// UPGRADE_HOPS=n-2,n-1,patches TEST_LABEL_FILTER="upgradeMatrix && monitoring" go test -timeout 180m github.com/rancher/observability-e2e/tests/e2e -v -count=1 -ginkgo.v
+var _ = DescribeTable("Observability chart upgrade matrix",
+	func(chart upgrade.Chart, requiresMonitoring bool) {
+		By("Creating a client session")
+		clientWithSession, err := client.WithSession(sess)
+		Expect(err).NotTo(HaveOccurred())
+
+		if requiresMonitoring {
+			monitoringChart, err := extencharts.GetChartStatus(clientWithSession, project.ClusterID, charts.RancherMonitoringNamespace, charts.RancherMonitoringName)
+			Expect(err).NotTo(HaveOccurred())
+			if !monitoringChart.IsAlreadyInstalled {
+				Skip(fmt.Sprintf("%s needs rancher-monitoring to be installed", chart.Name))
+			}
+		}
+
+		By(fmt.Sprintf("Planning the upgrade hops for %s", chart.Name))
+		versions, err := version.ListChartVersions(clientWithSession, chart.Name)
+		Expect(err).NotTo(HaveOccurred())
+		hops, err := upgrade.PlanHops(versions, upgrade.StrategiesFromEnv())
+		Expect(err).NotTo(HaveOccurred())
+		if len(hops) == 0 {
+			Skip(fmt.Sprintf("No older versions of %s to upgrade from", chart.Name))
+		}
+
+		runner := &upgrade.Runner{Client: clientWithSession, Cluster: cluster, ProjectID: project.ID}
+		installed, err := runner.Installed(chart)
+		Expect(err).NotTo(HaveOccurred())
+		if !installed {
+			// The hops leave the chart installed at the latest version, remove it with its CRDs once they are done
+			DeferCleanup(func() {
+				By(fmt.Sprintf("Uninstalling %s after the upgrade matrix", chart.Name))
+				Expect(runner.Uninstall(chart)).To(Succeed())
+			})
+		}
+
+		var failed []string
+		for _, hop := range hops {
+			By(fmt.Sprintf("Upgrading %s, %s", chart.Name, hop))
+			result := runner.Run(chart, hop)
+			AddReportEntry(fmt.Sprintf("upgrade hop %s", hop), result)
+			if !result.Passed {
+				failed = append(failed, result.String())
+			}
+		}
+
+		e2e.Logf("%d of %d upgrade hops of %s passed", len(hops)-len(failed), len(hops), chart.Name)
+		Expect(failed).To(BeEmpty(), "upgrade hops failed")
+	},
+	Serial,
+
+	Entry("rancher-monitoring", Label("upgradeMatrix", "monitoring"), upgrade.MonitoringChart(), false),
+	Entry("rancher-logging", Label("upgradeMatrix", "logging"), upgrade.LoggingChart(), false),
+	Entry("rancher-alerting-drivers", Label("upgradeMatrix", "rancher-alert"), upgrade.AlertingChart(), false),
+	Entry("prometheus-federator", Label("upgradeMatrix", "promfed"), upgrade.PrometheusFederatorChart(), true),
+)
@@ -0,0 +1,298 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// Namespace is where rancher-alerting-drivers and the delivery objects live.
+	Namespace = "cattle-monitoring-system"
+
+	// SachetURL and Prom2TeamsURL are the Alertmanager webhook endpoints of the drivers. prom2teams serves the
+	// connector configured through the "connector" value as "Connector".
+	SachetURL     = "http://rancher-alerting-drivers-sachet.cattle-monitoring-system.svc:9876/alert"
+	Prom2TeamsURL = "http://rancher-alerting-drivers-prom2teams.cattle-monitoring-system.svc:8089/v2/Connector"
+
+	// DeliveryPrefix prefixes the generated name of the AlertmanagerConfig and PrometheusRule of the delivery check.
+	DeliveryPrefix = "e2e-driver-delivery"
+
+	SMSReceiver   = "sms"
+	TeamsReceiver = "teams"
+	SMSSender     = "e2e-alerts"
+	SMSRecipient  = "+15550100"
+	// SMSText is the sachet message template, giving "<status> <alertname>".
+	SMSText = "{{ .Status }} {{ .CommonLabels.alertname }}"
+
+	smsPath   = "/sms"
+	teamsPath = "/teams"
+	// deliveryLabel routes the test alert to the delivery receivers only. Its value is the delivery name.
+	deliveryLabel = "e2e_delivery"
+
+	// resolvedPrefix and resolvedColor mark the resolved cards of the prom2teams template, which prefixes the
+	// summary and the title with the status and colors the card green.
+	resolvedPrefix = "(Resolved)"
+	resolvedColor  = "2DC72D"
+)
+
+// Alert states as they appear in the notifications.
+const (
+	Firing   = "firing"
+	Resolved = "resolved"
+)
+
+var (
+	prometheusRuleGVR     = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules"}
+	alertmanagerConfigGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1alpha1", Resource: "alertmanagerconfigs"}
+)
+
+// DriverValues returns the sachet and prom2teams values that send SMS of the named delivery through a kannel
+// provider and Teams cards to the recorder.
+func DriverValues(recorder *Recorder, deliveryName string) (sms, teams map[string]interface{}) {
+	sms = map[string]interface{}{
+		"providers": map[string]interface{}{
+			"kannel": map[string]interface{}{
+				"url":      recorder.URL(smsPath),
+				"username": "e2e",
+				"password": "e2e",
+			},
+		},
+		"receivers": []interface{}{map[string]interface{}{
+			// The operator prefixes AlertmanagerConfig receivers with their namespace and config name, and
+			// sachet picks its receiver by that name.
+			"name":     fmt.Sprintf("%s/%s/%s", Namespace, deliveryName, SMSReceiver),
+			"provider": "kannel",
+			"from":     SMSSender,
+			"to":       []interface{}{SMSRecipient},
+			"text":     SMSText,
+		}},
+	}
+	teams = map[string]interface{}{
+		"connector": recorder.URL(teamsPath),
+	}
+	return sms, teams
+}
+
+// CreateDelivery creates an AlertmanagerConfig sending the test alert to both drivers with sendResolved set, and
+// a PrometheusRule firing the alert, both with the given name.
+func CreateDelivery(client *rancher.Client, clusterID, name, alertName, summary string) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+
+	webhook := func(url string) []interface{} {
+		return []interface{}{map[string]interface{}{"url": url, "sendResolved": true}}
+	}
+	config := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1alpha1",
+		"kind":       "AlertmanagerConfig",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"receivers": []interface{}{
+				map[string]interface{}{"name": SMSReceiver, "webhookConfigs": webhook(SachetURL)},
+				map[string]interface{}{"name": TeamsReceiver, "webhookConfigs": webhook(Prom2TeamsURL)},
+			},
+			"route": map[string]interface{}{
+				"receiver":       SMSReceiver,
+				"groupBy":        []interface{}{"alertname"},
+				"groupWait":      "10s",
+				"groupInterval":  "10s",
+				"repeatInterval": "1h",
+				"matchers": []interface{}{map[string]interface{}{
+					"name":      deliveryLabel,
+					"matchType": "=",
+					"value":     name,
+				}},
+				"routes": []interface{}{
+					map[string]interface{}{"receiver": SMSReceiver, "continue": true},
+					map[string]interface{}{"receiver": TeamsReceiver},
+				},
+			},
+		},
+	}}
+	if _, err := dynamicClient.Resource(alertmanagerConfigGVR).Namespace(Namespace).Create(context.TODO(), config, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create AlertmanagerConfig %s: %w", name, err)
+	}
+
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"groups": []interface{}{map[string]interface{}{
+				"name":     name,
+				"interval": "15s",
+				"rules": []interface{}{map[string]interface{}{
+					"alert": alertName,
+					"expr":  "vector(1)",
+					"labels": map[string]interface{}{
+						deliveryLabel: name,
+						// AlertmanagerConfig routes only match alerts of their own namespace.
+						"namespace": Namespace,
+						"severity":  "warning",
+					},
+					"annotations": map[string]interface{}{"summary": summary},
+				}},
+			}},
+		},
+	}}
+	if _, err := dynamicClient.Resource(prometheusRuleGVR).Namespace(Namespace).Create(context.TODO(), rule, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create PrometheusRule %s: %w", name, err)
+	}
+	return nil
+}
+
+// ResolveDelivery makes the test alert expression of the named delivery empty, so Prometheus resolves the alert on
+// its next evaluation.
+func ResolveDelivery(client *rancher.Client, clusterID, name string) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+	resource := dynamicClient.Resource(prometheusRuleGVR).Namespace(Namespace)
+
+	rule, err := resource.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	groups, _, err := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	if err != nil || len(groups) == 0 {
+		return fmt.Errorf("PrometheusRule %s has no groups: %v", name, err)
+	}
+	group, ok := groups[0].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("PrometheusRule %s has a malformed group: %v", name, groups[0])
+	}
+	rules, _, err := unstructured.NestedSlice(group, "rules")
+	if err != nil || len(rules) == 0 {
+		return fmt.Errorf("PrometheusRule %s has no rules: %v", name, err)
+	}
+	for _, r := range rules {
+		entry, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("PrometheusRule %s has a malformed rule: %v", name, r)
+		}
+		entry["expr"] = "vector(1) < 0"
+	}
+	if err := unstructured.SetNestedSlice(group, rules, "rules"); err != nil {
+		return err
+	}
+	groups[0] = group
+	if err := unstructured.SetNestedSlice(rule.Object, groups, "spec", "groups"); err != nil {
+		return err
+	}
+	_, err = resource.Update(context.TODO(), rule, metav1.UpdateOptions{})
+	return err
+}
+
+// DeleteDelivery removes the AlertmanagerConfig and PrometheusRule of the named delivery check.
+func DeleteDelivery(client *rancher.Client, clusterID, name string) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+	for _, gvr := range []schema.GroupVersionResource{prometheusRuleGVR, alertmanagerConfigGVR} {
+		err := dynamicClient.Resource(gvr).Namespace(Namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifySMS checks that sachet sent an SMS with the given status for the alert, from SMSSender to SMSRecipient.
+func VerifySMS(records []Record, alertName, status string) error {
+	expected := status + " " + alertName
+	var texts []string
+	for _, record := range records {
+		if record.Path != smsPath {
+			continue
+		}
+		text := first(record.Query["text"])
+		if text != expected {
+			texts = append(texts, text)
+			continue
+		}
+		if from := first(record.Query["from"]); from != SMSSender {
+			return fmt.Errorf("SMS %q was sent from %q, expected %q", text, from, SMSSender)
+		}
+		if to := first(record.Query["to"]); to != SMSRecipient {
+			return fmt.Errorf("SMS %q was sent to %q, expected %q", text, to, SMSRecipient)
+		}
+		return nil
+	}
+	return fmt.Errorf("no SMS %q was delivered, got %q", expected, texts)
+}
+
+// teamsCard is the part of a prom2teams MessageCard the delivery check reads.
+type teamsCard struct {
+	Type       string `json:"@type"`
+	Title      string `json:"title"`
+	Summary    string `json:"summary"`
+	ThemeColor string `json:"themeColor"`
+	Sections   []struct {
+		ActivityTitle string `json:"activityTitle"`
+		Text          string `json:"text"`
+	} `json:"sections"`
+}
+
+// resolved reports whether prom2teams rendered the card for a resolved alert, by the status prefix of its title
+// or summary, or by its color.
+func (c teamsCard) resolved() bool {
+	return strings.HasPrefix(strings.TrimSpace(c.Summary), resolvedPrefix) ||
+		strings.Contains(c.Title, resolvedPrefix) ||
+		strings.EqualFold(strings.TrimSpace(c.ThemeColor), resolvedColor)
+}
+
+// describes reports whether a section of the card is about the alert summary.
+func (c teamsCard) describes(summary string) bool {
+	for _, section := range c.Sections {
+		if strings.TrimSpace(section.ActivityTitle) == summary || strings.Contains(section.Text, summary) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyTeams checks that prom2teams posted a MessageCard for the alert summary, marked resolved or not as asked.
+func VerifyTeams(records []Record, summary string, resolved bool) error {
+	cards := 0
+	for _, record := range records {
+		if record.Path != teamsPath {
+			continue
+		}
+		cards++
+
+		var card teamsCard
+		if err := json.Unmarshal([]byte(record.Body), &card); err != nil {
+			return fmt.Errorf("Teams message is not JSON: %w: %s", err, record.Body)
+		}
+		if card.Type != "MessageCard" {
+			return fmt.Errorf("Teams message is a %q, expected a MessageCard: %s", card.Type, record.Body)
+		}
+		if card.describes(summary) && card.resolved() == resolved {
+			return nil
+		}
+	}
+	state := Firing
+	if resolved {
+		state = Resolved
+	}
+	return fmt.Errorf("none of %d Teams cards is a %s card for %q", cards, state, summary)
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,61 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// card returns the body of a MessageCard as the prom2teams template renders it.
+func card(title, summary, themeColor, activityTitle string) string {
+	return fmt.Sprintf(`{
+	"@type": "MessageCard",
+	"@context": "http://schema.org/extensions",
+	"themeColor": %q,
+	"summary": %q,
+	"title": %q,
+	"sections": [{
+		"activityTitle": %q,
+		"facts": [{"name": "Status", "value": "firing"}],
+		"markdown": true
+	}]
+}`, themeColor, summary, title, activityTitle)
+}
+
+func TestVerifyTeams(t *testing.T) {
+	const summary = "Alerting drivers delivery check E2EDriverDeliveryabcde"
+	firing := card("Prometheus alert ", summary, " FFA500 ", summary)
+	resolved := card("Prometheus alert (Resolved) ", "(Resolved) "+summary, " 2DC72D ", summary)
+
+	tests := []struct {
+		name     string
+		records  []Record
+		resolved bool
+		wantErr  string
+	}{
+		{name: "firing", records: []Record{{Path: teamsPath, Body: firing}}},
+		{name: "resolved", records: []Record{{Path: teamsPath, Body: firing}, {Path: teamsPath, Body: resolved}}, resolved: true},
+		{name: "resolved before it fired", records: []Record{{Path: teamsPath, Body: resolved}}, wantErr: "none of 1 Teams cards is a firing card"},
+		{name: "not resolved yet", records: []Record{{Path: teamsPath, Body: firing}}, resolved: true, wantErr: "none of 1 Teams cards is a resolved card"},
+		{name: "resolved by color", records: []Record{{Path: teamsPath, Body: card("Prometheus alert", summary, "2dc72d", summary)}}, resolved: true},
+		{name: "resolved by title", records: []Record{{Path: teamsPath, Body: card("Prometheus alert (Resolved)", summary, "FFA500", summary)}}, resolved: true},
+		// The raw body of a firing card can mention "resolved" without the card being resolved.
+		{name: "resolved in a fact", records: []Record{{Path: teamsPath, Body: strings.Replace(firing, `"value": "firing"`, `"value": "resolved soon"`, 1)}}, resolved: true, wantErr: "is a resolved card"},
+		{name: "summary outside the sections", records: []Record{{Path: teamsPath, Body: card("Prometheus alert ", summary, "FFA500", "another alert")}}, wantErr: "none of 1 Teams cards"},
+		{name: "other alert", records: []Record{{Path: teamsPath, Body: card("Prometheus alert ", "other", "FFA500", "other")}}, wantErr: "none of 1 Teams cards"},
+		{name: "SMS only", records: []Record{{Path: smsPath, Body: firing}}, wantErr: "none of 0 Teams cards"},
+		{name: "not JSON", records: []Record{{Path: teamsPath, Body: "1"}}, wantErr: "not JSON"},
+		{name: "not a MessageCard", records: []Record{{Path: teamsPath, Body: `{"@type":"AdaptiveCard"}`}}, wantErr: "expected a MessageCard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyTeams(tt.records, summary, tt.resolved)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package alerting
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/probe"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/shepherd/clients/rancher"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	recorderImage = "library/python:3.12-alpine"
+	recorderPort  = 8080
+)
+
+var (
+	configMapGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	serviceGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+// recorderScript records every request it gets and returns them on GET /records. Kannel style GET requests are
+// answered with 202 and POST requests with 200, which is what the SMS and Teams clients of the drivers expect.
+const recorderScript = `import json
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from urllib.parse import parse_qs, urlsplit
+
+records = []
+
+
+class Handler(BaseHTTPRequestHandler):
+    def reply(self, code, body, content_type="text/plain"):
+        data = body.encode()
+        self.send_response(code)
+        self.send_header("Content-Type", content_type)
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    def record(self):
+        url = urlsplit(self.path)
+        length = int(self.headers.get("Content-Length") or 0)
+        body = self.rfile.read(length).decode("utf-8", "replace")
+        records.append({"method": self.command, "path": url.path, "query": parse_qs(url.query), "body": body})
+
+    def do_GET(self):
+        if self.path == "/records":
+            return self.reply(200, json.dumps(records), "application/json")
+        self.record()
+        self.reply(202, "0: Accepted for delivery")
+
+    def do_POST(self):
+        self.record()
+        self.reply(200, "1")
+
+    def do_DELETE(self):
+        records.clear()
+        self.reply(200, "")
+
+    def log_message(self, *args):
+        pass
+
+
+ThreadingHTTPServer(("", 8080), Handler).serve_forever()
+`
+
+// Record is a request received by the recorder.
+type Record struct {
+	Method string              `json:"method"`
+	Path   string              `json:"path"`
+	Query  map[string][]string `json:"query"`
+	Body   string              `json:"body"`
+}
+
+// Recorder is an in-cluster HTTP receiver standing in for the SMS provider and the Teams webhook of the
+// alerting drivers. Its records are read through the Rancher service proxy.
+type Recorder struct {
+	ClusterID string
+	Namespace string
+	Name      string
+
+	proxyURL   string
+	token      string
+	httpClient *http.Client
+}
+
+// DeployRecorder deploys a recorder in the namespace and waits for it to be ready. The image is pulled through the
+// system-default-registry, so air-gapped installs need it mirrored as library/python.
+func DeployRecorder(client *rancher.Client, clusterID, namespace, name string) (*Recorder, error) {
+	registry, err := probe.DefaultRegistry(client)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]interface{}{"app": name}
+	objects := []struct {
+		gvr    schema.GroupVersionResource
+		object map[string]interface{}
+	}{
+		{configMapGVR, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name},
+			"data":       map[string]interface{}{"recorder.py": recorderScript},
+		}},
+		{deploymentGVR, map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"selector": map[string]interface{}{"matchLabels": labels},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": labels},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{
+							"name":    "recorder",
+							"image":   probe.WithRegistry(recorderImage, registry),
+							"command": []interface{}{"python", "-u", "/app/recorder.py"},
+							"ports": []interface{}{map[string]interface{}{
+								"name":          "http",
+								"containerPort": int64(recorderPort),
+							}},
+							"readinessProbe": map[string]interface{}{
+								"tcpSocket": map[string]interface{}{"port": int64(recorderPort)},
+							},
+							"volumeMounts": []interface{}{map[string]interface{}{"name": "app", "mountPath": "/app"}},
+						}},
+						"volumes": []interface{}{map[string]interface{}{
+							"name":      "app",
+							"configMap": map[string]interface{}{"name": name},
+						}},
+					},
+				},
+			},
+		}},
+		{serviceGVR, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+			"spec": map[string]interface{}{
+				"selector": labels,
+				"ports": []interface{}{map[string]interface{}{
+					"name":       "http",
+					"port":       int64(recorderPort),
+					"targetPort": "http",
+				}},
+			},
+		}},
+	}
+
+	for _, obj := range objects {
+		object := &unstructured.Unstructured{Object: obj.object}
+		if _, err := dynamicClient.Resource(obj.gvr).Namespace(namespace).Create(context.TODO(), object, metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create %s %s/%s: %w", obj.gvr.Resource, namespace, name, err)
+		}
+	}
+
+	err = wait.PollUntilContextTimeout(context.TODO(), 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		deployment, err := dynamicClient.Resource(deploymentGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		ready, _, _ := unstructured.NestedInt64(deployment.Object, "status", "readyReplicas")
+		return ready > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recorder %s/%s is not ready: %w", namespace, name, err)
+	}
+
+	return &Recorder{
+		ClusterID: clusterID,
+		Namespace: namespace,
+		Name:      name,
+		proxyURL:  promclient.ServiceProxyURL(client.RancherConfig.Host, clusterID, namespace, name, recorderPort),
+		token:     client.RancherConfig.AdminToken,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+	}, nil
+}
+
+// URL returns the in-cluster URL of the recorder for the given path, to be configured in the drivers.
+func (r *Recorder) URL(path string) string {
+	return fmt.Sprintf("http://%s.%s.svc:%d%s", r.Name, r.Namespace, recorderPort, path)
+}
+
+// Records returns the requests received so far, oldest first.
+func (r *Recorder) Records() ([]Record, error) {
+	data, err := r.do(http.MethodGet, "/records")
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode the recorder records: %w", err)
+	}
+	return records, nil
+}
+
+// Clear drops the requests received so far.
+func (r *Recorder) Clear() error {
+	_, err := r.do(http.MethodDelete, "/records")
+	return err
+}
+
+// Delete removes the recorder from the cluster.
+func (r *Recorder) Delete(client *rancher.Client) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(r.ClusterID)
+	if err != nil {
+		return err
+	}
+	for _, gvr := range []schema.GroupVersionResource{serviceGVR, deploymentGVR, configMapGVR} {
+		err := dynamicClient.Resource(gvr).Namespace(r.Namespace).Delete(context.TODO(), r.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) do(method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, r.proxyURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("recorder request %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("recorder request %s %s returned %d: %s", method, path, resp.StatusCode, data)
+	}
+	return data, nil
+}
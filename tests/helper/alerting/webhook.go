@@ -0,0 +1,38 @@
+package alerting
+
+import "encoding/json"
+
+// WebhookPath is the recorder path Alertmanager webhook receivers are pointed at.
+const WebhookPath = "/webhook"
+
+// WebhookMessage is the payload Alertmanager posts to webhook receivers.
+type WebhookMessage struct {
+	Receiver    string            `json:"receiver"`
+	Status      string            `json:"status"`
+	GroupLabels map[string]string `json:"groupLabels"`
+	Alerts      []struct {
+		Status string            `json:"status"`
+		Labels map[string]string `json:"labels"`
+	} `json:"alerts"`
+}
+
+// WebhookNotifications counts the webhook notifications recorded on WebhookPath that carry the alert.
+func WebhookNotifications(records []Record, alertName string) int {
+	count := 0
+	for _, record := range records {
+		if record.Path != WebhookPath {
+			continue
+		}
+		message := WebhookMessage{}
+		if err := json.Unmarshal([]byte(record.Body), &message); err != nil {
+			continue
+		}
+		for _, alert := range message.Alerts {
+			if alert.Labels["alertname"] == alertName {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,164 @@
+package alertmanager
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+)
+
+// Client talks to the Alertmanager v2 API.
+type Client struct {
+	baseURL     string
+	bearerToken string
+	httpClient  *http.Client
+}
+
+// NewClient returns a client for the Alertmanager at alertmanagerURL.
+func NewClient(alertmanagerURL, bearerToken string) *Client {
+	return &Client{
+		baseURL:     strings.TrimSuffix(alertmanagerURL, "/"),
+		bearerToken: bearerToken,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+	}
+}
+
+// RancherMonitoringURL returns the proxy URL of the Alertmanager deployed by rancher-monitoring on the given cluster.
+func RancherMonitoringURL(host, clusterID string) string {
+	return promclient.ServiceProxyURL(host, clusterID, "cattle-monitoring-system", "rancher-monitoring-alertmanager", 9093)
+}
+
+// Alert is an alert posted to Alertmanager.
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt,omitempty"`
+	EndsAt       time.Time         `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Receiver is an Alertmanager receiver reference.
+type Receiver struct {
+	Name string `json:"name"`
+}
+
+// AlertStatus is the state of an alert: active, suppressed or unprocessed.
+type AlertStatus struct {
+	State       string   `json:"state"`
+	SilencedBy  []string `json:"silencedBy"`
+	InhibitedBy []string `json:"inhibitedBy"`
+}
+
+// Alert states.
+const (
+	StateActive     = "active"
+	StateSuppressed = "suppressed"
+)
+
+// GettableAlert is an alert as Alertmanager returns it.
+type GettableAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Fingerprint string            `json:"fingerprint"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Receivers   []Receiver        `json:"receivers"`
+	Status      AlertStatus       `json:"status"`
+}
+
+// AlertGroup is an aggregation group: the alerts a route groups together by its groupBy labels.
+type AlertGroup struct {
+	Labels   map[string]string `json:"labels"`
+	Receiver Receiver          `json:"receiver"`
+	Alerts   []GettableAlert   `json:"alerts"`
+}
+
+// PostAlerts sends the alerts to Alertmanager.
+func (c *Client) PostAlerts(alerts ...Alert) error {
+	return c.do(http.MethodPost, "/api/v2/alerts", nil, alerts, nil)
+}
+
+// Alerts returns the alerts matching all the filter matchers, such as `alertname="Watchdog"`.
+func (c *Client) Alerts(filter ...string) ([]GettableAlert, error) {
+	var alerts []GettableAlert
+	if err := c.do(http.MethodGet, "/api/v2/alerts", filterParams(filter), nil, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// Groups returns the alert groups holding alerts that match all the filter matchers.
+func (c *Client) Groups(filter ...string) ([]AlertGroup, error) {
+	var groups []AlertGroup
+	if err := c.do(http.MethodGet, "/api/v2/alerts/groups", filterParams(filter), nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func filterParams(filter []string) url.Values {
+	params := url.Values{}
+	for _, matcher := range filter {
+		params.Add("filter", matcher)
+	}
+	return params
+}
+
+func (c *Client) do(method, path string, params url.Values, in, out interface{}) error {
+	target := c.baseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("alertmanager request %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alertmanager request %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode alertmanager response of %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	"gopkg.in/yaml.v3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var AlertmanagerConfigGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1alpha1", Resource: "alertmanagerconfigs"}
+
+// Matcher is an AlertmanagerConfig route matcher. Older configs set Regex instead of MatchType.
+type Matcher struct {
+	Name      string `yaml:"name" json:"name"`
+	Value     string `yaml:"value" json:"value"`
+	MatchType string `yaml:"matchType,omitempty" json:"matchType,omitempty"`
+	Regex     bool   `yaml:"regex,omitempty" json:"regex,omitempty"`
+}
+
+// Route is an AlertmanagerConfig route.
+type Route struct {
+	Receiver       string    `yaml:"receiver,omitempty" json:"receiver,omitempty"`
+	GroupBy        []string  `yaml:"groupBy,omitempty" json:"groupBy,omitempty"`
+	GroupWait      string    `yaml:"groupWait,omitempty" json:"groupWait,omitempty"`
+	GroupInterval  string    `yaml:"groupInterval,omitempty" json:"groupInterval,omitempty"`
+	RepeatInterval string    `yaml:"repeatInterval,omitempty" json:"repeatInterval,omitempty"`
+	Matchers       []Matcher `yaml:"matchers,omitempty" json:"matchers,omitempty"`
+	Continue       bool      `yaml:"continue,omitempty" json:"continue,omitempty"`
+	Routes         []Route   `yaml:"routes,omitempty" json:"routes,omitempty"`
+}
+
+// InhibitRule is an AlertmanagerConfig inhibit rule. The operator restricts both matchers to the config namespace.
+type InhibitRule struct {
+	SourceMatch []Matcher `yaml:"sourceMatch,omitempty" json:"sourceMatch,omitempty"`
+	TargetMatch []Matcher `yaml:"targetMatch,omitempty" json:"targetMatch,omitempty"`
+	Equal       []string  `yaml:"equal,omitempty" json:"equal,omitempty"`
+}
+
+// AlertmanagerConfig is the routing part of an AlertmanagerConfig object. Receivers are kept as they are.
+type AlertmanagerConfig struct {
+	Name         string
+	Namespace    string
+	Labels       map[string]string
+	Route        Route
+	InhibitRules []InhibitRule
+	Receivers    []map[string]interface{}
+}
+
+// ParseAlertmanagerConfig reads an AlertmanagerConfig manifest, such as a rendered fixture template.
+func ParseAlertmanagerConfig(data []byte) (*AlertmanagerConfig, error) {
+	manifest := struct {
+		Metadata struct {
+			Name      string            `yaml:"name"`
+			Namespace string            `yaml:"namespace"`
+			Labels    map[string]string `yaml:"labels"`
+		} `yaml:"metadata"`
+		Spec struct {
+			Route        Route                    `yaml:"route"`
+			InhibitRules []InhibitRule            `yaml:"inhibitRules"`
+			Receivers    []map[string]interface{} `yaml:"receivers"`
+		} `yaml:"spec"`
+	}{}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the AlertmanagerConfig manifest: %w", err)
+	}
+	return &AlertmanagerConfig{
+		Name:         manifest.Metadata.Name,
+		Namespace:    manifest.Metadata.Namespace,
+		Labels:       manifest.Metadata.Labels,
+		Route:        manifest.Spec.Route,
+		InhibitRules: manifest.Spec.InhibitRules,
+		Receivers:    manifest.Spec.Receivers,
+	}, nil
+}
+
+// ReceiverName returns the name the operator gives a receiver of the config in the generated configuration.
+func (c *AlertmanagerConfig) ReceiverName(receiver string) string {
+	return fmt.Sprintf("%s/%s/%s", c.Namespace, c.Name, receiver)
+}
+
+// Routing is where the simulator expects an alert to be delivered.
+type Routing struct {
+	// Receiver is the generated receiver name, see ReceiverName.
+	Receiver string
+	// GroupKey holds the values of the route's groupBy labels, which identify the alert group.
+	GroupKey map[string]string
+}
+
+// Simulate returns where the config routes an alert with the given labels, in route order. It applies the
+// namespace matcher the operator injects into the top route, so alerts of other namespaces are not routed by the
+// config at all and get no routing.
+func (c *AlertmanagerConfig) Simulate(labels map[string]string) []Routing {
+	top := c.Route
+	top.Matchers = append([]Matcher{{Name: "namespace", Value: c.Namespace, MatchType: "="}}, top.Matchers...)
+	return c.simulate(top, nil, labels)
+}
+
+func (c *AlertmanagerConfig) simulate(route Route, parentGroupBy []string, labels map[string]string) []Routing {
+	if !matchesAll(route.Matchers, labels) {
+		return nil
+	}
+	groupBy := route.GroupBy
+	if len(groupBy) == 0 {
+		groupBy = parentGroupBy
+	}
+
+	var routings []Routing
+	for _, child := range route.Routes {
+		if child.Receiver == "" {
+			child.Receiver = route.Receiver
+		}
+		matched := c.simulate(child, groupBy, labels)
+		routings = append(routings, matched...)
+		if len(matched) > 0 && !child.Continue {
+			return routings
+		}
+	}
+	if len(routings) > 0 {
+		return routings
+	}
+
+	key := map[string]string{}
+	for _, name := range groupBy {
+		if value, ok := labels[name]; ok {
+			key[name] = value
+		}
+	}
+	return []Routing{{Receiver: c.ReceiverName(route.Receiver), GroupKey: key}}
+}
+
+func matchesAll(matchers []Matcher, labels map[string]string) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether a label value satisfies the matcher. Regular expressions are anchored as in Alertmanager.
+func (m Matcher) Matches(value string) bool {
+	matchType := m.MatchType
+	if matchType == "" {
+		matchType = "="
+		if m.Regex {
+			matchType = "=~"
+		}
+	}
+
+	switch matchType {
+	case "=":
+		return value == m.Value
+	case "!=":
+		return value != m.Value
+	case "=~", "!~":
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value) == (matchType == "=~")
+	default:
+		return false
+	}
+}
+
+// Create creates the config on the cluster.
+func (c *AlertmanagerConfig) Create(client *rancher.Client, clusterID string) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]interface{}{}
+	for key, value := range c.Labels {
+		labels[key] = value
+	}
+	receivers := make([]interface{}, 0, len(c.Receivers))
+	for _, receiver := range c.Receivers {
+		receivers = append(receivers, receiver)
+	}
+	route, err := toUnstructured(c.Route)
+	if err != nil {
+		return err
+	}
+	fields := map[string]interface{}{"route": route, "receivers": receivers}
+	if len(c.InhibitRules) > 0 {
+		fields["inhibitRules"] = c.InhibitRules
+	}
+	spec, err := toUnstructured(fields)
+	if err != nil {
+		return err
+	}
+
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": AlertmanagerConfigGVR.GroupVersion().String(),
+		"kind":       "AlertmanagerConfig",
+		"metadata": map[string]interface{}{
+			"name":      c.Name,
+			"namespace": c.Namespace,
+			"labels":    labels,
+		},
+		"spec": spec,
+	}}
+	if _, err := dynamicClient.Resource(AlertmanagerConfigGVR).Namespace(c.Namespace).Create(context.TODO(), object, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create AlertmanagerConfig %s/%s: %w", c.Namespace, c.Name, err)
+	}
+	return nil
+}
+
+// Delete removes the config from the cluster.
+func (c *AlertmanagerConfig) Delete(client *rancher.Client, clusterID string) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+	err = dynamicClient.Resource(AlertmanagerConfigGVR).Namespace(c.Namespace).Delete(context.TODO(), c.Name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// VerifyRouting compares where Alertmanager grouped each alert with what Simulate expects. The alerts must already
+// be posted and carry a unique alertname. It returns one line per mismatch: a missing or unexpected receiver, a
+// wrong group key, or an alert of another namespace reaching a receiver of the config.
+func VerifyRouting(am *Client, config *AlertmanagerConfig, alerts []Alert) ([]string, error) {
+	var problems []string
+	for _, alert := range alerts {
+		groups, err := am.Groups(fmt.Sprintf("alertname=%q", alert.Labels["alertname"]))
+		if err != nil {
+			return nil, err
+		}
+
+		actual := map[string]map[string]string{}
+		for _, group := range groups {
+			for _, candidate := range group.Alerts {
+				if sameLabels(candidate.Labels, alert.Labels) {
+					actual[group.Receiver.Name] = group.Labels
+				}
+			}
+		}
+
+		expected := map[string]map[string]string{}
+		for _, routing := range config.Simulate(alert.Labels) {
+			expected[routing.Receiver] = routing.GroupKey
+		}
+
+		name := alert.Labels["alertname"]
+		for receiver, key := range expected {
+			got, ok := actual[receiver]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s did not reach %s, reached %v", name, receiver, sortedKeys(actual)))
+				continue
+			}
+			if !sameLabels(got, key) {
+				problems = append(problems, fmt.Sprintf("%s was grouped by %v at %s, expected %v", name, got, receiver, key))
+			}
+		}
+		prefix := config.ReceiverName("")
+		for receiver := range actual {
+			if _, ok := expected[receiver]; !ok && strings.HasPrefix(receiver, prefix) {
+				problems = append(problems, fmt.Sprintf("%s unexpectedly reached %s", name, receiver))
+			}
+		}
+	}
+	return problems, nil
+}
+
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toUnstructured converts a value to the JSON types unstructured objects accept.
+func toUnstructured(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,159 @@
+package alertmanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+const routingManifest = `apiVersion: monitoring.coreos.com/v1alpha1
+kind: AlertmanagerConfig
+metadata:
+  name: routing
+  namespace: cattle-monitoring-system
+spec:
+  route:
+    receiver: default
+    groupBy: [alertname]
+    routes:
+      - receiver: critical
+        matchers:
+          - {name: severity, value: critical}
+        continue: true
+      - receiver: pager
+        groupBy: [alertname, team]
+        matchers:
+          - {name: severity, value: "critical|page", matchType: "=~"}
+        routes:
+          - matchers:
+              - {name: team, value: db}
+          - receiver: frontend
+            matchers:
+              - {name: team, value: "front.*", regex: true}
+      - receiver: quiet
+        matchers:
+          - {name: team, value: ops, matchType: "!="}
+  receivers:
+    - name: default
+    - name: critical
+    - name: pager
+    - name: frontend
+    - name: quiet
+`
+
+func TestSimulate(t *testing.T) {
+	config, err := ParseAlertmanagerConfig([]byte(routingManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routing := func(receiver string, key map[string]string) Routing {
+		return Routing{Receiver: "cattle-monitoring-system/routing/" + receiver, GroupKey: key}
+	}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []Routing
+	}{
+		{
+			name:   "continue reaches the next sibling and a child inherits the receiver",
+			labels: map[string]string{"namespace": "cattle-monitoring-system", "alertname": "A", "severity": "critical", "team": "db"},
+			want: []Routing{
+				routing("critical", map[string]string{"alertname": "A"}),
+				routing("pager", map[string]string{"alertname": "A", "team": "db"}),
+			},
+		},
+		{
+			name:   "legacy regex matcher of a nested route",
+			labels: map[string]string{"namespace": "cattle-monitoring-system", "alertname": "B", "severity": "page", "team": "frontend"},
+			want:   []Routing{routing("frontend", map[string]string{"alertname": "B", "team": "frontend"})},
+		},
+		{
+			name:   "parent route when no child matches",
+			labels: map[string]string{"namespace": "cattle-monitoring-system", "alertname": "C", "severity": "page", "team": "ops"},
+			want:   []Routing{routing("pager", map[string]string{"alertname": "C", "team": "ops"})},
+		},
+		{
+			name:   "regex matchers are anchored",
+			labels: map[string]string{"namespace": "cattle-monitoring-system", "alertname": "D", "severity": "paged", "team": "dev"},
+			want:   []Routing{routing("quiet", map[string]string{"alertname": "D"})},
+		},
+		{
+			name:   "top route receiver when no route matches",
+			labels: map[string]string{"namespace": "cattle-monitoring-system", "alertname": "E", "severity": "warning", "team": "ops"},
+			want:   []Routing{routing("default", map[string]string{"alertname": "E"})},
+		},
+		{
+			name:   "group key leaves out missing labels",
+			labels: map[string]string{"namespace": "cattle-monitoring-system", "severity": "page"},
+			want:   []Routing{routing("pager", map[string]string{})},
+		},
+		{
+			name:   "alerts of another namespace are not routed",
+			labels: map[string]string{"namespace": "default", "alertname": "F", "severity": "critical"},
+		},
+		{
+			name:   "alerts without a namespace are not routed",
+			labels: map[string]string{"alertname": "G", "severity": "critical"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.Simulate(tt.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulateKeepsTopMatchers(t *testing.T) {
+	config := &AlertmanagerConfig{
+		Name:      "scoped",
+		Namespace: "team-a",
+		Route: Route{
+			Receiver: "default",
+			Matchers: []Matcher{{Name: "team", Value: "a"}},
+		},
+	}
+	if got := config.Simulate(map[string]string{"namespace": "team-a", "team": "b"}); got != nil {
+		t.Errorf("got %v for another team, want no routing", got)
+	}
+	want := []Routing{{Receiver: "team-a/scoped/default", GroupKey: map[string]string{}}}
+	if got := config.Simulate(map[string]string{"namespace": "team-a", "team": "a"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(config.Route.Matchers) != 1 {
+		t.Errorf("Simulate changed the route matchers to %v", config.Route.Matchers)
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher Matcher
+		value   string
+		want    bool
+	}{
+		{name: "default equal", matcher: Matcher{Value: "critical"}, value: "critical", want: true},
+		{name: "default equal mismatch", matcher: Matcher{Value: "critical"}, value: "warning"},
+		{name: "equal", matcher: Matcher{Value: "critical", MatchType: "="}, value: "critical", want: true},
+		{name: "not equal", matcher: Matcher{Value: "critical", MatchType: "!="}, value: "warning", want: true},
+		{name: "not equal on a missing label", matcher: Matcher{Value: "critical", MatchType: "!="}, value: "", want: true},
+		{name: "regex", matcher: Matcher{Value: "crit.*", MatchType: "=~"}, value: "critical", want: true},
+		{name: "regex is anchored at the start", matcher: Matcher{Value: "rit.*", MatchType: "=~"}, value: "critical"},
+		{name: "regex is anchored at the end", matcher: Matcher{Value: "crit", MatchType: "=~"}, value: "critical"},
+		{name: "regex alternation is anchored", matcher: Matcher{Value: "a|b", MatchType: "=~"}, value: "ab"},
+		{name: "negative regex", matcher: Matcher{Value: "warn.*", MatchType: "!~"}, value: "critical", want: true},
+		{name: "negative regex match", matcher: Matcher{Value: "crit.*", MatchType: "!~"}, value: "critical"},
+		{name: "legacy regex", matcher: Matcher{Value: "crit.*", Regex: true}, value: "critical", want: true},
+		{name: "match type wins over legacy regex", matcher: Matcher{Value: "crit.*", MatchType: "=", Regex: true}, value: "critical"},
+		{name: "invalid regex", matcher: Matcher{Value: "(", MatchType: "=~"}, value: "("},
+		{name: "unknown match type", matcher: Matcher{Value: "critical", MatchType: "=="}, value: "critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matcher.Matches(tt.value); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package alertmanager
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Silence states.
+const (
+	SilenceActive  = "active"
+	SilenceExpired = "expired"
+)
+
+// SilenceMatcher is a matcher of a silence.
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// Silence is an Alertmanager silence.
+type Silence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []SilenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+	Status    *struct {
+		State string `json:"state"`
+	} `json:"status,omitempty"`
+}
+
+// State returns the state Alertmanager reports for the silence.
+func (s *Silence) State() string {
+	if s.Status == nil {
+		return ""
+	}
+	return s.Status.State
+}
+
+// CreateSilence silences the alerts whose labels equal the given ones, starting now for the given duration, and
+// returns the silence id.
+func (c *Client) CreateSilence(labels map[string]string, duration time.Duration, comment string) (string, error) {
+	silence := Silence{
+		StartsAt:  time.Now(),
+		EndsAt:    time.Now().Add(duration),
+		CreatedBy: "observability-e2e",
+		Comment:   comment,
+	}
+	for name, value := range labels {
+		silence.Matchers = append(silence.Matchers, SilenceMatcher{Name: name, Value: value, IsEqual: true})
+	}
+
+	response := struct {
+		SilenceID string `json:"silenceID"`
+	}{}
+	if err := c.do(http.MethodPost, "/api/v2/silences", nil, silence, &response); err != nil {
+		return "", err
+	}
+	return response.SilenceID, nil
+}
+
+// Silence returns the silence with the given id.
+func (c *Client) Silence(id string) (*Silence, error) {
+	silence := &Silence{}
+	if err := c.do(http.MethodGet, "/api/v2/silence/"+url.PathEscape(id), nil, nil, silence); err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+// ExpireSilence ends the silence now.
+func (c *Client) ExpireSilence(id string) error {
+	return c.do(http.MethodDelete, "/api/v2/silence/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Alert returns the alert with the given alertname.
+func (c *Client) Alert(alertName string) (*GettableAlert, error) {
+	alerts, err := c.Alerts(fmt.Sprintf("alertname=%q", alertName))
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, fmt.Errorf("alert %s is not known to alertmanager", alertName)
+	}
+	return &alerts[0], nil
+}
+
+// AlertState returns the state of the alert with the given alertname.
+func (c *Client) AlertState(alertName string) (string, error) {
+	alert, err := c.Alert(alertName)
+	if err != nil {
+		return "", err
+	}
+	return alert.Status.State, nil
+}
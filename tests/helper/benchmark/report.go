@@ -0,0 +1,98 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Durations holds the measured phases of a backup and restore cycle in milliseconds.
+type Durations struct {
+	BackupReadyMs    int64 `json:"backupReadyMs"`
+	ArtifactUploadMs int64 `json:"artifactUploadMs,omitempty"`
+	RestoreReadyMs   int64 `json:"restoreReadyMs"`
+	RancherReadyMs   int64 `json:"rancherReadyMs"`
+}
+
+// Result is the outcome of a single benchmark run.
+type Result struct {
+	ChartVersion string    `json:"chartVersion"`
+	StorageType  string    `json:"storageType"`
+	Encrypted    bool      `json:"encrypted"`
+	SeedProfile  string    `json:"seedProfile"`
+	ObjectCount  int       `json:"objectCount"`
+	StartedAt    time.Time `json:"startedAt"`
+	Durations    Durations `json:"durations"`
+	Samples      []Sample  `json:"samples,omitempty"`
+	PeakCPUCores float64   `json:"peakCpuCores"`
+	PeakMemoryMB float64   `json:"peakMemoryMB"`
+}
+
+// Key identifies the result inside a report so that runs of the same configuration overwrite each other.
+func (r *Result) Key() string {
+	encryption := "off"
+	if r.Encrypted {
+		encryption = "on"
+	}
+	return fmt.Sprintf("chart=%s,storage=%s,encryption=%s", r.ChartVersion, r.StorageType, encryption)
+}
+
+// SetSamples stores the operator resource samples along with their peaks.
+func (r *Result) SetSamples(samples []Sample) {
+	r.Samples = samples
+	for _, sample := range samples {
+		if sample.CPUCores > r.PeakCPUCores {
+			r.PeakCPUCores = sample.CPUCores
+		}
+		if memoryMB := sample.MemoryBytes / (1024 * 1024); memoryMB > r.PeakMemoryMB {
+			r.PeakMemoryMB = memoryMB
+		}
+	}
+}
+
+// Report maps result keys to their latest result.
+type Report map[string]*Result
+
+// Keys returns the keys of the report in a stable order.
+func (r Report) Keys() []string {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ReadReport loads a report from disk. A missing file yields an empty report.
+func ReadReport(path string) (Report, error) {
+	report := Report{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark report %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// WriteResult merges the result into the report stored at path, creating the file if needed.
+func WriteResult(path string, result *Result) error {
+	report, err := ReadReport(path)
+	if err != nil {
+		return err
+	}
+	report[result.Key()] = result
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal benchmark report: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
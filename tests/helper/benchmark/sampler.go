@@ -0,0 +1,107 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// Sample is a single reading of the operator pod resource usage.
+type Sample struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Phase       string    `json:"phase"`
+	CPUCores    float64   `json:"cpuCores"`
+	MemoryBytes float64   `json:"memoryBytes"`
+}
+
+// Sampler periodically records the CPU and memory of the pods in a namespace through Prometheus.
+type Sampler struct {
+	client    *promclient.Client
+	namespace string
+	interval  time.Duration
+
+	mu      sync.Mutex
+	phase   string
+	samples []Sample
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewSampler returns a sampler for the pods of the given namespace.
+func NewSampler(client *promclient.Client, namespace string, interval time.Duration) *Sampler {
+	return &Sampler{client: client, namespace: namespace, interval: interval}
+}
+
+// Start begins sampling in the background, tagging samples with the given phase.
+func (s *Sampler) Start(phase string) {
+	s.SetPhase(phase)
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.sample()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SetPhase tags the following samples with a new phase.
+func (s *Sampler) SetPhase(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+}
+
+// Stop ends sampling and returns every sample taken.
+func (s *Sampler) Stop() []Sample {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sample(nil), s.samples...)
+}
+
+func (s *Sampler) sample() {
+	cpuQuery := fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{namespace="%s",container!=""}[1m]))`, s.namespace)
+	memoryQuery := fmt.Sprintf(`sum(container_memory_working_set_bytes{namespace="%s",container!=""})`, s.namespace)
+
+	cpu, err := s.scalar(cpuQuery)
+	if err != nil {
+		e2e.Logf("Skipping CPU sample: %v", err)
+		return
+	}
+	memory, err := s.scalar(memoryQuery)
+	if err != nil {
+		e2e.Logf("Skipping memory sample: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, Sample{Timestamp: time.Now(), Phase: s.phase, CPUCores: cpu, MemoryBytes: memory})
+}
+
+func (s *Sampler) scalar(query string) (float64, error) {
+	result, err := s.client.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	if len(*result) == 0 {
+		return 0, fmt.Errorf("query returned no results: %s", query)
+	}
+	return float64((*result)[0].Value), nil
+}
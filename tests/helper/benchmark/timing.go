@@ -0,0 +1,171 @@
+package benchmark
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	bv1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/shepherd/clients/rancher"
+	v1 "github.com/rancher/shepherd/clients/rancher/v1"
+	"github.com/rancher/wrangler/pkg/genericcondition"
+	corev1 "k8s.io/api/core/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+const (
+	pollInterval   = time.Second
+	backupTimeout  = 30 * time.Minute
+	restoreTimeout = 60 * time.Minute
+)
+
+// BackupTiming is the measured duration of a backup.
+type BackupTiming struct {
+	Filename string
+	// Ready is the time from creating the Backup until it reports Ready.
+	Ready time.Duration
+	// Upload is the time from creating the Backup until the artifact was uploaded. It is zero when the storage
+	// location does not report an Uploaded condition.
+	Upload time.Duration
+}
+
+// MeasureBackup creates a backup and records how long it takes to upload its artifact and become Ready.
+func MeasureBackup(client *rancher.Client, backupOptions charts.BackupOptions) (*BackupTiming, error) {
+	client, err := client.ReLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	backup, err := charts.CreateRancherBackup(client, backupOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	timing := &BackupTiming{}
+	ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for backup %s to be ready", backupOptions.Name)
+		case <-ticker.C:
+			backupObj, err := client.Steve.SteveType(charts.BackupSteveType).ByID(backup.ID)
+			if err != nil {
+				continue
+			}
+			status := &bv1.BackupStatus{}
+			if err := utils.ConvertToStruct(backupObj.Status, status); err != nil {
+				return nil, err
+			}
+
+			if timing.Upload == 0 && conditionTrue(status.Conditions, bv1.BackupConditionUploaded) {
+				timing.Upload = time.Since(start)
+			}
+			if conditionTrue(status.Conditions, bv1.BackupConditionReady) {
+				timing.Ready = time.Since(start)
+				timing.Filename = status.Filename
+				e2e.Logf("Backup %s ready after %v (upload %v)", backupOptions.Name, timing.Ready, timing.Upload)
+				return timing, nil
+			}
+		}
+	}
+}
+
+// MeasureRestore creates a restore and records how long it takes to become Ready.
+func MeasureRestore(client *rancher.Client, restore *bv1.Restore) (time.Duration, error) {
+	client, err := client.ReLogin()
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	created, err := client.Steve.SteveType(charts.RestoreSteveType).Create(restore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create restore: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("timed out waiting for restore %s to be ready", created.ID)
+		case <-ticker.C:
+			// The restore briefly makes the API unavailable, so errors here are retried until the timeout.
+			restoreObj, err := client.Steve.SteveType(charts.RestoreSteveType).ByID(created.ID)
+			if err != nil {
+				continue
+			}
+			status := &bv1.RestoreStatus{}
+			if err := v1.ConvertToK8sType(restoreObj.Status, status); err != nil {
+				return 0, err
+			}
+			if conditionTrue(status.Conditions, bv1.RestoreConditionReady) {
+				elapsed := time.Since(start)
+				e2e.Logf("Restore %s ready after %v", created.ID, elapsed)
+				return elapsed, nil
+			}
+		}
+	}
+}
+
+// WaitForRancherReady measures how long it takes until the Rancher health endpoint and API answer again.
+func WaitForRancherReady(client *rancher.Client, timeout time.Duration) (time.Duration, error) {
+	httpClient := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	healthURL := fmt.Sprintf("https://%s/healthz", client.RancherConfig.Host)
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("rancher did not become ready within %v", timeout)
+		case <-ticker.C:
+			resp, err := httpClient.Get(healthURL)
+			if err != nil {
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				continue
+			}
+			relogged, err := client.ReLogin()
+			if err != nil {
+				continue
+			}
+			if _, err := relogged.Management.Setting.ByID("server-version"); err != nil {
+				continue
+			}
+			elapsed := time.Since(start)
+			e2e.Logf("Rancher ready after %v", elapsed)
+			return elapsed, nil
+		}
+	}
+}
+
+func conditionTrue(conditions []genericcondition.GenericCondition, conditionType string) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
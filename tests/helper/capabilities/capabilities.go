@@ -0,0 +1,230 @@
+package capabilities
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/observability-e2e/tests/helper/version"
+	"github.com/rancher/shepherd/clients/rancher"
+	"github.com/rancher/shepherd/clients/rancher/catalog"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// Capability is a feature that only some Rancher or chart versions provide.
+type Capability string
+
+const (
+	// ResourceSetDefault is the rancher-resource-set shipped before the basic/full split.
+	ResourceSetDefault Capability = "resource-set-default"
+	// ResourceSetBasic is rancher-resource-set-basic, which leaves secrets out of the backup.
+	ResourceSetBasic Capability = "resource-set-basic"
+	// ResourceSetFull is rancher-resource-set-full, which includes secrets.
+	ResourceSetFull Capability = "resource-set-full"
+	// BackupServiceMonitor is the monitoring.serviceMonitor value of the rancher-backup chart.
+	BackupServiceMonitor Capability = "backup-service-monitor"
+	// SCC is SUSE Customer Center registration, available on Rancher Prime.
+	SCC Capability = "scc"
+)
+
+// labelPrefix marks Ginkgo labels that declare a required capability.
+const labelPrefix = "requires-"
+
+// Requirement describes what an environment needs to provide a capability. Empty fields are not checked.
+type Requirement struct {
+	Description  string
+	RancherRange string
+	Chart        string
+	ChartRange   string
+	PrimeOnly    bool
+	// ResourceSet is the name of the ResourceSet object a resource set capability stands for.
+	ResourceSet string
+}
+
+// Registry maps every known capability to its requirement.
+var Registry = map[Capability]Requirement{
+	ResourceSetDefault: {
+		Description:  "rancher-resource-set",
+		RancherRange: "<2.11",
+		ResourceSet:  "rancher-resource-set",
+	},
+	ResourceSetBasic: {
+		Description:  "rancher-resource-set-basic",
+		RancherRange: ">=2.11",
+		ResourceSet:  "rancher-resource-set-basic",
+	},
+	ResourceSetFull: {
+		Description:  "rancher-resource-set-full",
+		RancherRange: ">=2.11",
+		ResourceSet:  "rancher-resource-set-full",
+	},
+	BackupServiceMonitor: {
+		Description: "rancher-backup monitoring.serviceMonitor",
+		Chart:       "rancher-backup",
+		ChartRange:  ">=105",
+	},
+	SCC: {
+		Description:  "SCC registration",
+		RancherRange: ">=2.12",
+		PrimeOnly:    true,
+	},
+}
+
+// chartVersionEnv lists the environment variables that pin the chart version under test.
+var chartVersionEnv = map[string]string{
+	"rancher-backup": "BACKUP_RESTORE_CHART_VERSION",
+}
+
+// Environment is the Rancher installation capabilities are evaluated against.
+type Environment struct {
+	RancherVersion version.Version
+	Prime          bool
+
+	client        *rancher.Client
+	mu            sync.Mutex
+	chartVersions map[string]version.Version
+}
+
+// Detect reads the Rancher version and edition from the server.
+func Detect(client *rancher.Client) (*Environment, error) {
+	config, err := utils.RequestRancherVersion(client.RancherConfig.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Rancher version: %w", err)
+	}
+	rancherVersion, err := version.Parse(config.RancherVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	e2e.Logf("Detected Rancher %s (prime: %t)", config.RancherVersion, config.IsPrime)
+	return &Environment{
+		RancherVersion: rancherVersion,
+		Prime:          config.IsPrime,
+		client:         client,
+		chartVersions:  map[string]version.Version{},
+	}, nil
+}
+
+// ChartVersion returns the version of the chart under test: the pinned version when one is set in the
+// environment, otherwise the latest version in the Rancher charts repository.
+func (e *Environment) ChartVersion(chart string) (version.Version, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if chartVersion, ok := e.chartVersions[chart]; ok {
+		return chartVersion, nil
+	}
+
+	raw := ""
+	if envVar, ok := chartVersionEnv[chart]; ok {
+		raw = os.Getenv(envVar)
+	}
+	if raw == "" {
+		if e.client == nil {
+			return version.Version{}, fmt.Errorf("no client to look up chart %s", chart)
+		}
+		latest, err := e.client.Catalog.GetLatestChartVersion(chart, catalog.RancherChartRepo)
+		if err != nil {
+			return version.Version{}, fmt.Errorf("failed to get latest version of chart %s: %w", chart, err)
+		}
+		raw = latest
+	}
+
+	chartVersion, err := version.Parse(raw)
+	if err != nil {
+		return version.Version{}, fmt.Errorf("invalid version for chart %s: %w", chart, err)
+	}
+	e.chartVersions[chart] = chartVersion
+	return chartVersion, nil
+}
+
+// Supports reports whether the environment provides the capability, and when it does not, why.
+func (e *Environment) Supports(capability Capability) (bool, string) {
+	requirement, ok := Registry[capability]
+	if !ok {
+		return false, fmt.Sprintf("unknown capability %q", capability)
+	}
+
+	if requirement.PrimeOnly && !e.Prime {
+		return false, fmt.Sprintf("%s requires Rancher Prime", requirement.Description)
+	}
+
+	if requirement.RancherRange != "" {
+		matched, err := inRange(requirement.RancherRange, e.RancherVersion)
+		if err != nil {
+			return false, err.Error()
+		}
+		if !matched {
+			return false, fmt.Sprintf("%s requires Rancher %s, found %s", requirement.Description, requirement.RancherRange, e.RancherVersion)
+		}
+	}
+
+	if requirement.Chart != "" {
+		chartVersion, err := e.ChartVersion(requirement.Chart)
+		if err != nil {
+			return false, err.Error()
+		}
+		matched, err := inRange(requirement.ChartRange, chartVersion)
+		if err != nil {
+			return false, err.Error()
+		}
+		if !matched {
+			return false, fmt.Sprintf("%s requires %s %s, found %s", requirement.Description, requirement.Chart, requirement.ChartRange, chartVersion)
+		}
+	}
+	return true, ""
+}
+
+// ResourceSets returns the names of the resource sets available in the environment.
+func (e *Environment) ResourceSets() []string {
+	var names []string
+	for capability, requirement := range Registry {
+		if requirement.ResourceSet == "" {
+			continue
+		}
+		if ok, _ := e.Supports(capability); ok {
+			names = append(names, requirement.ResourceSet)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Label returns the Ginkgo labels declaring that a spec requires the given capabilities.
+func Label(capabilities ...Capability) ginkgo.Labels {
+	labels := make(ginkgo.Labels, 0, len(capabilities))
+	for _, capability := range capabilities {
+		labels = append(labels, labelPrefix+string(capability))
+	}
+	return labels
+}
+
+// SkipUnsupported skips the current spec when the environment lacks a capability declared through Label.
+// Suites call it from a BeforeEach.
+func SkipUnsupported(env *Environment) {
+	for _, label := range ginkgo.CurrentSpecReport().Labels() {
+		if !strings.HasPrefix(label, labelPrefix) {
+			continue
+		}
+		if env == nil {
+			ginkgo.Fail("capabilities were not detected for this suite")
+		}
+		capability := Capability(strings.TrimPrefix(label, labelPrefix))
+		if ok, reason := env.Supports(capability); !ok {
+			ginkgo.Skip(fmt.Sprintf("Skipping: %s", reason))
+		}
+	}
+}
+
+// inRange reports whether v satisfies the range expression. Pre-release and head builds count as their release.
+func inRange(expr string, v version.Version) (bool, error) {
+	versionRange, err := version.ParseRange(expr)
+	if err != nil {
+		return false, err
+	}
+	return versionRange.Contains(v), nil
+}
@@ -45,6 +45,9 @@ type RancherMonitoringOpts struct {
 	Etcd              bool `json:"etcd" yaml:"etcd"`
 	Proxy             bool `json:"proxy" yaml:"proxy"`
 	Scheduler         bool `json:"scheduler" yaml:"scheduler"`
+	// PrometheusStorageClass keeps the Prometheus TSDB on a volume claim of this storage class, so samples survive
+	// a restart or an upgrade of Prometheus. Empty keeps the chart default, which has no volume.
+	PrometheusStorageClass string `json:"-" yaml:"-"`
 }
 
 // RancherBackupOpts is a struct of the required options to install Rancher Backups with desired chart values.
@@ -74,6 +77,10 @@ type RancherLoggingOpts struct {
 type RancherAlertingOpts struct {
 	SMS   bool
 	Teams bool
+	// SMSValues and TeamsValues are merged into the sachet and prom2teams values, for example to configure
+	// providers and connectors.
+	SMSValues   map[string]interface{}
+	TeamsValues map[string]interface{}
 }
 
 // GetChartCaseEndpointResult is a struct that GetChartCaseEndpoint helper function returns.
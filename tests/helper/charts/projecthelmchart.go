@@ -0,0 +1,296 @@
+package charts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/shepherd/clients/rancher"
+	"gopkg.in/yaml.v3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+const (
+	// ProjectHelmChartDeployed is the status.status of a ProjectHelmChart whose release is installed.
+	ProjectHelmChartDeployed = "Deployed"
+	// ProjectMonitoringHelmAPIVersion is the helmApiVersion handled by Prometheus Federator.
+	ProjectMonitoringHelmAPIVersion = "monitoring.cattle.io/v1alpha1"
+
+	projectHelmChartTimeout = 5 * time.Minute
+)
+
+var (
+	ProjectHelmChartGVR = schema.GroupVersionResource{Group: "helm.cattle.io", Version: "v1alpha1", Resource: "projecthelmcharts"}
+	deploymentGVR       = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	statefulSetGVR      = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+)
+
+// ProjectHelmChart is a Prometheus Federator project monitoring stack. It lives in the registration namespace of
+// its project and deploys the stack into a release namespace the operator creates.
+type ProjectHelmChart struct {
+	Namespace      string                 `yaml:"-"`
+	Name           string                 `yaml:"-"`
+	HelmAPIVersion string                 `yaml:"helmApiVersion"`
+	Values         map[string]interface{} `yaml:"values"`
+}
+
+// ProjectHelmChartStatus is the status the operator reports for a ProjectHelmChart.
+type ProjectHelmChartStatus struct {
+	Status           string
+	StatusMessage    string
+	ReleaseName      string
+	ReleaseNamespace string
+	// DashboardValues holds the URLs of the project Prometheus, Alertmanager and Grafana.
+	DashboardValues map[string]interface{}
+}
+
+// ProjectRegistrationNamespace returns the namespace ProjectHelmCharts of the project must be created in.
+func ProjectRegistrationNamespace(projectID string) string {
+	return "cattle-project-" + projectID[strings.LastIndex(projectID, ":")+1:]
+}
+
+// LoadProjectHelmChart reads the metadata.name and spec of a ProjectHelmChart manifest. The namespace is set by
+// the caller.
+func LoadProjectHelmChart(path string) (*ProjectHelmChart, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest := struct {
+		Metadata struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+		Spec ProjectHelmChart `yaml:"spec"`
+	}{}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	manifest.Spec.Name = manifest.Metadata.Name
+	return &manifest.Spec, nil
+}
+
+// CreateProjectHelmChart creates the ProjectHelmChart and waits for it to be deployed.
+func CreateProjectHelmChart(client *rancher.Client, clusterID string, chart *ProjectHelmChart) (*ProjectHelmChartStatus, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	helmAPIVersion := chart.HelmAPIVersion
+	if helmAPIVersion == "" {
+		helmAPIVersion = ProjectMonitoringHelmAPIVersion
+	}
+	values, err := toJSONValues(chart.Values)
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": ProjectHelmChartGVR.GroupVersion().String(),
+		"kind":       "ProjectHelmChart",
+		"metadata": map[string]interface{}{
+			"name":      chart.Name,
+			"namespace": chart.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"helmApiVersion": helmAPIVersion,
+			"values":         values,
+		},
+	}}
+	if _, err := dynamicClient.Resource(ProjectHelmChartGVR).Namespace(chart.Namespace).Create(context.TODO(), object, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create ProjectHelmChart %s/%s: %w", chart.Namespace, chart.Name, err)
+	}
+	return WaitProjectHelmChartDeployed(client, clusterID, chart.Namespace, chart.Name)
+}
+
+// UpdateProjectHelmChartValues replaces the values of the ProjectHelmChart and waits for it to be deployed again.
+func UpdateProjectHelmChartValues(client *rancher.Client, clusterID, namespace, name string, values map[string]interface{}) (*ProjectHelmChartStatus, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	resource := dynamicClient.Resource(ProjectHelmChartGVR).Namespace(namespace)
+
+	current, err := resource.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	jsonValues, err := toJSONValues(values)
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(current.Object, jsonValues, "spec", "values"); err != nil {
+		return nil, err
+	}
+	if _, err := resource.Update(context.TODO(), current, metav1.UpdateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to update ProjectHelmChart %s/%s: %w", namespace, name, err)
+	}
+	return WaitProjectHelmChartDeployed(client, clusterID, namespace, name)
+}
+
+// GetProjectHelmChartStatus returns the current status of the ProjectHelmChart.
+func GetProjectHelmChartStatus(client *rancher.Client, clusterID, namespace, name string) (*ProjectHelmChartStatus, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	object, err := dynamicClient.Resource(ProjectHelmChartGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ProjectHelmChartStatus{}
+	status.Status, _, _ = unstructured.NestedString(object.Object, "status", "status")
+	status.StatusMessage, _, _ = unstructured.NestedString(object.Object, "status", "statusMessage")
+	status.ReleaseName, _, _ = unstructured.NestedString(object.Object, "status", "releaseName")
+	status.ReleaseNamespace, _, _ = unstructured.NestedString(object.Object, "status", "releaseNamespace")
+	status.DashboardValues, _, _ = unstructured.NestedMap(object.Object, "status", "dashboardValues")
+	return status, nil
+}
+
+// ProjectPrometheusURL returns the Rancher service proxy URL of the project Prometheus of a deployed
+// ProjectHelmChart.
+func ProjectPrometheusURL(host, clusterID string, status *ProjectHelmChartStatus) string {
+	return promclient.ServiceProxyURL(host, clusterID, status.ReleaseNamespace, status.ReleaseName+"-prometheus", 9090)
+}
+
+// WaitProjectHelmChartDeployed waits until the ProjectHelmChart reports Deployed together with its release
+// namespace and dashboard values.
+func WaitProjectHelmChartDeployed(client *rancher.Client, clusterID, namespace, name string) (*ProjectHelmChartStatus, error) {
+	var status *ProjectHelmChartStatus
+	err := wait.PollUntilContextTimeout(context.TODO(), 10*time.Second, projectHelmChartTimeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		status, err = GetProjectHelmChartStatus(client, clusterID, namespace, name)
+		if err != nil {
+			return false, err
+		}
+		if status.Status != ProjectHelmChartDeployed || status.ReleaseNamespace == "" || len(status.DashboardValues) == 0 {
+			e2e.Logf("ProjectHelmChart %s/%s is %q, waiting for it to be deployed", namespace, name, status.Status)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		if status != nil {
+			return status, fmt.Errorf("ProjectHelmChart %s/%s is %q: %s: %w", namespace, name, status.Status, status.StatusMessage, err)
+		}
+		return nil, err
+	}
+	return status, nil
+}
+
+// VerifyProjectMonitoringWorkloads waits for the project Prometheus and, unless disabled in the values, the
+// project Alertmanager and Grafana to be ready in the release namespace.
+func VerifyProjectMonitoringWorkloads(client *rancher.Client, clusterID string, chart *ProjectHelmChart, status *ProjectHelmChartStatus) error {
+	expected := map[string]bool{
+		"prometheus":   true,
+		"alertmanager": componentEnabled(chart.Values, "alertmanager"),
+		"grafana":      componentEnabled(chart.Values, "grafana"),
+	}
+
+	return wait.PollUntilContextTimeout(context.TODO(), 10*time.Second, projectHelmChartTimeout, true, func(ctx context.Context) (bool, error) {
+		ready, err := readyProjectWorkloads(client, clusterID, status.ReleaseNamespace)
+		if err != nil {
+			return false, err
+		}
+		for component, enabled := range expected {
+			if ready[component] != enabled {
+				e2e.Logf("Project %s in %s: ready %t, expected %t", component, status.ReleaseNamespace, ready[component], enabled)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// DeleteProjectHelmChart deletes the ProjectHelmChart and waits until the operator has removed it and every
+// deployment and statefulset of its release namespace.
+func DeleteProjectHelmChart(client *rancher.Client, clusterID, namespace, name string) error {
+	status, err := GetProjectHelmChartStatus(client, clusterID, namespace, name)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+	resource := dynamicClient.Resource(ProjectHelmChartGVR).Namespace(namespace)
+	if err := resource.Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("failed to delete ProjectHelmChart %s/%s: %w", namespace, name, err)
+	}
+
+	return wait.PollUntilContextTimeout(context.TODO(), 10*time.Second, projectHelmChartTimeout, true, func(ctx context.Context) (bool, error) {
+		if _, err := resource.Get(ctx, name, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		if status.ReleaseNamespace == "" {
+			return true, nil
+		}
+		for _, gvr := range []schema.GroupVersionResource{deploymentGVR, statefulSetGVR} {
+			list, err := dynamicClient.Resource(gvr).Namespace(status.ReleaseNamespace).List(ctx, metav1.ListOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return false, err
+			}
+			if err == nil && len(list.Items) > 0 {
+				e2e.Logf("%d %s left in %s, waiting for the cleanup", len(list.Items), gvr.Resource, status.ReleaseNamespace)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// readyProjectWorkloads reports which project monitoring components have all their replicas ready. Grafana runs
+// as a deployment, Prometheus and Alertmanager as statefulsets named after them by the operator.
+func readyProjectWorkloads(client *rancher.Client, clusterID, namespace string) (map[string]bool, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	ready := map[string]bool{}
+	for _, gvr := range []schema.GroupVersionResource{deploymentGVR, statefulSetGVR} {
+		list, err := dynamicClient.Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			replicas, _, _ := unstructured.NestedInt64(item.Object, "spec", "replicas")
+			readyReplicas, _, _ := unstructured.NestedInt64(item.Object, "status", "readyReplicas")
+			for _, component := range []string{"prometheus", "alertmanager", "grafana"} {
+				if strings.Contains(item.GetName(), component) && replicas > 0 && readyReplicas == replicas {
+					ready[component] = true
+				}
+			}
+		}
+	}
+	return ready, nil
+}
+
+// toJSONValues converts values decoded from YAML, which may hold ints, to the JSON types unstructured objects
+// accept.
+func toJSONValues(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	converted := map[string]interface{}{}
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// componentEnabled reads <component>.enabled from chart values, which defaults to true.
+func componentEnabled(values map[string]interface{}, component string) bool {
+	enabled, found, err := unstructured.NestedBool(values, component, "enabled")
+	return !found || err != nil || enabled
+}
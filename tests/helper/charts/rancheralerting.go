@@ -3,6 +3,7 @@ package charts
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	catalogv1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/shepherd/clients/rancher"
@@ -35,14 +36,7 @@ func InstallRancherAlertingChart(client *rancher.Client, installOptions *Install
 	}
 
 	// Prepare the alerting values.
-	alertingValues := map[string]interface{}{
-		"prom2teams": map[string]interface{}{
-			"enabled": rancherAlertingOpts.Teams,
-		},
-		"sachet": map[string]interface{}{
-			"enabled": rancherAlertingOpts.SMS,
-		},
-	}
+	alertingValues := rancherAlertingValues(rancherAlertingOpts)
 
 	// Create chart install configuration for the main chart.
 	chartInstall := newChartInstall(
@@ -119,3 +113,124 @@ func InstallRancherAlertingChart(client *rancher.Client, installOptions *Install
 	// The app has been successfully deployed.
 	return nil
 }
+
+// UpgradeRancherAlertingChart upgrades the rancher-alerting-drivers chart and waits until the upgraded app is deployed.
+func UpgradeRancherAlertingChart(client *rancher.Client, installOptions *InstallOptions, rancherAlertingOpts *RancherAlertingOpts) error {
+	// Retrieve server settings with scheme validation
+	serverSetting, err := client.Management.Setting.ByID(serverURLSettingID)
+	if err != nil {
+		return err
+	}
+	serverURL := serverSetting.Value
+	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
+		serverURL = "https://" + serverURL
+	}
+
+	// Retrieve registry settings
+	registrySetting, err := client.Management.Setting.ByID(defaultRegistrySettingID)
+	if err != nil {
+		return err
+	}
+
+	alertingValues := rancherAlertingValues(rancherAlertingOpts)
+
+	chartUpgrade := newChartUpgrade(
+		RancherAlertingName,
+		RancherAlertingName,
+		installOptions.Version,
+		installOptions.Cluster.ID,
+		installOptions.Cluster.Name,
+		serverURL,
+		registrySetting.Value,
+		alertingValues,
+	)
+	chartUpgradeAction := newChartUpgradeAction(RancherAlertingNamespace, []types.ChartUpgrade{*chartUpgrade})
+
+	catalogClient, err := client.GetClusterCatalogClient(installOptions.Cluster.ID)
+	if err != nil {
+		return err
+	}
+	if err := catalogClient.UpgradeChart(chartUpgradeAction, catalog.RancherChartRepo); err != nil {
+		return err
+	}
+
+	// Wait for the target version to be deployed. The app may go through pending-upgrade too fast to be seen, and
+	// it reports the previous version as deployed until the upgrade starts.
+	timeoutSeconds := int64(5 * 60) // 5 minute timeout
+	watchInterface, err := catalogClient.Apps(RancherAlertingNamespace).Watch(context.TODO(), metav1.ListOptions{
+		FieldSelector:  "metadata.name=" + RancherAlertingName,
+		TimeoutSeconds: &timeoutSeconds,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = wait.WatchWait(watchInterface, func(event watch.Event) (bool, error) {
+		app, ok := event.Object.(*catalogv1.App)
+		if !ok {
+			return false, fmt.Errorf("unexpected type %T", event.Object)
+		}
+		if app.Spec.Chart == nil || app.Spec.Chart.Metadata == nil || app.Spec.Chart.Metadata.Version != installOptions.Version {
+			return false, nil
+		}
+
+		switch app.Status.Summary.State {
+		case string(catalogv1.StatusDeployed):
+			return true, nil
+		case string(catalogv1.StatusFailed):
+			return false, fmt.Errorf("rancher-alerting-drivers upgrade to %s failed", installOptions.Version)
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		if err.Error() == wait.TimeoutError {
+			return fmt.Errorf("timeout: rancher-alerting-drivers %s was not deployed within 5 minutes", installOptions.Version)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// rancherAlertingValues returns the chart values enabling the drivers selected in the options, with their extra
+// values merged in.
+func rancherAlertingValues(rancherAlertingOpts *RancherAlertingOpts) map[string]interface{} {
+	prom2teams := map[string]interface{}{}
+	for key, value := range rancherAlertingOpts.TeamsValues {
+		prom2teams[key] = value
+	}
+	prom2teams["enabled"] = rancherAlertingOpts.Teams
+
+	sachet := map[string]interface{}{}
+	for key, value := range rancherAlertingOpts.SMSValues {
+		sachet[key] = value
+	}
+	sachet["enabled"] = rancherAlertingOpts.SMS
+
+	return map[string]interface{}{
+		"prom2teams": prom2teams,
+		"sachet":     sachet,
+	}
+}
+
+// RancherAlertingOptsFromValues returns the options a rancher-alerting-drivers app was installed with, read from its
+// sachet and prom2teams values, so that an upgrade with them restores the app as it was.
+func RancherAlertingOptsFromValues(values map[string]interface{}) *RancherAlertingOpts {
+	driver := func(key string) (bool, map[string]interface{}) {
+		driverValues, _ := values[key].(map[string]interface{})
+		extra := map[string]interface{}{}
+		for k, v := range driverValues {
+			if k != "enabled" {
+				extra[k] = v
+			}
+		}
+		enabled, _ := driverValues["enabled"].(bool)
+		return enabled, extra
+	}
+
+	opts := &RancherAlertingOpts{}
+	opts.SMS, opts.SMSValues = driver("sachet")
+	opts.Teams, opts.TeamsValues = driver("prom2teams")
+	return opts
+}
@@ -7,19 +7,18 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	bv1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/observability-e2e/tests/helper/capabilities"
 	localConfig "github.com/rancher/observability-e2e/tests/helper/config"
-	localkubectl "github.com/rancher/observability-e2e/tests/helper/kubectl"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/reporting"
 	"github.com/rancher/observability-e2e/tests/helper/utils"
 	catalogv1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
-	"github.com/rancher/rancher/tests/v2/actions/projects"
 	"github.com/rancher/rancher/tests/v2/actions/secrets"
 	"github.com/rancher/shepherd/clients/rancher"
 	"github.com/rancher/shepherd/clients/rancher/catalog"
@@ -33,6 +32,7 @@ import (
 	"github.com/rancher/shepherd/pkg/wait"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
@@ -44,22 +44,15 @@ const (
 	RancherBackupRestoreCRDName   = "rancher-backup-crd"
 	BackupSteveType               = "resources.cattle.io.backup"
 	RestoreSteveType              = "resources.cattle.io.restore"
-	resourceCount                 = 2
 	cniCalico                     = "calico"
 )
 
 var (
-	rules = []management.PolicyRule{
-		{
-			APIGroups: []string{"management.cattle.io"},
-			Resources: []string{"projects"},
-			Verbs:     []string{"backupRole"},
-		},
-	}
 	BackupRestoreConfigurationFileKey = utils.GetYamlPath("tests/helper/yamls/inputBackupRestoreConfig.yaml")
 	localStorageClass                 = utils.GetYamlPath("tests/helper/yamls/localStorageClass.yaml")
 	EncryptionConfigFilePath          = utils.GetYamlPath("tests/helper/yamls/encryption-provider-config.yaml")
 	EncryptionConfigAsteriskFilePath  = utils.GetYamlPath("tests/helper/yamls/encrptionConfigwithAsterisk.yaml")
+	fleetGitRepoGVR                   = schema.GroupVersionResource{Group: "fleet.cattle.io", Version: "v1alpha1", Resource: "gitrepos"}
 )
 
 type BackupOptions struct {
@@ -402,14 +395,20 @@ func VerifyBackupCompleted(client *rancher.Client, steveType string, backup *v1.
 	}
 }
 
-func CreateRancherBackupAndVerifyCompleted(client *rancher.Client, backupOptions BackupOptions) (*v1.SteveAPIObject, string, error) {
+// CreateRancherBackup creates a Backup object without waiting for it to complete. The client must have been
+// logged in after the chart was installed so that its schema knows about backups.
+func CreateRancherBackup(client *rancher.Client, backupOptions BackupOptions) (*v1.SteveAPIObject, error) {
 	backup := setBackupObject(backupOptions)
 	backupTemplate := bv1.NewBackup("", backupOptions.Name, *backup)
+	return client.Steve.SteveType(BackupSteveType).Create(backupTemplate)
+}
+
+func CreateRancherBackupAndVerifyCompleted(client *rancher.Client, backupOptions BackupOptions) (*v1.SteveAPIObject, string, error) {
 	client, err := client.ReLogin() // This needs to be done as the chart installed changed the schema
 	if err != nil {
 		return nil, "", err
 	}
-	completedBackup, err := client.Steve.SteveType(BackupSteveType).Create(backupTemplate)
+	completedBackup, err := CreateRancherBackup(client, backupOptions)
 	if err != nil {
 		return nil, "", err
 	}
@@ -420,39 +419,6 @@ func CreateRancherBackupAndVerifyCompleted(client *rancher.Client, backupOptions
 	return completedBackup, backupFileName, err
 }
 
-func CreateRancherResources(client *rancher.Client, clusterID string, context string) ([]*management.User, []*management.Project, []*management.RoleTemplate, error) {
-	userList := []*management.User{}
-	projList := []*management.Project{}
-	roleList := []*management.RoleTemplate{}
-
-	for i := 0; i < resourceCount; i++ {
-		u, err := users.CreateUserWithRole(client, users.UserConfig(), "user")
-		if err != nil {
-			return userList, projList, roleList, err
-		}
-		userList = append(userList, u)
-
-		p, _, err := projects.CreateProjectAndNamespace(client, clusterID)
-		if err != nil {
-			return userList, projList, roleList, err
-		}
-		projList = append(projList, p)
-
-		rt, err := client.Management.RoleTemplate.Create(
-			&management.RoleTemplate{
-				Context: context,
-				Name:    namegen.AppendRandomString("bro-role"),
-				Rules:   rules,
-			})
-		if err != nil {
-			return userList, projList, roleList, err
-		}
-		roleList = append(roleList, rt)
-	}
-
-	return userList, projList, roleList, nil
-}
-
 func SetRestoreObject(backupName string, prune bool, encryptionConfigSecretName string) bv1.Restore {
 	restore := bv1.Restore{
 		ObjectMeta: metav1.ObjectMeta{
@@ -574,36 +540,16 @@ func ValidateBackupFile(basePath string) error {
 	return nil
 }
 
-// IsVersionAtLeast compares a semantic version string (e.g. "2.10.3")
-// against a target major and minor version. Returns true if the version
-// is equal to or greater than the target.
-func IsVersionAtLeast(version string, targetMajor, targetMinor int) (bool, error) {
-	var major, minor int
-	_, err := fmt.Sscanf(version, "%d.%d", &major, &minor)
-	if err != nil {
-		return false, fmt.Errorf("invalid version format: %w", err)
-	}
-
-	if major > targetMajor || (major == targetMajor && minor >= targetMinor) {
-		return true, nil
-	}
-	return false, nil
-}
-
-// SelectResourceSetName determines the resource set name based on the Rancher version.
-func SelectResourceSetName(clientWithSession *rancher.Client, params *BackupOptions) error {
-	rancherVersion, err := utils.GetRancherVersion(clientWithSession)
-	if err != nil {
-		return fmt.Errorf("unable to parse the version: %v", err)
+// SelectResourceSetName picks the full resource set when the Rancher version provides it, and the default one otherwise.
+// The environment is the one the suite detected once, see capabilities.Detect.
+func SelectResourceSetName(env *capabilities.Environment, params *BackupOptions) error {
+	if env == nil {
+		return fmt.Errorf("capabilities were not detected for this suite")
 	}
-	ok, err := IsVersionAtLeast(rancherVersion, 2, 11)
-	if err != nil {
-		return err
-	}
-	if ok {
-		params.ResourceSetName = "rancher-resource-set-full"
+	if ok, _ := env.Supports(capabilities.ResourceSetFull); ok {
+		params.ResourceSetName = capabilities.Registry[capabilities.ResourceSetFull].ResourceSet
 	} else {
-		params.ResourceSetName = "rancher-resource-set"
+		params.ResourceSetName = capabilities.Registry[capabilities.ResourceSetDefault].ResourceSet
 	}
 	return nil
 }
@@ -754,63 +700,41 @@ func InstallLatestBackupRestoreChart(
 }
 
 // VerifyFleetState checks that the GitRepo is Ready and the downstream app is running.
-func VerifyFleetState(gitRepoName string, fleetNamespace string, appDeploymentName string, appNamespace string) {
+func VerifyFleetState(client *rancher.Client, gitRepoName string, fleetNamespace string, appDeploymentName string, appNamespace string) {
 	const (
 		Timeout = 15 * time.Minute
 		Poll    = 5 * time.Second
 	)
 
+	kubeClient, err := kube.NewClient(client, kube.LocalCluster)
+	Expect(err).NotTo(HaveOccurred())
+
 	By(fmt.Sprintf("Verifying GitRepo %s in namespace %s is Ready", gitRepoName, fleetNamespace))
 	Eventually(func() string {
-		out, err := localkubectl.Execute("get", "gitrepo", gitRepoName,
-			"-n", fleetNamespace,
-			"-o", "jsonpath={.status.conditions[?(@.type=='Ready')].status}")
-
+		gitRepo, err := kubeClient.Get(context.TODO(), fleetGitRepoGVR, fleetNamespace, gitRepoName)
 		if err != nil {
 			return ""
 		}
+		out, _ := kube.JSONPath(gitRepo, "{.status.conditions[?(@.type=='Ready')].status}")
 		return out
 	}, Timeout, Poll).Should(Equal("True"), "GitRepo Condition 'Ready' should be 'True'")
 
 	By(fmt.Sprintf("Verifying fleet workload %s in namespace %s is Available", appDeploymentName, appNamespace))
-	Eventually(func() string {
+	Eventually(func() int64 {
 		// Check if the deployment exists and has available replicas
-		out, err := localkubectl.Execute("get", "deployment", appDeploymentName,
-			"-n", appNamespace,
-			"-o", "jsonpath={.status.availableReplicas}")
-
-		// If error or field missing, return "0"
-		if err != nil || out == "" {
-			return "0"
-		}
-		return out
-	}, Timeout, Poll).ShouldNot(Equal("0"), "Workload should have available replicas > 0")
-}
-
-// ✅ Extract multiple QASE IDs from the test name.
-// Supports: [QASE-123], [QASE-123,456], or multiple tags like [QASE-123] [QASE-789].
-func ExtractQaseIDs(name string) []int {
-	re := regexp.MustCompile(`\[QASE-([\d,]+)\]`)
-	matches := re.FindAllStringSubmatch(name, -1)
-
-	var ids []int
-	for _, match := range matches {
-		if len(match) > 1 {
-			for _, idStr := range strings.Split(match[1], ",") {
-				id, err := strconv.Atoi(strings.TrimSpace(idStr))
-				if err == nil {
-					ids = append(ids, id)
-				}
-			}
+		deployment, err := kubeClient.Get(context.TODO(), kube.DeploymentsGVR, appNamespace, appDeploymentName)
+		if err != nil {
+			return 0
 		}
-	}
-	return ids
+		available, _, _ := unstructured.NestedInt64(deployment.Object, "status", "availableReplicas")
+		return available
+	}, Timeout, Poll).ShouldNot(BeZero(), "Workload should have available replicas > 0")
 }
 
 // ✅ Helper wrapper for Entry()
 // Extracts one or more QASE IDs and adds all as labels automatically.
 func QaseEntry(text string, labels []interface{}, params interface{}) TableEntry {
-	qaseIDs := ExtractQaseIDs(text)
+	qaseIDs := reporting.ExtractIDs(text)
 	for _, id := range qaseIDs {
 		labels = append(labels, Label(fmt.Sprintf("QASE-%d", id)))
 	}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/rancher/observability-e2e/tests/helper/probe"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
 	catalogv1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/shepherd/clients/rancher"
 	"github.com/rancher/shepherd/clients/rancher/catalog"
@@ -14,6 +16,7 @@ import (
 	"github.com/rancher/shepherd/pkg/api/steve/catalog/types"
 	"github.com/rancher/shepherd/pkg/wait"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/watch"
 )
 
@@ -21,8 +24,57 @@ const (
 	RancherMonitoringNamespace = "cattle-monitoring-system"
 	RancherMonitoringName      = "rancher-monitoring"
 	RancherMonitoringCRDName   = "rancher-monitoring-crd"
+
+	// LocalStorageClassName is the storage class created by DeployLocalStorage.
+	LocalStorageClassName = "local-storage"
+	// prometheusStorageSize and prometheusRetentionSize fit the PersistentVolume of localStorageClass.
+	prometheusStorageSize   = "2Gi"
+	prometheusRetentionSize = "1GiB"
+	// prometheusDataVolume is the volume the Prometheus operator mounts the storage volume claim as.
+	prometheusDataVolume = "prometheus-rancher-monitoring-prometheus-db"
+	// prometheusInitImage is pulled through the system default registry, so it must be mirrored as library/busybox.
+	prometheusInitImage = "library/busybox:1.36"
 )
 
+// DeployLocalStorage creates the local-storage StorageClass and its hostPath PersistentVolume. Objects that
+// already exist are left in place, so it can run before every install.
+func DeployLocalStorage(client *rancher.Client) error {
+	return utils.DeployYamlResource(client, localStorageClass, RancherMonitoringNamespace)
+}
+
+// setPrometheusStorage adds the values that keep the Prometheus TSDB on a volume claim of the storage class of the
+// options. The kubelet creates a hostPath volume owned by root, so an init container hands it to the Prometheus
+// user of the chart first.
+func setPrometheusStorage(monitoringValues map[string]interface{}, rancherMonitoringOpts *RancherMonitoringOpts, registry string) {
+	if rancherMonitoringOpts.PrometheusStorageClass == "" {
+		return
+	}
+	prometheusSpec := monitoringValues["prometheus"].(map[string]interface{})["prometheusSpec"].(map[string]interface{})
+	prometheusSpec["retentionSize"] = prometheusRetentionSize
+	prometheusSpec["storageSpec"] = map[string]interface{}{
+		"volumeClaimTemplate": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"storageClassName": rancherMonitoringOpts.PrometheusStorageClass,
+				"accessModes":      []interface{}{"ReadWriteOnce"},
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"storage": prometheusStorageSize},
+				},
+			},
+		},
+	}
+	prometheusSpec["initContainers"] = []interface{}{
+		map[string]interface{}{
+			"name":            "init-chown-data",
+			"image":           probe.WithRegistry(prometheusInitImage, registry),
+			"command":         []interface{}{"chown", "-R", "1000:2000", "/prometheus"},
+			"securityContext": map[string]interface{}{"runAsUser": 0, "runAsNonRoot": false},
+			"volumeMounts": []interface{}{
+				map[string]interface{}{"name": prometheusDataVolume, "mountPath": "/prometheus"},
+			},
+		},
+	}
+}
+
 // InstallRancherMonitoringChart installs the rancher-monitoring chart with a timeout.
 func InstallRancherMonitoringChart(client *rancher.Client, installOptions *InstallOptions, rancherMonitoringOpts *RancherMonitoringOpts) error {
 	// Retrieve the server URL setting.
@@ -83,6 +135,12 @@ func InstallRancherMonitoringChart(client *rancher.Client, installOptions *Insta
 		monitoringValues[newKey] = map[string]interface{}{"enabled": value}
 	}
 
+	// K3s runs its control plane in the server process, scraped by the single k3sServer exporter of the chart.
+	if installOptions.Cluster.Provider == clusters.KubernetesProviderK3S {
+		monitoringValues["k3sServer"] = map[string]interface{}{"enabled": rancherMonitoringOpts.ControllerManager || rancherMonitoringOpts.Proxy || rancherMonitoringOpts.Scheduler}
+	}
+	setPrometheusStorage(monitoringValues, rancherMonitoringOpts, registrySetting.Value)
+
 	// Create chart install configurations for the CRD and the main chart.
 	chartInstallCRD := newChartInstall(
 		RancherMonitoringCRDName,
@@ -221,6 +279,13 @@ func UpgradeRancherMonitoringChart(client *rancher.Client, installOptions *Insta
 		monitoringValues[prefixedKey] = map[string]interface{}{"enabled": value}
 	}
 
+	// K3s runs its control plane in the server process, scraped by the single k3sServer exporter of the chart.
+	if installOptions.Cluster.Provider == clusters.KubernetesProviderK3S {
+		monitoringValues["k3sServer"] = map[string]interface{}{"enabled": rancherMonitoringOpts.ControllerManager || rancherMonitoringOpts.Proxy || rancherMonitoringOpts.Scheduler}
+	}
+	// The volume claim template of a StatefulSet can not change, so an upgrade keeps the storage of the install.
+	setPrometheusStorage(monitoringValues, rancherMonitoringOpts, registrySetting.Value)
+
 	// Create chart upgrade actions
 	chartUpgrade := newChartUpgrade(
 		RancherMonitoringName,
@@ -310,3 +375,27 @@ func UpgradeRancherMonitoringChart(client *rancher.Client, installOptions *Insta
 
 	return nil
 }
+
+// RancherMonitoringOptsFromValues returns the exporter options a rancher-monitoring app was installed with, read
+// from the provider-prefixed keys of its values the same way InstallRancherMonitoringChart writes them. A component
+// without a value is disabled, as in the chart defaults. The storage class is read from the Prometheus storage spec.
+func RancherMonitoringOptsFromValues(provider clusters.KubernetesProvider, values map[string]interface{}) *RancherMonitoringOpts {
+	enabled := func(option string) bool {
+		key := fmt.Sprintf("%v%v%v", provider, strings.ToUpper(option[:1]), option[1:])
+		if option == "ingressNginx" && provider == clusters.KubernetesProviderRKE {
+			key = option
+		}
+		component, _ := values[key].(map[string]interface{})
+		value, _ := component["enabled"].(bool)
+		return value
+	}
+	storageClass, _, _ := unstructured.NestedString(values, "prometheus", "prometheusSpec", "storageSpec", "volumeClaimTemplate", "spec", "storageClassName")
+	return &RancherMonitoringOpts{
+		IngressNginx:           enabled("ingressNginx"),
+		ControllerManager:      enabled("controllerManager"),
+		Etcd:                   enabled("etcd"),
+		Proxy:                  enabled("proxy"),
+		Scheduler:              enabled("scheduler"),
+		PrometheusStorageClass: storageClass,
+	}
+}
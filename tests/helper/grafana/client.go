@@ -0,0 +1,158 @@
+package grafana
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+)
+
+// Client talks to the Grafana HTTP API. Requests carry the Rancher token so they pass the Rancher service proxy;
+// Grafana itself serves them as the anonymous user rancher-monitoring configures.
+type Client struct {
+	baseURL     string
+	bearerToken string
+	httpClient  *http.Client
+}
+
+// NewClient returns a client for the Grafana at grafanaURL.
+func NewClient(grafanaURL, bearerToken string) *Client {
+	return &Client{
+		baseURL:     strings.TrimSuffix(grafanaURL, "/"),
+		bearerToken: bearerToken,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+	}
+}
+
+// RancherMonitoringURL returns the proxy URL of the Grafana deployed by rancher-monitoring on the given cluster.
+func RancherMonitoringURL(host, clusterID string) string {
+	return promclient.ServiceProxyURL(host, clusterID, "cattle-monitoring-system", "rancher-monitoring-grafana", 80)
+}
+
+// Datasource is a Grafana datasource.
+type Datasource struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// Datasources lists the configured datasources. They are read from the frontend settings, which unlike
+// /api/datasources are available to viewers such as the anonymous user.
+func (c *Client) Datasources() ([]Datasource, error) {
+	settings := struct {
+		Datasources map[string]Datasource `json:"datasources"`
+	}{}
+	if err := c.get("/api/frontend/settings", nil, &settings); err != nil {
+		return nil, err
+	}
+
+	var datasources []Datasource
+	for name, datasource := range settings.Datasources {
+		// Built-in pseudo datasources such as "-- Grafana --" have no uid of their own to check.
+		if strings.HasPrefix(name, "-- ") {
+			continue
+		}
+		if datasource.Name == "" {
+			datasource.Name = name
+		}
+		datasources = append(datasources, datasource)
+	}
+	return datasources, nil
+}
+
+// CheckDatasourceHealth runs the datasource health check and returns an error describing a failed check.
+func (c *Client) CheckDatasourceHealth(uid string) error {
+	health := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{}
+	if err := c.get("/api/datasources/uid/"+url.PathEscape(uid)+"/health", nil, &health); err != nil {
+		return err
+	}
+	if health.Status != "OK" {
+		return fmt.Errorf("datasource %s is %s: %s", uid, health.Status, health.Message)
+	}
+	return nil
+}
+
+// DashboardRef is a dashboard search result.
+type DashboardRef struct {
+	UID         string   `json:"uid"`
+	Title       string   `json:"title"`
+	Tags        []string `json:"tags"`
+	FolderTitle string   `json:"folderTitle"`
+}
+
+// SearchDashboards lists dashboards whose title contains query and that carry all the given tags. An empty query
+// and no tags list every dashboard.
+func (c *Client) SearchDashboards(query string, tags ...string) ([]DashboardRef, error) {
+	params := url.Values{"type": {"dash-db"}}
+	if query != "" {
+		params.Set("query", query)
+	}
+	for _, tag := range tags {
+		params.Add("tag", tag)
+	}
+
+	var dashboards []DashboardRef
+	if err := c.get("/api/search", params, &dashboards); err != nil {
+		return nil, err
+	}
+	return dashboards, nil
+}
+
+// Dashboard returns the dashboard with the given uid.
+func (c *Client) Dashboard(uid string) (*Dashboard, error) {
+	response := struct {
+		Dashboard Dashboard `json:"dashboard"`
+	}{}
+	if err := c.get("/api/dashboards/uid/"+url.PathEscape(uid), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.Dashboard, nil
+}
+
+func (c *Client) get(path string, params url.Values, out interface{}) error {
+	target := c.baseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("grafana request %s failed: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("grafana request %s returned %d: %s", target, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode grafana response of %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+)
+
+// Dashboard is the part of a Grafana dashboard model needed to evaluate its panels.
+type Dashboard struct {
+	UID        string   `json:"uid"`
+	Title      string   `json:"title"`
+	Tags       []string `json:"tags"`
+	Panels     []Panel  `json:"panels"`
+	Templating struct {
+		List []Variable `json:"list"`
+	} `json:"templating"`
+}
+
+// Panel is a dashboard panel. Collapsed rows keep their panels nested.
+type Panel struct {
+	ID         int             `json:"id"`
+	Title      string          `json:"title"`
+	Type       string          `json:"type"`
+	Datasource json.RawMessage `json:"datasource"`
+	Targets    []Target        `json:"targets"`
+	Panels     []Panel         `json:"panels"`
+}
+
+// Target is a panel query.
+type Target struct {
+	RefID      string          `json:"refId"`
+	Expr       string          `json:"expr"`
+	Hide       bool            `json:"hide"`
+	Datasource json.RawMessage `json:"datasource"`
+}
+
+// Variable is a dashboard template variable.
+type Variable struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Query   json.RawMessage `json:"query"`
+	Current struct {
+		Value json.RawMessage `json:"value"`
+	} `json:"current"`
+}
+
+// AllPanels returns the panels of the dashboard including those inside collapsed rows.
+func (d *Dashboard) AllPanels() []Panel {
+	var panels []Panel
+	var walk func([]Panel)
+	walk = func(list []Panel) {
+		for _, panel := range list {
+			panels = append(panels, panel)
+			walk(panel.Panels)
+		}
+	}
+	walk(d.Panels)
+	return panels
+}
+
+// Panel query outcomes.
+const (
+	PanelOK     = "ok"
+	PanelNoData = "no data"
+	PanelError  = "error"
+)
+
+// PanelResult is the outcome of one panel query evaluated against Prometheus.
+type PanelResult struct {
+	Dashboard string
+	PanelID   int
+	Panel     string
+	RefID     string
+	// Expr is the query as written in the dashboard, Query the one sent to Prometheus.
+	Expr   string
+	Query  string
+	Status string
+	Error  string
+}
+
+// String formats the result for logs and failure messages.
+func (r PanelResult) String() string {
+	s := fmt.Sprintf("%s / %s (%s): %s", r.Dashboard, r.Panel, r.RefID, r.Status)
+	if r.Error != "" {
+		s += ": " + r.Error
+	}
+	return s + " [" + r.Query + "]"
+}
+
+// defaultQueryRange is the time range panel queries are evaluated over, matching the Grafana default.
+const defaultQueryRange = time.Hour
+
+// builtinVariables are the Grafana interval and range variables, with the values the default time range gives.
+var builtinVariables = map[string]string{
+	"__rate_interval": "5m",
+	"__interval":      "1m",
+	"__interval_ms":   "60000",
+	"__range":         "1h",
+	"__range_s":       "3600",
+	"__range_ms":      "3600000",
+}
+
+var labelValuesQuery = regexp.MustCompile(`^\s*label_values\((.*),\s*(\w+)\s*\)\s*$`)
+
+// CheckPanels evaluates every Prometheus query of the dashboard over the last hour. A query Prometheus rejects is
+// reported as an error, one that returns no series as no data. Targets of other datasources are skipped.
+func CheckPanels(prom *promclient.Client, dashboard *Dashboard) []PanelResult {
+	values := resolveVariables(prom, dashboard)
+	end := time.Now()
+	start := end.Add(-defaultQueryRange)
+
+	var results []PanelResult
+	for _, panel := range dashboard.AllPanels() {
+		for _, target := range panel.Targets {
+			if target.Hide || target.Expr == "" || !isPrometheus(target.Datasource, panel.Datasource) {
+				continue
+			}
+			result := PanelResult{
+				Dashboard: dashboard.Title,
+				PanelID:   panel.ID,
+				Panel:     panel.Title,
+				RefID:     target.RefID,
+				Expr:      target.Expr,
+				Query:     interpolate(target.Expr, values),
+			}
+
+			matrix, err := prom.QueryRange(result.Query, start, end, time.Minute)
+			switch {
+			case err != nil:
+				result.Status = PanelError
+				result.Error = err.Error()
+			case len(matrix) == 0:
+				result.Status = PanelNoData
+			default:
+				result.Status = PanelOK
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// isPrometheus reports whether a target runs against Prometheus. The target datasource wins over the panel one;
+// references by name or by a template variable are assumed to be the Prometheus datasource rancher-monitoring
+// ships.
+func isPrometheus(datasources ...json.RawMessage) bool {
+	for _, raw := range datasources {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		ref := struct {
+			Type string `json:"type"`
+		}{}
+		if err := json.Unmarshal(raw, &ref); err != nil || ref.Type == "" {
+			return true
+		}
+		return ref.Type == "prometheus"
+	}
+	return true
+}
+
+// resolveVariables returns a value for each template variable: its current value when the dashboard stores one,
+// otherwise the first value of a label_values() query, otherwise a match-all regex.
+func resolveVariables(prom *promclient.Client, dashboard *Dashboard) map[string]string {
+	values := map[string]string{}
+	for name, value := range builtinVariables {
+		values[name] = value
+	}
+
+	for _, variable := range dashboard.Templating.List {
+		if variable.Type == "datasource" {
+			values[variable.Name] = "prometheus"
+			continue
+		}
+		if current := currentValue(variable); current != "" {
+			values[variable.Name] = current
+			continue
+		}
+		if first := firstLabelValue(prom, variable, values); first != "" {
+			values[variable.Name] = first
+			continue
+		}
+		values[variable.Name] = ".*"
+	}
+	return values
+}
+
+func currentValue(variable Variable) string {
+	var value string
+	if err := json.Unmarshal(variable.Current.Value, &value); err == nil {
+		if value == "$__all" {
+			return ".*"
+		}
+		return value
+	}
+	var list []string
+	if err := json.Unmarshal(variable.Current.Value, &list); err == nil && len(list) > 0 {
+		if list[0] == "$__all" {
+			return ".*"
+		}
+		return strings.Join(list, "|")
+	}
+	return ""
+}
+
+func firstLabelValue(prom *promclient.Client, variable Variable, values map[string]string) string {
+	// The query is either a string or, in newer dashboards, an object with a "query" field.
+	var query string
+	if err := json.Unmarshal(variable.Query, &query); err != nil {
+		object := struct {
+			Query string `json:"query"`
+		}{}
+		if err := json.Unmarshal(variable.Query, &object); err != nil {
+			return ""
+		}
+		query = object.Query
+	}
+
+	match := labelValuesQuery.FindStringSubmatch(query)
+	if match == nil {
+		return ""
+	}
+	result, err := prom.Query(fmt.Sprintf("group by (%s) (%s)", match[2], interpolate(match[1], values)))
+	if err != nil || len(*result) == 0 {
+		return ""
+	}
+	return string((*result)[0].Metric[model.LabelName(match[2])])
+}
+
+// interpolate replaces $var, ${var}, ${var:format} and [[var]] references. Longer names are replaced first so
+// $namespace is not mistaken for $name.
+func interpolate(expr string, values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		value := values[name]
+		expr = regexp.MustCompile(`\$\{`+regexp.QuoteMeta(name)+`(:[^}]*)?\}`).ReplaceAllLiteralString(expr, value)
+		expr = strings.ReplaceAll(expr, "[["+name+"]]", value)
+		expr = regexp.MustCompile(`\$`+regexp.QuoteMeta(name)+`\b`).ReplaceAllLiteralString(expr, value)
+	}
+	return expr
+}
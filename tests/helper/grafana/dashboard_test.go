@@ -0,0 +1,225 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+)
+
+// fakePrometheus answers instant queries with one series whose labels are given by query, and range queries with
+// a series, no series when the query contains "absent" or a bad_data error when it contains "invalid(". It records
+// the queries it received.
+func fakePrometheus(t *testing.T, series map[string]map[string]string) (*promclient.Client, *[]string) {
+	t.Helper()
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid query: %v", err)
+		}
+		query := r.Form.Get("query")
+		queries = append(queries, query)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case strings.Contains(query, "invalid("):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+		case strings.HasSuffix(r.URL.Path, "/query_range"):
+			result := `[{"metric":{},"values":[[1700000000,"1"]]}]`
+			if strings.Contains(query, "absent") {
+				result = `[]`
+			}
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":%s}}`, result)
+		default:
+			result := []interface{}{}
+			if labels, ok := series[query]; ok {
+				result = append(result, map[string]interface{}{"metric": labels, "value": []interface{}{1700000000, "1"}})
+			}
+			data, _ := json.Marshal(result)
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":%s}}`, data)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := promclient.NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, &queries
+}
+
+// variable returns a template variable with the given JSON query and current value.
+func variable(name, variableType, query, current string) Variable {
+	v := Variable{Name: name, Type: variableType, Query: json.RawMessage(query)}
+	v.Current.Value = json.RawMessage(current)
+	return v
+}
+
+func TestInterpolate(t *testing.T) {
+	values := map[string]string{
+		"name":            "rancher-backup",
+		"namespace":       "cattle-resources-system",
+		"__interval":      "1m",
+		"__rate_interval": "5m",
+	}
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{name: "dollar", expr: `up{namespace="$namespace"}`, want: `up{namespace="cattle-resources-system"}`},
+		{name: "braces", expr: `up{job="${name}-metrics"}`, want: `up{job="rancher-backup-metrics"}`},
+		{name: "braces with format", expr: `up{namespace=~"${namespace:regex}"}`, want: `up{namespace=~"cattle-resources-system"}`},
+		{name: "brackets", expr: `up{job="[[name]]"}`, want: `up{job="rancher-backup"}`},
+		{name: "longer name first", expr: `up{namespace="$namespace",name="$name"}`, want: `up{namespace="cattle-resources-system",name="rancher-backup"}`},
+		{name: "name followed by a word character", expr: `up{job="$names"}`, want: `up{job="$names"}`},
+		{name: "interval", expr: `sum(increase(errors[$__interval]))`, want: `sum(increase(errors[1m]))`},
+		{name: "rate interval", expr: `rate(errors[$__rate_interval])`, want: `rate(errors[5m])`},
+		{name: "unknown variable", expr: `up{pod="$pod"}`, want: `up{pod="$pod"}`},
+		{name: "every form", expr: `$name ${name} [[name]]`, want: `rancher-backup rancher-backup rancher-backup`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interpolate(tt.expr, values); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		want    string
+	}{
+		{name: "single value", current: `"cattle-resources-system"`, want: "cattle-resources-system"},
+		{name: "multiple values", current: `["a","b"]`, want: "a|b"},
+		{name: "all", current: `"$__all"`, want: ".*"},
+		{name: "all in a list", current: `["$__all"]`, want: ".*"},
+		{name: "empty list", current: `[]`},
+		{name: "empty string", current: `""`},
+		{name: "missing"},
+		{name: "number", current: `1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := currentValue(variable("v", "query", "", tt.current)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPrometheus(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		panel        string
+		isPrometheus bool
+	}{
+		{name: "no datasource", isPrometheus: true},
+		{name: "null datasources", target: `null`, panel: `null`, isPrometheus: true},
+		{name: "prometheus target", target: `{"type":"prometheus","uid":"prometheus"}`, isPrometheus: true},
+		{name: "loki target", target: `{"type":"loki","uid":"loki"}`, panel: `{"type":"prometheus"}`},
+		{name: "panel datasource", panel: `{"type":"loki"}`},
+		{name: "target wins over panel", target: `{"type":"prometheus"}`, panel: `{"type":"loki"}`, isPrometheus: true},
+		{name: "name reference", target: `"Prometheus"`, isPrometheus: true},
+		{name: "variable reference", target: `"$datasource"`, isPrometheus: true},
+		{name: "variable uid", target: `{"uid":"$datasource"}`, isPrometheus: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPrometheus(json.RawMessage(tt.target), json.RawMessage(tt.panel)); got != tt.isPrometheus {
+				t.Errorf("got %t, want %t", got, tt.isPrometheus)
+			}
+		})
+	}
+}
+
+func TestResolveVariables(t *testing.T) {
+	prom, queries := fakePrometheus(t, map[string]map[string]string{
+		`group by (namespace) (rancher_backups_count{job="rancher-backup"})`: {"namespace": "cattle-resources-system"},
+	})
+	dashboard := &Dashboard{}
+	dashboard.Templating.List = []Variable{
+		variable("datasource", "datasource", `"prometheus"`, `"default"`),
+		variable("job", "custom", `"rancher-backup"`, `"rancher-backup"`),
+		variable("instance", "query", `"label_values(up, instance)"`, `["a:8080","b:8080"]`),
+		variable("pod", "query", `"label_values(up, pod)"`, `"$__all"`),
+		// Newer dashboards store the query as an object, and a query can use the variables before it.
+		variable("namespace", "query", `{"query":"label_values(rancher_backups_count{job=\"$job\"}, namespace)","refId":"A"}`, `null`),
+		variable("node", "query", `"label_values(node_uname_info, nodename)"`, `null`),
+		variable("interval", "interval", `"1m,5m"`, `[]`),
+	}
+
+	values := resolveVariables(prom, dashboard)
+	want := map[string]string{
+		"datasource": "prometheus",
+		"job":        "rancher-backup",
+		"instance":   "a:8080|b:8080",
+		"pod":        ".*",
+		"namespace":  "cattle-resources-system",
+		"node":       ".*",
+		"interval":   ".*",
+	}
+	for name, value := range builtinVariables {
+		want[name] = value
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+
+	wantQueries := []string{
+		`group by (namespace) (rancher_backups_count{job="rancher-backup"})`,
+		`group by (nodename) (node_uname_info)`,
+	}
+	if !reflect.DeepEqual(*queries, wantQueries) {
+		t.Errorf("got queries %q, want %q", *queries, wantQueries)
+	}
+}
+
+func TestCheckPanels(t *testing.T) {
+	prom, _ := fakePrometheus(t, nil)
+	dashboard := &Dashboard{
+		Title: "Rancher Backups",
+		Panels: []Panel{
+			{ID: 1, Title: "Backups", Targets: []Target{
+				{RefID: "A", Expr: `sum(rate(rancher_backups_count{namespace="$namespace"}[$__rate_interval]))`},
+				{RefID: "B", Expr: `absent_backups`},
+				{RefID: "C", Expr: `hidden`, Hide: true},
+				{RefID: "D"},
+			}},
+			{ID: 2, Title: "Logs", Datasource: json.RawMessage(`{"type":"loki"}`), Targets: []Target{
+				{RefID: "A", Expr: `{namespace="cattle-resources-system"}`},
+			}},
+			{ID: 3, Title: "Row", Type: "row", Panels: []Panel{
+				{ID: 4, Title: "Broken", Targets: []Target{{RefID: "A", Expr: `invalid(`}}},
+			}},
+		},
+	}
+	dashboard.Templating.List = []Variable{variable("namespace", "custom", `"cattle-resources-system"`, `"cattle-resources-system"`)}
+
+	results := CheckPanels(prom, dashboard)
+	var got []string
+	for _, result := range results {
+		got = append(got, fmt.Sprintf("%s/%s %s %s", result.Panel, result.RefID, result.Status, result.Query))
+	}
+	want := []string{
+		`Backups/A ok sum(rate(rancher_backups_count{namespace="cattle-resources-system"}[5m]))`,
+		`Backups/B no data absent_backups`,
+		`Broken/A error invalid(`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if broken := results[len(results)-1]; !strings.Contains(broken.Error, "parse error") || broken.PanelID != 4 {
+		t.Errorf("got panel %d with error %q, want the Prometheus error of panel 4", broken.PanelID, broken.Error)
+	}
+}
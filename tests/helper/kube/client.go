@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+// LocalCluster is the cluster ID of the Rancher local cluster.
+const LocalCluster = "local"
+
+// FieldManager is the field manager of objects applied by the tests.
+const FieldManager = "observability-e2e"
+
+// Client gives typed and dynamic access to one cluster through the Rancher proxy. It needs no kubeconfig or kubectl
+// binary: requests use the token of the Rancher client it was created from.
+type Client struct {
+	ClusterID string
+	// Config is the REST config of the cluster proxy, for client-go tooling that needs one.
+	Config    *rest.Config
+	Clientset kubernetes.Interface
+	// Dynamic is the shepherd dynamic client, so objects it creates are cleaned up with the session.
+	Dynamic dynamic.Interface
+
+	mapperOnce sync.Once
+	mapper     meta.ResettableRESTMapper
+}
+
+// NewClient returns a client for the cluster with the given ID, such as LocalCluster.
+func NewClient(client *rancher.Client, clusterID string) (*Client, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dynamic client of cluster %s: %w", clusterID, err)
+	}
+
+	config := RESTConfig(client, clusterID)
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clientset of cluster %s: %w", clusterID, err)
+	}
+
+	return &Client{
+		ClusterID: clusterID,
+		Config:    config,
+		Clientset: clientset,
+		Dynamic:   dynamicClient,
+	}, nil
+}
+
+// RESTConfig returns the REST config of the Rancher proxy of a cluster, authenticated as the Rancher client.
+func RESTConfig(client *rancher.Client, clusterID string) *rest.Config {
+	config := &rest.Config{
+		Host:        fmt.Sprintf("https://%s/k8s/clusters/%s", client.RancherConfig.Host, clusterID),
+		BearerToken: client.RancherConfig.AdminToken,
+	}
+	if client.Management != nil && client.Management.Opts != nil && client.Management.Opts.TokenKey != "" {
+		config.BearerToken = client.Management.Opts.TokenKey
+	}
+	if client.RancherConfig.Insecure != nil {
+		config.Insecure = *client.RancherConfig.Insecure
+	}
+	if !config.Insecure {
+		config.CAFile = client.RancherConfig.CAFile
+	}
+	return config
+}
+
+// restMapper returns a mapper from kinds to resources, backed by the discovery of the cluster. It is reset when a
+// kind is not found, so CRDs installed after the first lookup are found too.
+func (c *Client) restMapper() meta.ResettableRESTMapper {
+	c.mapperOnce.Do(func() {
+		c.mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.Clientset.Discovery()))
+	})
+	return c.mapper
+}
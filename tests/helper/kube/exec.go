@@ -0,0 +1,87 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// ExecOptions describe a command to run in a container. Nil streams are not attached.
+type ExecOptions struct {
+	Namespace string
+	Pod       string
+	// Container defaults to the first container of the pod.
+	Container string
+	Command   []string
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Stderr    io.Writer
+	// TTY allocates a terminal, which merges stderr into stdout.
+	TTY bool
+}
+
+// ExecStream runs a command in a container and connects its streams until it exits or ctx is done. The command
+// runs over WebSocket through the Rancher proxy and falls back to SPDY when the proxy can not upgrade to it.
+func (c *Client) ExecStream(ctx context.Context, opts ExecOptions) error {
+	req := c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(opts.Namespace).
+		Name(opts.Pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: opts.Container,
+			Command:   opts.Command,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    opts.Stdout != nil,
+			Stderr:    opts.Stderr != nil && !opts.TTY,
+			TTY:       opts.TTY,
+		}, scheme.ParameterCodec)
+
+	websocketExec, err := remotecommand.NewWebSocketExecutor(c.Config, http.MethodGet, req.URL().String())
+	if err != nil {
+		return err
+	}
+	spdyExec, err := remotecommand.NewSPDYExecutor(c.Config, http.MethodPost, req.URL())
+	if err != nil {
+		return err
+	}
+	executor, err := remotecommand.NewFallbackExecutor(websocketExec, spdyExec, httpstream.IsUpgradeFailure)
+	if err != nil {
+		return err
+	}
+
+	streams := remotecommand.StreamOptions{Stdin: opts.Stdin, Stdout: opts.Stdout, Tty: opts.TTY}
+	if !opts.TTY {
+		streams.Stderr = opts.Stderr
+	}
+	if err := executor.StreamWithContext(ctx, streams); err != nil {
+		return fmt.Errorf("exec %q in %s/%s failed: %w", strings.Join(opts.Command, " "), opts.Namespace, opts.Pod, err)
+	}
+	return nil
+}
+
+// Exec runs a command in a container of a pod and returns its stdout and stderr. The first container is used when
+// container is empty.
+func (c *Client) Exec(ctx context.Context, namespace, pod, container string, command ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := c.ExecStream(ctx, ExecOptions{
+		Namespace: namespace,
+		Pod:       pod,
+		Container: container,
+		Command:   command,
+		Stdout:    &stdout,
+		Stderr:    &stderr,
+	})
+	if err != nil {
+		return stdout.String(), stderr.String(), fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), stderr.String(), nil
+}
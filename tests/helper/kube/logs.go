@@ -0,0 +1,85 @@
+package kube
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxLogLine bounds the length of a log line WaitForLog can match.
+const maxLogLine = 1024 * 1024
+
+// Logs returns the logs of a pod. The options select the container, where the first container is the default, and
+// limit the lines, such as TailLines for `kubectl logs --tail`.
+func (c *Client) Logs(ctx context.Context, namespace, pod string, opts corev1.PodLogOptions) (string, error) {
+	data, err := c.Clientset.CoreV1().Pods(namespace).GetLogs(pod, &opts).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of %s/%s: %w", namespace, pod, err)
+	}
+	return string(data), nil
+}
+
+// StreamLogs opens the log stream of a pod. With Follow set it stays open until the container stops or ctx is done,
+// and SinceTime skips older lines. The caller closes the stream.
+func (c *Client) StreamLogs(ctx context.Context, namespace, pod string, opts corev1.PodLogOptions) (io.ReadCloser, error) {
+	stream, err := c.Clientset.CoreV1().Pods(namespace).GetLogs(pod, &opts).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream logs of %s/%s: %w", namespace, pod, err)
+	}
+	return stream, nil
+}
+
+// WaitForLog follows the logs of a pod until a line matches pattern and returns that line. Only lines logged since
+// opts.SinceTime are read, or all lines when it is unset. A stream closed by the proxy is reopened from the time it
+// was last opened. It fails when no line matched within timeout.
+func (c *Client) WaitForLog(ctx context.Context, namespace, pod string, opts corev1.PodLogOptions, pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	opts.Follow = true
+	for {
+		opened := metav1.Now()
+		line, err := c.matchLog(ctx, namespace, pod, opts, pattern)
+		if line != "" || err == nil {
+			return line, err
+		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("no log line of %s/%s matched %q within %s: %w", namespace, pod, pattern, timeout, err)
+		}
+
+		opts.SinceTime = &opened
+		opts.TailLines = nil
+		select {
+		case <-ctx.Done():
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// matchLog reads one log stream and returns the first line matching pattern. It returns an error when the stream
+// ends without a match.
+func (c *Client) matchLog(ctx context.Context, namespace, pod string, opts corev1.PodLogOptions, pattern *regexp.Regexp) (string, error) {
+	stream, err := c.StreamLogs(ctx, namespace, pod, opts)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLine)
+	for scanner.Scan() {
+		if pattern.MatchString(scanner.Text()) {
+			return scanner.Text(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("log stream of %s/%s ended", namespace, pod)
+}
@@ -0,0 +1,81 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RunPod creates a pod and waits until it is ready, like `kubectl run` followed by `kubectl wait`.
+func (c *Client) RunPod(ctx context.Context, pod *corev1.Pod, timeout time.Duration) (*corev1.Pod, error) {
+	created, err := c.Clientset.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	return c.WaitForPodReady(ctx, created.Namespace, created.Name, timeout)
+}
+
+// WaitForPodReady waits until a pod is ready, see PodReady. It fails early when the pod terminated.
+func (c *Client) WaitForPodReady(ctx context.Context, namespace, name string, timeout time.Duration) (*corev1.Pod, error) {
+	var pod *corev1.Pod
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		pod, err = c.Clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded {
+			return false, fmt.Errorf("pod %s/%s terminated with phase %s", namespace, name, pod.Status.Phase)
+		}
+		return PodReady(pod), nil
+	})
+	if err != nil {
+		return pod, fmt.Errorf("pod %s/%s is not ready: %w", namespace, name, err)
+	}
+	return pod, nil
+}
+
+// DeletePod deletes a pod right away. A missing pod is not an error.
+func (c *Client) DeletePod(ctx context.Context, namespace, name string) error {
+	var gracePeriod int64
+	err := c.Clientset.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// DeploymentPods returns the pods selected by a deployment.
+func (c *Client) DeploymentPods(ctx context.Context, namespace, name string) ([]corev1.Pod, error) {
+	deployment, err := c.Clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of deployment %s/%s: %w", namespace, name, err)
+	}
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// PodReady reports whether a pod is running with its Ready condition true, like 1/1 Running in `kubectl get pods`.
+func PodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
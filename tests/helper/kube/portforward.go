@@ -0,0 +1,77 @@
+package kube
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForward is a running port-forward to a pod.
+type PortForward struct {
+	// LocalPort is the local port that forwards to the pod.
+	LocalPort int
+
+	stopChan chan struct{}
+	doneChan chan error
+}
+
+// URL returns the http URL of the forwarded port on localhost with the given path.
+func (p *PortForward) URL(path string) string {
+	return fmt.Sprintf("http://127.0.0.1:%d/%s", p.LocalPort, strings.TrimPrefix(path, "/"))
+}
+
+// Close stops the port-forward.
+func (p *PortForward) Close() error {
+	close(p.stopChan)
+	return <-p.doneChan
+}
+
+// PortForward forwards a free local port to a port of a pod until Close is called. It tunnels SPDY over WebSocket
+// and falls back to plain SPDY when the proxy does not support it.
+func (c *Client) PortForward(namespace, pod string, port int) (*PortForward, error) {
+	req := c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(c.Config)
+	if err != nil {
+		return nil, err
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+	tunnelingDialer, err := portforward.NewSPDYOverWebsocketDialer(req.URL(), c.Config)
+	if err != nil {
+		return nil, err
+	}
+	dialer = portforward.NewFallbackDialer(tunnelingDialer, dialer, httpstream.IsUpgradeFailure)
+
+	forward := &PortForward{stopChan: make(chan struct{}), doneChan: make(chan error, 1)}
+	readyChan := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)}, forward.stopChan, readyChan, io.Discard, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		forward.doneChan <- forwarder.ForwardPorts()
+	}()
+	select {
+	case <-readyChan:
+	case err := <-forward.doneChan:
+		return nil, fmt.Errorf("failed to port-forward to %s/%s:%d: %w", namespace, pod, port, err)
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		close(forward.stopChan)
+		return nil, err
+	}
+	forward.LocalPort = int(ports[0].Local)
+	return forward, nil
+}
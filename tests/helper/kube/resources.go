@@ -0,0 +1,220 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Resources used across the helpers.
+var (
+	DeploymentsGVR  = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	DaemonSetsGVR   = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}
+	StatefulSetsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	PodsGVR         = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	ServicesGVR     = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	NamespacesGVR   = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	CRDsGVR         = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	AppsGVR         = schema.GroupVersionResource{Group: "catalog.cattle.io", Version: "v1", Resource: "apps"}
+	// ServiceMonitorsGVR and PodMonitorsGVR are the scrape configuration of the prometheus-operator.
+	ServiceMonitorsGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+	PodMonitorsGVR     = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "podmonitors"}
+)
+
+// Resource returns the dynamic client of a resource, namespaced unless namespace is empty.
+func (c *Client) Resource(gvr schema.GroupVersionResource, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return c.Dynamic.Resource(gvr)
+	}
+	return c.Dynamic.Resource(gvr).Namespace(namespace)
+}
+
+// Get returns an object. Cluster scoped objects take an empty namespace.
+func (c *Client) Get(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	return c.Resource(gvr, namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// List returns the objects of a resource, in every namespace when namespace is empty.
+func (c *Client) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	list, err := c.Resource(gvr, namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Watch watches the objects of a resource, in every namespace when namespace is empty.
+func (c *Client) Watch(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.Resource(gvr, namespace).Watch(ctx, opts)
+}
+
+// Exists reports whether an object exists.
+func (c *Client) Exists(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (bool, error) {
+	_, err := c.Get(ctx, gvr, namespace, name)
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ResourceFor returns the resource of a kind and whether it is namespaced.
+func (c *Client) ResourceFor(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	mapper := c.restMapper()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		mapper.Reset()
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("failed to find the resource of %s: %w", gvk, err)
+	}
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// Apply applies every object of a multi-document YAML or JSON manifest with server-side apply, like `kubectl apply`.
+// Namespaced objects without a namespace go to namespace, or to default when it is empty.
+func (c *Client) Apply(ctx context.Context, manifest []byte, namespace string) ([]*unstructured.Unstructured, error) {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, object := range objects {
+		resource, err := c.resourceOf(object, namespace)
+		if err != nil {
+			return applied, err
+		}
+		data, err := object.MarshalJSON()
+		if err != nil {
+			return applied, err
+		}
+		force := true
+		result, err := resource.Patch(ctx, object.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: FieldManager, Force: &force})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply %s %s: %w", object.GetKind(), object.GetName(), err)
+		}
+		applied = append(applied, result)
+	}
+	return applied, nil
+}
+
+// ApplyFile applies a manifest file, see Apply.
+func (c *Client) ApplyFile(ctx context.Context, path, namespace string) ([]*unstructured.Unstructured, error) {
+	manifest, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.Apply(ctx, manifest, namespace)
+}
+
+// DeleteManifest deletes every object of a manifest, like `kubectl delete -f`. Missing objects are ignored.
+func (c *Client) DeleteManifest(ctx context.Context, manifest []byte, namespace string) error {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, object := range objects {
+		resource, err := c.resourceOf(object, namespace)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = resource.Delete(ctx, object.GetName(), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", object.GetKind(), object.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteFile deletes the objects of a manifest file, see DeleteManifest.
+func (c *Client) DeleteFile(ctx context.Context, path, namespace string) error {
+	manifest, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.DeleteManifest(ctx, manifest, namespace)
+}
+
+// resourceOf returns the dynamic client of an object of a manifest and sets its namespace when it has none.
+func (c *Client) resourceOf(object *unstructured.Unstructured, namespace string) (dynamic.ResourceInterface, error) {
+	gvr, namespaced, err := c.ResourceFor(object.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+	if !namespaced {
+		return c.Dynamic.Resource(gvr), nil
+	}
+	if object.GetNamespace() == "" {
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		object.SetNamespace(namespace)
+	}
+	return c.Dynamic.Resource(gvr).Namespace(object.GetNamespace()), nil
+}
+
+// DecodeManifest splits a multi-document YAML or JSON manifest into objects. Empty documents are skipped and List
+// objects are expanded.
+func DecodeManifest(manifest []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		object := map[string]interface{}{}
+		if err := decoder.Decode(&object); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		if len(object) == 0 {
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: object}
+		if !u.IsList() {
+			objects = append(objects, u)
+			continue
+		}
+		err := u.EachListItem(func(item runtime.Object) error {
+			objects = append(objects, item.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// JSONPath evaluates a kubectl style JSONPath template, such as {.status.conditions[?(@.type=="Ready")].status},
+// against an object. Missing fields give an empty string.
+func JSONPath(object *unstructured.Unstructured, template string) (string, error) {
+	parser := jsonpath.New("jsonpath").AllowMissingKeys(true)
+	if err := parser.Parse(template); err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := parser.Execute(&out, object.Object); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
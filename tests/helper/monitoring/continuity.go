@@ -0,0 +1,368 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/shepherd/clients/rancher"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+const (
+	// Namespace is where rancher-monitoring runs and where the fixture creates its monitoring objects.
+	Namespace = "cattle-monitoring-system"
+	// DashboardNamespace is watched by the Grafana dashboard sidecar.
+	DashboardNamespace = "cattle-dashboards"
+	// PrometheusName is the Prometheus object rancher-monitoring creates.
+	PrometheusName = "rancher-monitoring-prometheus"
+
+	// ContinuityLabel marks every object created by a continuity fixture.
+	ContinuityLabel = "observability-e2e/continuity"
+	// ContinuityMetric is the recording rule whose samples are compared across the upgrade.
+	ContinuityMetric = "e2e_continuity_value"
+
+	ruleEvaluationInterval = "15s"
+	minContinuitySamples   = 4
+	sampleTimeout          = 5 * time.Minute
+)
+
+var (
+	PrometheusRuleGVR     = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules"}
+	ServiceMonitorGVR     = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+	PodMonitorGVR         = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "podmonitors"}
+	PrometheusGVR         = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheuses"}
+	AlertmanagerConfigGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1alpha1", Resource: "alertmanagerconfigs"}
+	configMapGVR          = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+// FixtureObject is an object created before the upgrade together with the content that must survive it.
+type FixtureObject struct {
+	Kind      string                      `json:"kind"`
+	GVR       schema.GroupVersionResource `json:"gvr"`
+	Namespace string                      `json:"namespace"`
+	Name      string                      `json:"name"`
+	// Field is the top level field compared after the upgrade, "spec" or "data".
+	Field   string      `json:"field"`
+	Content interface{} `json:"content"`
+}
+
+// Sample is a value of ContinuityMetric stored by Prometheus before the upgrade.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// ContinuityFixture records user data created before a rancher-monitoring upgrade so it can be checked after it.
+// It is saved to a file between the before and after upgrade runs.
+type ContinuityFixture struct {
+	ClusterID string          `json:"clusterID"`
+	ID        string          `json:"id"`
+	Objects   []FixtureObject `json:"objects"`
+	Samples   []Sample        `json:"samples"`
+}
+
+// BeforeUpgrade creates a PrometheusRule, an AlertmanagerConfig, a ServiceMonitor and a Grafana dashboard
+// ConfigMap, then waits until Prometheus has stored a few samples of the fixture's recording rule. Prometheus must
+// keep its TSDB on a volume claim, see charts.RancherMonitoringOpts.PrometheusStorageClass, since the samples are
+// lost on restart otherwise.
+func BeforeUpgrade(client *rancher.Client, clusterID string) (*ContinuityFixture, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	persistent, err := prometheusHasStorage(client, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if !persistent {
+		return nil, fmt.Errorf("prometheus %s/%s has no storage volume claim template, so its samples can not survive the upgrade", Namespace, PrometheusName)
+	}
+
+	fixture := &ContinuityFixture{ClusterID: clusterID, ID: namegen.RandStringLower(6)}
+
+	for _, obj := range fixture.objects() {
+		created, err := dynamicClient.Resource(obj.gvr).Namespace(obj.namespace).Create(context.TODO(), obj.object, metav1.CreateOptions{})
+		if err != nil {
+			return fixture, fmt.Errorf("failed to create %s %s/%s: %w", obj.kind, obj.namespace, obj.object.GetName(), err)
+		}
+		content, _, err := unstructured.NestedFieldCopy(created.Object, obj.field)
+		if err != nil {
+			return fixture, err
+		}
+		fixture.Objects = append(fixture.Objects, FixtureObject{
+			Kind:      obj.kind,
+			GVR:       obj.gvr,
+			Namespace: obj.namespace,
+			Name:      created.GetName(),
+			Field:     obj.field,
+			Content:   content,
+		})
+	}
+
+	promClient, err := promclient.NewClient(promclient.RancherMonitoringURL(client.RancherConfig.Host, clusterID), client.RancherConfig.AdminToken)
+	if err != nil {
+		return fixture, err
+	}
+
+	start := time.Now()
+	err = wait.PollUntilContextTimeout(context.TODO(), 15*time.Second, sampleTimeout, false, func(ctx context.Context) (bool, error) {
+		matrix, err := promClient.QueryRange(fixture.query(), start, time.Now(), 15*time.Second)
+		if err != nil || len(matrix) == 0 || len(matrix[0].Values) < minContinuitySamples {
+			return false, nil
+		}
+		fixture.Samples = nil
+		for _, pair := range matrix[0].Values {
+			fixture.Samples = append(fixture.Samples, Sample{Timestamp: pair.Timestamp.Time(), Value: float64(pair.Value)})
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fixture, fmt.Errorf("prometheus did not record %d samples of %s: %w", minContinuitySamples, fixture.query(), err)
+	}
+
+	e2e.Logf("Continuity fixture %s recorded %d samples", fixture.ID, len(fixture.Samples))
+	return fixture, nil
+}
+
+// AfterUpgrade checks that every fixture object still exists with the same content, that the recording rule
+// is still evaluated and that the samples taken before the upgrade are still returned with the same values.
+func (f *ContinuityFixture) AfterUpgrade(client *rancher.Client) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(f.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range f.Objects {
+		current, err := dynamicClient.Resource(obj.GVR).Namespace(obj.Namespace).Get(context.TODO(), obj.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("%s %s/%s did not survive the upgrade: %w", obj.Kind, obj.Namespace, obj.Name, err)
+		}
+		content, _, err := unstructured.NestedFieldCopy(current.Object, obj.Field)
+		if err != nil {
+			return err
+		}
+		if !sameContent(obj.Content, content) {
+			return fmt.Errorf("%s %s/%s %s changed during the upgrade", obj.Kind, obj.Namespace, obj.Name, obj.Field)
+		}
+	}
+
+	promClient, err := promclient.NewClient(promclient.RancherMonitoringURL(client.RancherConfig.Host, f.ClusterID), client.RancherConfig.AdminToken)
+	if err != nil {
+		return err
+	}
+
+	err = wait.PollUntilContextTimeout(context.TODO(), 15*time.Second, sampleTimeout, true, func(ctx context.Context) (bool, error) {
+		result, err := promClient.Query(f.query())
+		return err == nil && len(*result) > 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("recording rule %s is not evaluated after the upgrade: %w", f.query(), err)
+	}
+
+	if len(f.Samples) == 0 {
+		return fmt.Errorf("continuity fixture %s has no samples to compare", f.ID)
+	}
+	for _, sample := range f.Samples {
+		result, err := promClient.QueryAt(f.query(), sample.Timestamp)
+		if err != nil {
+			return err
+		}
+		if len(*result) == 0 {
+			return fmt.Errorf("sample of %s at %s was lost during the upgrade", f.query(), sample.Timestamp.Format(time.RFC3339))
+		}
+		if value := float64((*result)[0].Value); value != sample.Value {
+			return fmt.Errorf("sample of %s at %s changed from %v to %v", f.query(), sample.Timestamp.Format(time.RFC3339), sample.Value, value)
+		}
+	}
+	e2e.Logf("All %d samples of continuity fixture %s survived the upgrade", len(f.Samples), f.ID)
+	return nil
+}
+
+// Cleanup deletes the fixture objects. Objects that are already gone are ignored.
+func (f *ContinuityFixture) Cleanup(client *rancher.Client) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(f.ClusterID)
+	if err != nil {
+		return err
+	}
+	for _, obj := range f.Objects {
+		err := dynamicClient.Resource(obj.GVR).Namespace(obj.Namespace).Delete(context.TODO(), obj.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s %s/%s: %w", obj.Kind, obj.Namespace, obj.Name, err)
+		}
+	}
+	return nil
+}
+
+// Save writes the fixture to a file so a later run can check it.
+func (f *ContinuityFixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadContinuityFixture reads a fixture written by Save.
+func LoadContinuityFixture(path string) (*ContinuityFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &ContinuityFixture{}
+	if err := json.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse continuity fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// query selects the samples of this fixture's recording rule.
+func (f *ContinuityFixture) query() string {
+	return fmt.Sprintf(`%s{fixture="%s"}`, ContinuityMetric, f.ID)
+}
+
+type fixtureTemplate struct {
+	kind      string
+	gvr       schema.GroupVersionResource
+	namespace string
+	field     string
+	object    *unstructured.Unstructured
+}
+
+// objects returns the objects to create. The recording rule value is random so samples from an earlier fixture
+// cannot be mistaken for this one.
+func (f *ContinuityFixture) objects() []fixtureTemplate {
+	name := "e2e-continuity-" + f.ID
+	labels := map[string]interface{}{ContinuityLabel: f.ID}
+	value := rand.Intn(1000000)
+
+	dashboard := map[string]interface{}{
+		"uid":           name,
+		"title":         "E2E continuity " + f.ID,
+		"tags":          []interface{}{"e2e-continuity"},
+		"schemaVersion": 36,
+		"panels": []interface{}{
+			map[string]interface{}{
+				"type":    "timeseries",
+				"title":   ContinuityMetric,
+				"targets": []interface{}{map[string]interface{}{"expr": f.query()}},
+			},
+		},
+	}
+	dashboardJSON, _ := json.Marshal(dashboard)
+
+	return []fixtureTemplate{
+		{
+			kind: "PrometheusRule", gvr: PrometheusRuleGVR, namespace: Namespace, field: "spec",
+			object: newObject("monitoring.coreos.com/v1", "PrometheusRule", Namespace, name, labels, "spec", map[string]interface{}{
+				"groups": []interface{}{
+					map[string]interface{}{
+						"name":     name,
+						"interval": ruleEvaluationInterval,
+						"rules": []interface{}{
+							map[string]interface{}{
+								"record": ContinuityMetric,
+								"expr":   fmt.Sprintf("vector(%d)", value),
+								"labels": map[string]interface{}{"fixture": f.ID},
+							},
+						},
+					},
+				},
+			}),
+		},
+		{
+			kind: "AlertmanagerConfig", gvr: AlertmanagerConfigGVR, namespace: Namespace, field: "spec",
+			object: newObject("monitoring.coreos.com/v1alpha1", "AlertmanagerConfig", Namespace, name,
+				map[string]interface{}{ContinuityLabel: f.ID, "managed-by": "rancher"}, "spec", map[string]interface{}{
+					"receivers": []interface{}{
+						map[string]interface{}{
+							"name": "continuity",
+							"webhookConfigs": []interface{}{
+								map[string]interface{}{"url": "http://continuity.invalid/alert", "sendResolved": false},
+							},
+						},
+					},
+					"route": map[string]interface{}{
+						"receiver": "continuity",
+						"matchers": []interface{}{
+							map[string]interface{}{"name": "fixture", "value": f.ID, "matchType": "="},
+						},
+					},
+				}),
+		},
+		{
+			kind: "ServiceMonitor", gvr: ServiceMonitorGVR, namespace: Namespace, field: "spec",
+			object: newObject("monitoring.coreos.com/v1", "ServiceMonitor", Namespace, name, labels, "spec", map[string]interface{}{
+				"selector":  map[string]interface{}{"matchLabels": map[string]interface{}{ContinuityLabel: f.ID}},
+				"endpoints": []interface{}{map[string]interface{}{"port": "metrics", "interval": "30s"}},
+			}),
+		},
+		{
+			kind: "ConfigMap", gvr: configMapGVR, namespace: DashboardNamespace, field: "data",
+			object: newObject("v1", "ConfigMap", DashboardNamespace, name,
+				map[string]interface{}{ContinuityLabel: f.ID, "grafana_dashboard": "1"}, "data", map[string]interface{}{
+					name + ".json": string(dashboardJSON),
+				}),
+		},
+	}
+}
+
+func newObject(apiVersion, kind, namespace, name string, labels map[string]interface{}, field string, content map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    labels,
+		},
+		field: content,
+	}}
+}
+
+// prometheusHasStorage reports whether the rancher-monitoring Prometheus keeps its TSDB on a volume claim.
+func prometheusHasStorage(client *rancher.Client, clusterID string) (bool, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return false, err
+	}
+	prometheus, err := dynamicClient.Resource(PrometheusGVR).Namespace(Namespace).Get(context.TODO(), PrometheusName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get Prometheus %s/%s: %w", Namespace, PrometheusName, err)
+	}
+	_, found, err := unstructured.NestedMap(prometheus.Object, "spec", "storage", "volumeClaimTemplate")
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// sameContent compares content after a JSON round trip, so numbers read from a saved fixture (float64) and from
+// the API (int64) compare equal.
+func sameContent(a, b interface{}) bool {
+	return reflect.DeepEqual(roundTrip(a), roundTrip(b))
+}
+
+func roundTrip(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return value
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package monitoring
+
+import (
+	"fmt"
+
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/shepherd/extensions/clusters"
+)
+
+// k3sServerJob is the job of the single k3sServer exporter, which serves the metrics of every control plane
+// component k3s runs in its server process.
+const k3sServerJob = "k3s-server"
+
+// Exporter is the control plane exporter enabled by one RancherMonitoringOpts field on a given provider.
+type Exporter struct {
+	// Component is the json name of the RancherMonitoringOpts field, for example "etcd".
+	Component string
+	// Job is the Prometheus job the exporter targets are scraped under.
+	Job string
+	// Metrics must have at least one series on Job while the exporter is enabled.
+	Metrics []string
+	// Shared is set when other components are scraped under the same job, so a disabled component can not be
+	// told apart by its targets.
+	Shared bool
+}
+
+var (
+	controllerManagerMetrics = []string{"workqueue_adds_total"}
+	etcdMetrics              = []string{"etcd_server_has_leader"}
+	proxyMetrics             = []string{"kubeproxy_sync_proxy_rules_duration_seconds_count"}
+	schedulerMetrics         = []string{"scheduler_schedule_attempts_total"}
+	ingressNginxMetrics      = []string{"nginx_ingress_controller_config_last_reload_successful"}
+)
+
+// pushProxExporters are the exporters of the RKE and RKE2 pushprox subcharts, scraped under the job of their
+// component.
+var pushProxExporters = []Exporter{
+	{Component: "controllerManager", Job: "kube-controller-manager", Metrics: controllerManagerMetrics},
+	{Component: "etcd", Job: "kube-etcd", Metrics: etcdMetrics},
+	{Component: "proxy", Job: "kube-proxy", Metrics: proxyMetrics},
+	{Component: "scheduler", Job: "kube-scheduler", Metrics: schedulerMetrics},
+	{Component: "ingressNginx", Job: "ingress-nginx", Metrics: ingressNginxMetrics},
+}
+
+// k3sExporters are served by the k3s server process. K3s ships traefik instead of ingress-nginx and only exposes
+// etcd metrics when asked to, so neither has an exporter here.
+var k3sExporters = []Exporter{
+	{Component: "controllerManager", Job: k3sServerJob, Metrics: controllerManagerMetrics, Shared: true},
+	{Component: "proxy", Job: k3sServerJob, Metrics: proxyMetrics, Shared: true},
+	{Component: "scheduler", Job: k3sServerJob, Metrics: schedulerMetrics, Shared: true},
+}
+
+// Exporters returns the control plane exporters rancher-monitoring deploys on clusters of the given provider.
+// Imported RKE2 and K3s clusters report their distribution as provider and get the same exporters.
+func Exporters(provider clusters.KubernetesProvider) ([]Exporter, error) {
+	switch provider {
+	case clusters.KubernetesProviderRKE, clusters.KubernetesProviderRKE2:
+		return pushProxExporters, nil
+	case clusters.KubernetesProviderK3S:
+		return k3sExporters, nil
+	default:
+		return nil, fmt.Errorf("no control plane exporters are known for provider %q", provider)
+	}
+}
+
+// VerifyExporters checks the control plane exporters of the cluster against the options rancher-monitoring was
+// installed with. Each enabled component needs an up target and series of its key metrics; a disabled component
+// must have no targets. It returns one line per problem found.
+func VerifyExporters(prom *promclient.Client, provider clusters.KubernetesProvider, opts *charts.RancherMonitoringOpts) ([]string, error) {
+	exporters, err := Exporters(provider)
+	if err != nil {
+		return nil, err
+	}
+	enabled := map[string]bool{
+		"controllerManager": opts.ControllerManager,
+		"etcd":              opts.Etcd,
+		"proxy":             opts.Proxy,
+		"scheduler":         opts.Scheduler,
+		"ingressNginx":      opts.IngressNginx,
+	}
+
+	var problems []string
+	for _, exporter := range exporters {
+		up, err := prom.Query(fmt.Sprintf(`up{job=%q}`, exporter.Job))
+		if err != nil {
+			return nil, err
+		}
+
+		if !enabled[exporter.Component] {
+			if !exporter.Shared && len(*up) > 0 {
+				problems = append(problems, fmt.Sprintf("%s is disabled but job %s has %d targets", exporter.Component, exporter.Job, len(*up)))
+			}
+			continue
+		}
+
+		if len(*up) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is enabled but job %s has no targets", exporter.Component, exporter.Job))
+			continue
+		}
+		for _, sample := range *up {
+			if sample.Value != 1 {
+				problems = append(problems, fmt.Sprintf("%s target %s of job %s is down", exporter.Component, sample.Metric["instance"], exporter.Job))
+			}
+		}
+		for _, metric := range exporter.Metrics {
+			series, err := prom.Query(fmt.Sprintf(`%s{job=%q}`, metric, exporter.Job))
+			if err != nil {
+				return nil, err
+			}
+			if len(*series) == 0 {
+				problems = append(problems, fmt.Sprintf("%s emits no %s on job %s", exporter.Component, metric, exporter.Job))
+			}
+		}
+	}
+	return problems, nil
+}
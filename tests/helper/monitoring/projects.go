@@ -0,0 +1,202 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rancher/observability-e2e/tests/helper/probe"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/shepherd/clients/rancher"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// IsolationMetric is exposed by the metrics workload, labelled with the marker it was deployed with.
+	IsolationMetric = "e2e_project_isolation_info"
+	// FederatedMetric is a kube-state-metrics series a project Prometheus only gets through federation.
+	FederatedMetric = "kube_pod_info"
+
+	metricsWorkloadName  = "e2e-metrics"
+	metricsWorkloadImage = "library/busybox:1.36"
+	metricsWorkloadPort  = 8080
+	projectIDLabel       = "field.cattle.io/projectId"
+)
+
+var (
+	deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	serviceGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	namespaceGVR  = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+// DeployMetricsWorkload deploys a static metrics endpoint exposing IsolationMetric with the given marker, and a
+// ServiceMonitor scraping it, into the namespace. The image is pulled through the system-default-registry, so
+// air-gapped installs need it mirrored as library/busybox.
+func DeployMetricsWorkload(client *rancher.Client, clusterID, namespace, marker string) error {
+	registry, err := probe.DefaultRegistry(client)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]interface{}{"app": metricsWorkloadName}
+	objects := []struct {
+		gvr    schema.GroupVersionResource
+		object map[string]interface{}
+	}{
+		{configMapGVR, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": metricsWorkloadName},
+			"data": map[string]interface{}{
+				// Served as metrics.txt so httpd sends the text/plain content type Prometheus expects.
+				"metrics.txt": fmt.Sprintf("# TYPE %s gauge\n%s{marker=%q} 1\n", IsolationMetric, IsolationMetric, marker),
+			},
+		}},
+		{deploymentGVR, map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": metricsWorkloadName, "labels": labels},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"selector": map[string]interface{}{"matchLabels": labels},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": labels},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{
+							"name":    "metrics",
+							"image":   probe.WithRegistry(metricsWorkloadImage, registry),
+							"command": []interface{}{"httpd", "-f", "-p", fmt.Sprint(metricsWorkloadPort), "-h", "/www"},
+							"ports": []interface{}{map[string]interface{}{
+								"name":          "metrics",
+								"containerPort": int64(metricsWorkloadPort),
+							}},
+							"volumeMounts": []interface{}{map[string]interface{}{"name": "www", "mountPath": "/www"}},
+						}},
+						"volumes": []interface{}{map[string]interface{}{
+							"name":      "www",
+							"configMap": map[string]interface{}{"name": metricsWorkloadName},
+						}},
+					},
+				},
+			},
+		}},
+		{serviceGVR, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": metricsWorkloadName, "labels": labels},
+			"spec": map[string]interface{}{
+				"selector": labels,
+				"ports": []interface{}{map[string]interface{}{
+					"name":       "metrics",
+					"port":       int64(metricsWorkloadPort),
+					"targetPort": "metrics",
+				}},
+			},
+		}},
+		{ServiceMonitorGVR, map[string]interface{}{
+			"apiVersion": "monitoring.coreos.com/v1",
+			"kind":       "ServiceMonitor",
+			"metadata":   map[string]interface{}{"name": metricsWorkloadName},
+			"spec": map[string]interface{}{
+				"selector":  map[string]interface{}{"matchLabels": labels},
+				"endpoints": []interface{}{map[string]interface{}{"port": "metrics", "path": "/metrics.txt", "interval": "15s"}},
+			},
+		}},
+	}
+
+	for _, obj := range objects {
+		object := &unstructured.Unstructured{Object: obj.object}
+		if _, err := dynamicClient.Resource(obj.gvr).Namespace(namespace).Create(context.TODO(), object, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create %s %s/%s: %w", obj.gvr.Resource, namespace, object.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// ProjectNamespaces returns the namespaces assigned to the project.
+func ProjectNamespaces(client *rancher.Client, clusterID, projectID string) ([]string, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	projectName := projectID[strings.LastIndex(projectID, ":")+1:]
+	list, err := dynamicClient.Resource(namespaceGVR).List(context.TODO(), metav1.ListOptions{LabelSelector: projectIDLabel + "=" + projectName})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		namespaces = append(namespaces, item.GetName())
+	}
+	return namespaces, nil
+}
+
+// SeriesNamespaces returns the sorted namespace label values of the series the query returns.
+func SeriesNamespaces(prom *promclient.Client, query string) ([]string, error) {
+	result, err := prom.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, sample := range *result {
+		seen[string(sample.Metric["namespace"])] = true
+	}
+	namespaces := make([]string, 0, len(seen))
+	for namespace := range seen {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// VerifyProjectIsolation checks what a project Prometheus sees. IsolationMetric must come only from the
+// project's workload namespace, with the project's marker, and federated FederatedMetric series must all belong to
+// the allowed namespaces and include the workload namespace. It returns one line per problem found.
+func VerifyProjectIsolation(prom *promclient.Client, workloadNamespace, marker string, allowed []string) ([]string, error) {
+	var problems []string
+
+	result, err := prom.Query(IsolationMetric)
+	if err != nil {
+		return nil, err
+	}
+	if len(*result) == 0 {
+		problems = append(problems, fmt.Sprintf("%s has no series", IsolationMetric))
+	}
+	for _, sample := range *result {
+		if namespace := string(sample.Metric["namespace"]); namespace != workloadNamespace {
+			problems = append(problems, fmt.Sprintf("%s is visible from foreign namespace %s", IsolationMetric, namespace))
+		}
+		if got := string(sample.Metric["marker"]); got != marker {
+			problems = append(problems, fmt.Sprintf("%s has marker %s of another project", IsolationMetric, got))
+		}
+	}
+
+	federated, err := SeriesNamespaces(prom, FederatedMetric)
+	if err != nil {
+		return nil, err
+	}
+	allowedSet := map[string]bool{}
+	for _, namespace := range allowed {
+		allowedSet[namespace] = true
+	}
+	found := false
+	for _, namespace := range federated {
+		if !allowedSet[namespace] {
+			problems = append(problems, fmt.Sprintf("federated %s is visible from foreign namespace %s", FederatedMetric, namespace))
+		}
+		found = found || namespace == workloadNamespace
+	}
+	if !found {
+		problems = append(problems, fmt.Sprintf("federated %s has no series of %s", FederatedMetric, workloadNamespace))
+	}
+	return problems, nil
+}
@@ -0,0 +1,95 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+)
+
+// DefaultTimeout bounds every network check of a probe.
+const DefaultTimeout = 30 * time.Second
+
+// Response is an HTTP response seen from the probe pod.
+type Response struct {
+	StatusCode int
+	Body       []byte
+}
+
+// HTTPGet sends a GET request from the probe pod. Certificates are not verified.
+func (p *Pod) HTTPGet(url string) (*Response, error) {
+	return p.http(nil, "curl", "-sS", "-k", "--max-time", seconds(DefaultTimeout), "-w", "\n%{http_code}", url)
+}
+
+// HTTPPost sends a POST request with the given content type and body from the probe pod. Certificates are not
+// verified.
+func (p *Pod) HTTPPost(url, contentType string, body []byte) (*Response, error) {
+	return p.http(body, "curl", "-sS", "-k", "--max-time", seconds(DefaultTimeout), "-w", "\n%{http_code}",
+		"-X", "POST", "-H", "Content-Type: "+contentType, "--data-binary", "@-", url)
+}
+
+func (p *Pod) http(stdin []byte, command ...string) (*Response, error) {
+	stdout, err := p.exec(stdin, command...)
+	if err != nil {
+		return nil, err
+	}
+	index := bytes.LastIndexByte(stdout, '\n')
+	if index < 0 {
+		return nil, fmt.Errorf("unexpected curl output %q", stdout)
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(string(stdout[index+1:])))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected curl status %q", stdout[index+1:])
+	}
+	return &Response{StatusCode: code, Body: stdout[:index]}, nil
+}
+
+// DialTCP opens a TCP connection from the probe pod to host:port and closes it again.
+func (p *Pod) DialTCP(host string, port int) error {
+	_, err := p.exec(nil, "timeout", seconds(DefaultTimeout), "bash", "-c", `exec 3<>"/dev/tcp/$1/$2"`, "probe", host, strconv.Itoa(port))
+	if err != nil {
+		return fmt.Errorf("tcp dial to %s:%d failed: %w", host, port, err)
+	}
+	return nil
+}
+
+// DialUDP sends payload as one datagram from the probe pod to host:port. UDP has no handshake, so only resolution
+// and routing errors are reported; check delivery at the receiver.
+func (p *Pod) DialUDP(host string, port int, payload []byte) error {
+	_, err := p.exec(payload, "timeout", seconds(DefaultTimeout), "bash", "-c", `cat > "/dev/udp/$1/$2"`, "probe", host, strconv.Itoa(port))
+	if err != nil {
+		return fmt.Errorf("udp send to %s:%d failed: %w", host, port, err)
+	}
+	return nil
+}
+
+// exec runs a command in the probe container with stdin attached when given, and returns its stdout.
+func (p *Pod) exec(stdin []byte, command ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), DefaultTimeout+30*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	opts := kube.ExecOptions{
+		Namespace: p.Namespace,
+		Pod:       p.Name,
+		Container: containerName,
+		Command:   command,
+		Stdout:    &stdout,
+		Stderr:    &stderr,
+	}
+	if stdin != nil {
+		opts.Stdin = bytes.NewReader(stdin)
+	}
+	if err := p.kube.ExecStream(ctx, opts); err != nil {
+		return stdout.Bytes(), fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(d.Seconds()))
+}
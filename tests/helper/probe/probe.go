@@ -0,0 +1,194 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/shepherd/clients/rancher"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Label marks every probe pod, so leftovers can be found with `-l observability-e2e/probe=true`.
+	Label = "observability-e2e/probe"
+
+	shellImageSettingID      = "shell-image"
+	defaultRegistrySettingID = "system-default-registry"
+
+	defaultNamespace    = "default"
+	defaultNamePrefix   = "probe"
+	defaultReadyTimeout = 3 * time.Minute
+	defaultDeadline     = time.Hour
+	containerName       = "probe"
+)
+
+// Options configure a probe pod. Zero values select the defaults.
+type Options struct {
+	// Namespace defaults to default.
+	Namespace string
+	// NamePrefix is followed by a random suffix. It defaults to probe.
+	NamePrefix string
+	// Image defaults to the rancher/shell image of the shell-image setting, which air-gapped installs mirror
+	// already. It needs bash, sleep and curl.
+	Image string
+	// Registry is prepended to Image when the image has no registry. It defaults to the system-default-registry
+	// setting.
+	Registry string
+	// Labels are added to the probe label.
+	Labels map[string]string
+	// ReadyTimeout bounds the wait for the pod to be ready. It defaults to three minutes.
+	ReadyTimeout time.Duration
+	// Deadline is the activeDeadlineSeconds of the pod, which stops a pod leaked by an aborted run. It defaults to
+	// one hour.
+	Deadline time.Duration
+}
+
+// Pod is a running probe pod, an in-cluster vantage point for network checks.
+type Pod struct {
+	Name      string
+	Namespace string
+	Image     string
+
+	kube *kube.Client
+}
+
+// Start creates a uniquely named probe pod on the cluster and waits until it is ready. The pod is deleted when the
+// current spec ends, whether it passes or not.
+func Start(client *rancher.Client, clusterID string, opts Options) (*Pod, error) {
+	kubeClient, err := kube.NewClient(client, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	image, err := resolveImage(client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	prefix := opts.NamePrefix
+	if prefix == "" {
+		prefix = defaultNamePrefix
+	}
+	readyTimeout := opts.ReadyTimeout
+	if readyTimeout <= 0 {
+		readyTimeout = defaultReadyTimeout
+	}
+	deadline := opts.Deadline
+	if deadline <= 0 {
+		deadline = defaultDeadline
+	}
+
+	labels := map[string]string{Label: "true"}
+	for key, value := range opts.Labels {
+		labels[key] = value
+	}
+	deadlineSeconds := int64(deadline.Seconds())
+	gracePeriod := int64(0)
+	pod := &Pod{
+		Name:      namegen.AppendRandomString(prefix),
+		Namespace: namespace,
+		Image:     image,
+		kube:      kubeClient,
+	}
+	spec := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: namespace, Labels: labels},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &deadlineSeconds,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			Containers: []corev1.Container{{
+				Name:            containerName,
+				Image:           image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sleep", fmt.Sprint(deadlineSeconds)},
+				SecurityContext: restrictedSecurityContext(),
+			}},
+		},
+	}
+
+	ginkgo.DeferCleanup(func() {
+		ginkgo.By(fmt.Sprintf("Deleting probe pod %s/%s", pod.Namespace, pod.Name))
+		if err := pod.Delete(); err != nil {
+			ginkgo.GinkgoWriter.Printf("failed to delete probe pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		}
+	})
+	if _, err := kubeClient.RunPod(context.TODO(), spec, readyTimeout); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// Delete removes the probe pod. Start already deletes it when the spec ends.
+func (p *Pod) Delete() error {
+	return p.kube.DeletePod(context.TODO(), p.Namespace, p.Name)
+}
+
+// resolveImage returns the probe image with the registry prepended.
+func resolveImage(client *rancher.Client, opts Options) (string, error) {
+	image := opts.Image
+	if image == "" {
+		setting, err := client.Management.Setting.ByID(shellImageSettingID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the %s setting: %w", shellImageSettingID, err)
+		}
+		image = setting.Value
+		if image == "" {
+			image = setting.Default
+		}
+	}
+
+	registry := opts.Registry
+	if registry == "" {
+		var err error
+		if registry, err = DefaultRegistry(client); err != nil {
+			return "", err
+		}
+	}
+	return WithRegistry(image, registry), nil
+}
+
+// DefaultRegistry returns the system-default-registry setting, empty when images are pulled from their own
+// registries.
+func DefaultRegistry(client *rancher.Client) (string, error) {
+	setting, err := client.Management.Setting.ByID(defaultRegistrySettingID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the %s setting: %w", defaultRegistrySettingID, err)
+	}
+	return setting.Value, nil
+}
+
+// WithRegistry prepends registry to an image reference that does not name a registry yet, as Rancher does for
+// system images.
+func WithRegistry(image, registry string) string {
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" {
+		return image
+	}
+	if first, _, found := strings.Cut(image, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+	return registry + "/" + image
+}
+
+// restrictedSecurityContext satisfies the restricted pod security standard, so probes run in any namespace.
+func restrictedSecurityContext() *corev1.SecurityContext {
+	nonRoot := true
+	noEscalation := false
+	user := int64(1000)
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             &nonRoot,
+		RunAsUser:                &user,
+		AllowPrivilegeEscalation: &noEscalation,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+}
@@ -67,10 +67,15 @@ func (art *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error
 
 // Query executes a PromQL query and returns the result as a model.Vector
 func (c *Client) Query(query string) (*model.Vector, error) {
+	return c.QueryAt(query, time.Now())
+}
+
+// QueryAt executes a PromQL query evaluated at the given time and returns the result as a model.Vector
+func (c *Client) QueryAt(query string, ts time.Time) (*model.Vector, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	result, warnings, err := c.v1api.Query(ctx, query, time.Now())
+	result, warnings, err := c.v1api.Query(ctx, query, ts)
 	if err != nil {
 		return nil, fmt.Errorf("error querying Prometheus: %w", err)
 	}
@@ -90,3 +95,35 @@ func (c *Client) Query(query string) (*model.Vector, error) {
 
 	return &vector, nil
 }
+
+// QueryRange executes a PromQL range query and returns the result as a model.Matrix
+func (c *Client) QueryRange(query string, start, end time.Time, step time.Duration) (model.Matrix, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, warnings, err := c.v1api.QueryRange(ctx, query, v1.Range{Start: start, End: end, Step: step})
+	if err != nil {
+		return nil, fmt.Errorf("error querying Prometheus: %w", err)
+	}
+
+	if len(warnings) > 0 {
+		e2e.Logf("Warnings: %v\n", warnings)
+	}
+
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type: %s", result.Type().String())
+	}
+
+	return matrix, nil
+}
+
+// ServiceProxyURL returns the URL of an in-cluster Prometheus service reached through the Rancher service proxy.
+func ServiceProxyURL(host, clusterID, namespace, service string, port int) string {
+	return fmt.Sprintf("https://%s/k8s/clusters/%s/api/v1/namespaces/%s/services/http:%s:%d/proxy", host, clusterID, namespace, service, port)
+}
+
+// RancherMonitoringURL returns the proxy URL of the Prometheus deployed by rancher-monitoring on the given cluster.
+func RancherMonitoringURL(host, clusterID string) string {
+	return ServiceProxyURL(host, clusterID, "cattle-monitoring-system", "rancher-monitoring-prometheus", 9090)
+}
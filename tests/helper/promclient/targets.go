@@ -0,0 +1,160 @@
+package promclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Monitor kinds as they appear in the scrape pools the prometheus-operator generates.
+const (
+	ServiceMonitorKind = "serviceMonitor"
+	PodMonitorKind     = "podMonitor"
+)
+
+// Monitor identifies a ServiceMonitor or PodMonitor.
+type Monitor struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// ServiceMonitor returns the Monitor of a ServiceMonitor.
+func ServiceMonitor(namespace, name string) Monitor {
+	return Monitor{Kind: ServiceMonitorKind, Namespace: namespace, Name: name}
+}
+
+// PodMonitor returns the Monitor of a PodMonitor.
+func PodMonitor(namespace, name string) Monitor {
+	return Monitor{Kind: PodMonitorKind, Namespace: namespace, Name: name}
+}
+
+func (m Monitor) String() string {
+	return fmt.Sprintf("%s/%s/%s", m.Kind, m.Namespace, m.Name)
+}
+
+// MonitorFromScrapePool returns the monitor a scrape pool was generated from. The operator names the pools
+// <kind>/<namespace>/<name>/<endpoint index>; pools of other scrape configs are reported as not found.
+func MonitorFromScrapePool(pool string) (Monitor, bool) {
+	parts := strings.Split(pool, "/")
+	if len(parts) != 4 || (parts[0] != ServiceMonitorKind && parts[0] != PodMonitorKind) {
+		return Monitor{}, false
+	}
+	return Monitor{Kind: parts[0], Namespace: parts[1], Name: parts[2]}, true
+}
+
+// Targets returns the active scrape targets of Prometheus.
+func (c *Client) Targets() ([]v1.ActiveTarget, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.v1api.Targets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing Prometheus targets: %w", err)
+	}
+	return result.Active, nil
+}
+
+// TargetsByMonitor groups the active scrape targets by the ServiceMonitor or PodMonitor that produced them.
+func (c *Client) TargetsByMonitor() (map[Monitor][]v1.ActiveTarget, error) {
+	targets, err := c.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	byMonitor := map[Monitor][]v1.ActiveTarget{}
+	for _, target := range targets {
+		if monitor, ok := MonitorFromScrapePool(target.ScrapePool); ok {
+			byMonitor[monitor] = append(byMonitor[monitor], target)
+		}
+	}
+	return byMonitor, nil
+}
+
+// CheckMonitors verifies that each monitor produced at least one target and that every target is up. It returns
+// one line per problem found, including the last scrape error of failing targets; an empty result means all
+// monitors are scraped.
+func (c *Client) CheckMonitors(monitors ...Monitor) ([]string, error) {
+	byMonitor, err := c.TargetsByMonitor()
+	if err != nil {
+		return nil, err
+	}
+	return checkMonitors(byMonitor, monitors), nil
+}
+
+// CheckNamespaceMonitors checks the monitors like CheckMonitors, and reports every other ServiceMonitor and PodMonitor
+// of the namespaces that has no active target, so a monitor that selects nothing is found without naming it.
+func (c *Client) CheckNamespaceMonitors(ctx context.Context, kubeClient *kube.Client, namespaces []string, monitors ...Monitor) ([]string, error) {
+	listed, err := ListMonitors(ctx, kubeClient, namespaces...)
+	if err != nil {
+		return nil, err
+	}
+	byMonitor, err := c.TargetsByMonitor()
+	if err != nil {
+		return nil, err
+	}
+
+	problems := checkMonitors(byMonitor, monitors)
+	named := map[Monitor]bool{}
+	for _, monitor := range monitors {
+		named[monitor] = true
+	}
+	for _, monitor := range listed {
+		if !named[monitor] && len(byMonitor[monitor]) == 0 {
+			problems = append(problems, fmt.Sprintf("%s has no scrape targets", monitor))
+		}
+	}
+	return problems, nil
+}
+
+// ListMonitors returns the ServiceMonitors and then the PodMonitors of each namespace, sorted by name.
+func ListMonitors(ctx context.Context, kubeClient *kube.Client, namespaces ...string) ([]Monitor, error) {
+	var monitors []Monitor
+	for _, namespace := range namespaces {
+		for _, resource := range []struct {
+			kind string
+			gvr  schema.GroupVersionResource
+		}{
+			{kind: ServiceMonitorKind, gvr: kube.ServiceMonitorsGVR},
+			{kind: PodMonitorKind, gvr: kube.PodMonitorsGVR},
+		} {
+			items, err := kubeClient.List(ctx, resource.gvr, namespace, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the %ss of namespace %s: %w", resource.kind, namespace, err)
+			}
+			names := make([]string, 0, len(items))
+			for _, item := range items {
+				names = append(names, item.GetName())
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				monitors = append(monitors, Monitor{Kind: resource.kind, Namespace: namespace, Name: name})
+			}
+		}
+	}
+	return monitors, nil
+}
+
+func checkMonitors(byMonitor map[Monitor][]v1.ActiveTarget, monitors []Monitor) []string {
+	var problems []string
+	for _, monitor := range monitors {
+		targets := byMonitor[monitor]
+		if len(targets) == 0 {
+			problems = append(problems, fmt.Sprintf("%s has no scrape targets", monitor))
+			continue
+		}
+		for _, target := range targets {
+			if target.Health != v1.HealthGood {
+				problems = append(problems, fmt.Sprintf("%s target %s is %s: %s", monitor, target.ScrapeURL, target.Health, target.LastError))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,158 @@
+package promclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+// target is an active target of the fake targets API.
+type target struct {
+	pool   string
+	health string
+}
+
+// fakeTargets returns a client whose Prometheus has the given active targets.
+func fakeTargets(t *testing.T, targets ...target) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		active := []map[string]interface{}{}
+		for i, target := range targets {
+			active = append(active, map[string]interface{}{
+				"scrapePool": target.pool,
+				"scrapeUrl":  fmt.Sprintf("http://10.0.0.%d:8080/metrics", i+1),
+				"health":     target.health,
+				"lastError":  map[bool]string{true: "", false: "connection refused"}[target.health == "up"],
+				"labels":     map[string]string{},
+			})
+		}
+		data, _ := json.Marshal(map[string]interface{}{"activeTargets": active, "droppedTargets": []interface{}{}})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":%s}`, data)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// monitorObject returns a ServiceMonitor or PodMonitor object.
+func monitorObject(kind, namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+	}}
+}
+
+// fakeKube returns a kube client of a cluster with the given monitors.
+func fakeKube(objects ...runtime.Object) *kube.Client {
+	listKinds := map[schema.GroupVersionResource]string{
+		kube.ServiceMonitorsGVR: "ServiceMonitorList",
+		kube.PodMonitorsGVR:     "PodMonitorList",
+	}
+	return &kube.Client{Dynamic: fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)}
+}
+
+func TestMonitorFromScrapePool(t *testing.T) {
+	tests := []struct {
+		pool string
+		want Monitor
+		ok   bool
+	}{
+		{pool: "serviceMonitor/cattle-resources-system/rancher-backup/0", want: ServiceMonitor("cattle-resources-system", "rancher-backup"), ok: true},
+		{pool: "podMonitor/my-namespace/my-pods/1", want: PodMonitor("my-namespace", "my-pods"), ok: true},
+		{pool: "probe/my-namespace/my-probe/0"},
+		{pool: "kubernetes-pods"},
+		{pool: "serviceMonitor/my-namespace/my-monitor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pool, func(t *testing.T) {
+			got, ok := MonitorFromScrapePool(tt.pool)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %v, %t, want %v, %t", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckMonitors(t *testing.T) {
+	prom := fakeTargets(t,
+		target{pool: "serviceMonitor/cattle-resources-system/rancher-backup/0", health: "up"},
+		target{pool: "serviceMonitor/cattle-monitoring-system/rancher-monitoring-kubelet/0", health: "up"},
+		target{pool: "serviceMonitor/cattle-monitoring-system/rancher-monitoring-kubelet/1", health: "down"},
+	)
+	problems, err := prom.CheckMonitors(
+		ServiceMonitor("cattle-resources-system", "rancher-backup"),
+		ServiceMonitor("cattle-monitoring-system", "rancher-monitoring-kubelet"),
+		PodMonitor("cattle-resources-system", "missing"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"serviceMonitor/cattle-monitoring-system/rancher-monitoring-kubelet target http://10.0.0.3:8080/metrics is down: connection refused",
+		"podMonitor/cattle-resources-system/missing has no scrape targets",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got %q, want %q", problems, want)
+	}
+}
+
+func TestCheckNamespaceMonitors(t *testing.T) {
+	prom := fakeTargets(t,
+		target{pool: "serviceMonitor/cattle-resources-system/rancher-backup/0", health: "up"},
+		target{pool: "serviceMonitor/cattle-resources-system/scraped-but-down/0", health: "down"},
+		target{pool: "podMonitor/cattle-resources-system/pods/0", health: "up"},
+	)
+	kubeClient := fakeKube(
+		monitorObject("ServiceMonitor", "cattle-resources-system", "rancher-backup"),
+		monitorObject("ServiceMonitor", "cattle-resources-system", "scraped-but-down"),
+		monitorObject("ServiceMonitor", "cattle-resources-system", "selects-nothing"),
+		monitorObject("PodMonitor", "cattle-resources-system", "pods"),
+		monitorObject("PodMonitor", "cattle-resources-system", "no-pods"),
+		monitorObject("ServiceMonitor", "other-namespace", "ignored"),
+	)
+
+	monitors, err := ListMonitors(context.Background(), kubeClient, "cattle-resources-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMonitors := []Monitor{
+		ServiceMonitor("cattle-resources-system", "rancher-backup"),
+		ServiceMonitor("cattle-resources-system", "scraped-but-down"),
+		ServiceMonitor("cattle-resources-system", "selects-nothing"),
+		PodMonitor("cattle-resources-system", "no-pods"),
+		PodMonitor("cattle-resources-system", "pods"),
+	}
+	if !reflect.DeepEqual(monitors, wantMonitors) {
+		t.Errorf("got monitors %v, want %v", monitors, wantMonitors)
+	}
+
+	problems, err := prom.CheckNamespaceMonitors(context.Background(), kubeClient, []string{"cattle-resources-system"},
+		ServiceMonitor("cattle-resources-system", "rancher-backup"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unlisted monitors are only reported without targets, the health of their targets is not checked.
+	want := []string{
+		"serviceMonitor/cattle-resources-system/selects-nothing has no scrape targets",
+		"podMonitor/cattle-resources-system/no-pods has no scrape targets",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got %q, want %q", problems, want)
+	}
+}
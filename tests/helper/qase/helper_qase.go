@@ -14,12 +14,22 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/onsi/ginkgo/v2/types"
 	qase "github.com/rancher-sandbox/qase-ginkgo"
+	"github.com/rancher/observability-e2e/tests/helper/reporting"
 	"github.com/sirupsen/logrus"
 )
 
@@ -41,23 +51,224 @@ func init() {
 func main() {
 	// Define the allowed options
 	createRun := flag.Bool("create", false, "create a new Qase run")
+	createFilteredRun := flag.Bool("create-filtered", false, "create a new Qase run with only the cases of the specs of -suite matching -label-filter")
 	deleteRun := flag.Bool("delete", false, "delete a Qase run, QASE_RUN_ID should be set")
 	publishRun := flag.Bool("publish", false, "publish a Qase report, QASE_RUN_ID should be set, it also depends on QASE_REPORT and QASE_RUN_COMPLETE")
+	attach := flag.Bool("attach", false, "upload the files given as arguments to a Qase run, or to one of its results with -result, QASE_RUN_ID should be set")
+	summary := flag.Bool("summary", false, "print a summary of a Qase run, QASE_RUN_ID should be set")
+	replay := flag.String("replay", "", "publish the unpublished results of a result journal, QASE_RUN_ID replaces the run of the results when set")
+
+	// Define the parameters of the options
+	labelFilter := flag.String("label-filter", os.Getenv("TEST_LABEL_FILTER"), "Ginkgo label filter of the specs for -create-filtered, the suites run LEVEL0 when it is empty")
+	suite := flag.String("suite", "./tests/e2e", "package of the Ginkgo suite for -create-filtered")
+	resultHash := flag.String("result", "", "hash of the result to attach the files to with -attach")
 
 	// Parse the arguments
 	flag.Parse()
+	ctx := context.Background()
+	client := reporting.NewClient("", os.Getenv("QASE_API_TOKEN"), os.Getenv("QASE_PROJECT_CODE"))
 
 	// Only one option at a time is allowed
 	if *createRun {
 		id := qase.CreateRun()
 		fmt.Printf("%d", id)
+	} else if *createFilteredRun {
+		ids, err := collectCaseIDs(*suite, *labelFilter)
+		if err != nil {
+			logrus.Fatalf("Error on collecting the Qase cases of %s: %v", *suite, err)
+		}
+		id, err := createRunWithCases(ctx, client, ids)
+		if err != nil {
+			logrus.Fatalf("Error on creating run: %v", err)
+		}
+		fmt.Printf("%d", id)
 	} else if *deleteRun {
 		qase.DeleteRun()
 		fmt.Printf("Qase run id %d deleted", runID)
 	} else if *publishRun {
 		qase.FinalizeResults()
 		fmt.Printf("Qase finalization for run id %d has been done", runID)
+	} else if *attach {
+		if runID <= 0 {
+			logrus.Fatal("QASE_RUN_ID should be set")
+		}
+		if err := attachFiles(ctx, os.Stdout, client, int64(runID), *resultHash, flag.Args()); err != nil {
+			logrus.Fatalf("Error on attaching files: %v", err)
+		}
+	} else if *summary {
+		if runID <= 0 {
+			logrus.Fatal("QASE_RUN_ID should be set")
+		}
+		if err := printSummary(ctx, os.Stdout, client, int64(runID)); err != nil {
+			logrus.Fatalf("Error on summarizing run: %v", err)
+		}
+	} else if *replay != "" {
+		if err := replayJournal(ctx, os.Stdout, client, *replay, int64(runID)); err != nil {
+			logrus.Fatalf("Error on replaying %s: %v", *replay, err)
+		}
 	} else {
 		fmt.Printf("Nothing to do!")
 	}
 }
+
+// collectCaseIDs dry-runs the suite with the label filter and returns the Qase cases of the specs it would run.
+// The suites read their label filter from TEST_LABEL_FILTER.
+func collectCaseIDs(suite, labelFilter string) ([]int64, error) {
+	dir, err := os.MkdirTemp("", "qase-dry-run")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report.json")
+
+	cmd := exec.Command("go", "test", suite, "-count=1", "-args", "-ginkgo.dry-run", "-ginkgo.json-report="+reportPath)
+	cmd.Env = append(os.Environ(), "TEST_LABEL_FILTER="+labelFilter)
+	// Keep stdout for the run ID, the caller reads it
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to dry-run the suite: %w", err)
+	}
+	return caseIDsFromReport(reportPath)
+}
+
+// caseIDsFromReport returns the Qase cases of the specs a Ginkgo JSON report ran. In a dry run, the specs the label
+// filter selects pass and all others are skipped.
+func caseIDsFromReport(path string) ([]int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var reports []types.Report
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return nil, fmt.Errorf("failed to decode Ginkgo report %s: %w", path, err)
+	}
+
+	seen := map[int64]bool{}
+	for _, report := range reports {
+		for _, spec := range report.SpecReports {
+			if spec.LeafNodeType != types.NodeTypeIt || spec.State != types.SpecStatePassed {
+				continue
+			}
+			for _, id := range reporting.CaseIDs(spec) {
+				seen[id] = true
+			}
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("no spec with a Qase case matches the label filter")
+	}
+
+	ids := make([]int64, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// createRunWithCases creates a run with the given cases, named and described as qase.CreateRun does with
+// QASE_RUN_NAME, QASE_RUN_DESCRIPTION and QASE_ENVIRONMENT_ID.
+func createRunWithCases(ctx context.Context, client *reporting.Client, ids []int64) (int64, error) {
+	run := reporting.NewRun{
+		Title:       os.Getenv("QASE_RUN_NAME"),
+		Description: os.Getenv("QASE_RUN_DESCRIPTION"),
+		Cases:       ids,
+		IsAutotest:  true,
+	}
+	if run.Title == "" {
+		run.Title = "Automated run " + time.Now().Format(time.RFC3339)
+	}
+	if run.Description == "" {
+		run.Description = "Ginkgo automated run"
+	}
+	if envStrID := os.Getenv("QASE_ENVIRONMENT_ID"); envStrID != "" {
+		envID, err := strconv.ParseInt(envStrID, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid QASE_ENVIRONMENT_ID %q: %w", envStrID, err)
+		}
+		run.EnvironmentID = envID
+	}
+	return client.CreateRun(ctx, run)
+}
+
+// attachFiles uploads the files and attaches them to the result with the given hash. Qase runs have no
+// attachments, so without a result the links to the files are appended to the description of the run.
+func attachFiles(ctx context.Context, w io.Writer, client *reporting.Client, runID int64, resultHash string, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no files to attach")
+	}
+
+	var attachments []reporting.Attachment
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		attachment, err := client.UploadAttachment(ctx, filepath.Base(file), f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Uploaded %s: %s\n", file, attachment.URL)
+		attachments = append(attachments, attachment)
+	}
+
+	if resultHash != "" {
+		hashes := make([]string, 0, len(attachments))
+		for _, attachment := range attachments {
+			hashes = append(hashes, attachment.Hash)
+		}
+		return client.AddResultAttachments(ctx, runID, resultHash, hashes)
+	}
+
+	run, err := client.GetRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	var description strings.Builder
+	description.WriteString(run.Description)
+	description.WriteString("\n\nAttachments:")
+	for _, attachment := range attachments {
+		fmt.Fprintf(&description, "\n- [%s](%s)", attachment.Filename, attachment.URL)
+	}
+	return client.UpdateRunDescription(ctx, runID, strings.TrimSpace(description.String()))
+}
+
+// printSummary prints the status of a run, its cases counted by status and its failed cases.
+func printSummary(ctx context.Context, w io.Writer, client *reporting.Client, runID int64) error {
+	run, err := client.GetRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	failed, err := client.ListResults(ctx, runID, reporting.Failed)
+	if err != nil {
+		return err
+	}
+
+	stats := run.Stats
+	fmt.Fprintf(w, "Run %d: %s (%s)\n", run.ID, run.Title, run.StatusText)
+	fmt.Fprintf(w, "Cases: %d total, %d passed, %d failed, %d blocked, %d skipped, %d invalid, %d untested\n",
+		stats.Total, stats.Passed, stats.Failed, stats.Blocked, stats.Skipped, stats.Invalid, stats.Untested)
+	fmt.Fprintf(w, "Time spent: %s\n", (time.Duration(run.TimeSpent) * time.Millisecond).Round(time.Second))
+
+	seen := map[int64]bool{}
+	var failedIDs []string
+	for _, result := range failed {
+		if !seen[result.CaseID] {
+			seen[result.CaseID] = true
+			failedIDs = append(failedIDs, strconv.FormatInt(result.CaseID, 10))
+		}
+	}
+	if len(failedIDs) > 0 {
+		fmt.Fprintf(w, "Failed cases: %s\n", strings.Join(failedIDs, ", "))
+	}
+	return nil
+}
+
+// replayJournal publishes the results of the journal at path that are not published yet.
+func replayJournal(ctx context.Context, w io.Writer, client *reporting.Client, path string, runID int64) error {
+	published, skipped, err := reporting.Replay(ctx, client, path, runID)
+	fmt.Fprintf(w, "Published %d results of %s, %d were already published\n", published, path, skipped)
+	return err
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rancher/observability-e2e/tests/helper/reporting"
+)
+
+const (
+	fakeToken   = "fake-token"
+	fakeProject = "OBS"
+)
+
+// fakeQase serves the part of the Qase API the helper uses, from memory.
+type fakeQase struct {
+	mu          sync.Mutex
+	runs        map[int64]*reporting.Run
+	created     []reporting.NewRun
+	results     map[int64][]reporting.RunResult
+	attachments map[string][]string
+	uploads     map[string]string
+}
+
+// newFakeQase starts a fake Qase API and returns a client of it.
+func newFakeQase(t *testing.T) (*fakeQase, *reporting.Client) {
+	f := &fakeQase{
+		runs:        map[int64]*reporting.Run{},
+		results:     map[int64][]reporting.RunResult{},
+		attachments: map[string][]string{},
+		uploads:     map[string]string{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, reporting.NewClient(server.URL, fakeToken, fakeProject)
+}
+
+func (f *fakeQase) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Token") != fakeToken {
+		reply(w, http.StatusUnauthorized, nil, "Unauthorized")
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[1] != fakeProject {
+		reply(w, http.StatusNotFound, nil, "Project not found")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && parts[0] == "run" && len(parts) == 2:
+		var run reporting.NewRun
+		if err := json.NewDecoder(r.Body).Decode(&run); err != nil {
+			reply(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		f.created = append(f.created, run)
+		id := int64(len(f.runs) + 1)
+		f.runs[id] = &reporting.Run{ID: id, Title: run.Title, Description: run.Description, StatusText: "active"}
+		reply(w, http.StatusOK, map[string]int64{"id": id}, "")
+
+	case parts[0] == "run" && len(parts) == 3:
+		run := f.runs[parseID(parts[2])]
+		if run == nil {
+			reply(w, http.StatusNotFound, nil, "Run not found")
+			return
+		}
+		if r.Method == http.MethodPatch {
+			var update struct {
+				Description string `json:"description"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				reply(w, http.StatusBadRequest, nil, err.Error())
+				return
+			}
+			run.Description = update.Description
+		}
+		reply(w, http.StatusOK, run, "")
+
+	case r.Method == http.MethodPost && parts[0] == "result" && len(parts) == 3:
+		var result reporting.Result
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			reply(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		runID := parseID(parts[2])
+		hash := fmt.Sprintf("result-%d-%d", runID, len(f.results[runID])+1)
+		f.results[runID] = append(f.results[runID], reporting.RunResult{Hash: hash, CaseID: result.CaseID, Status: result.Status, Comment: result.Comment})
+		f.attachments[hash] = result.Attachments
+		reply(w, http.StatusOK, map[string]any{"case_id": result.CaseID, "hash": hash}, "")
+
+	case r.Method == http.MethodGet && parts[0] == "result" && len(parts) == 2:
+		query := r.URL.Query()
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		var matching []reporting.RunResult
+		for _, result := range f.results[parseID(query.Get("run"))] {
+			if status := query.Get("status"); status == "" || string(result.Status) == status {
+				matching = append(matching, result)
+			}
+		}
+		page := matching[min(offset, len(matching)):min(offset+limit, len(matching))]
+		reply(w, http.StatusOK, map[string]any{"filtered": len(matching), "entities": page}, "")
+
+	case r.Method == http.MethodPatch && parts[0] == "result" && len(parts) == 4:
+		var update struct {
+			Attachments []string `json:"attachments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			reply(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		f.attachments[parts[3]] = append(f.attachments[parts[3]], update.Attachments...)
+		reply(w, http.StatusOK, map[string]string{"hash": parts[3]}, "")
+
+	case r.Method == http.MethodPost && parts[0] == "attachment":
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			reply(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		content, _ := io.ReadAll(file)
+		hash := fmt.Sprintf("attachment-%d", len(f.uploads)+1)
+		f.uploads[hash] = string(content)
+		reply(w, http.StatusOK, []reporting.Attachment{{Hash: hash, Filename: header.Filename, URL: "https://qase.test/" + hash}}, "")
+
+	default:
+		reply(w, http.StatusNotFound, nil, "Not found")
+	}
+}
+
+// reply writes a response in the envelope of the Qase API.
+func reply(w http.ResponseWriter, code int, result any, errorMessage string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"status": errorMessage == "", "result": result, "errorMessage": errorMessage})
+}
+
+func parseID(s string) int64 {
+	id, _ := strconv.ParseInt(s, 10, 64)
+	return id
+}
+
+func TestCaseIDsFromReport(t *testing.T) {
+	reports := []types.Report{{
+		SpecReports: types.SpecReports{
+			{
+				LeafNodeType:            types.NodeTypeIt,
+				ContainerHierarchyTexts: []string{"[QASE-4,3] Alerts"},
+				LeafNodeText:            "[QASE-12] routes alerts",
+				State:                   types.SpecStatePassed,
+			},
+			{
+				LeafNodeType:   types.NodeTypeIt,
+				LeafNodeText:   "upgrades the chart",
+				LeafNodeLabels: []string{"LEVEL1", "QASE-7"},
+				State:          types.SpecStatePassed,
+			},
+			{
+				LeafNodeType: types.NodeTypeIt,
+				LeafNodeText: "[QASE-99] filtered out",
+				State:        types.SpecStateSkipped,
+			},
+			{
+				LeafNodeType: types.NodeTypeReportAfterEach,
+				LeafNodeText: "[QASE-98] not a spec",
+				State:        types.SpecStatePassed,
+			},
+		},
+	}}
+	path := filepath.Join(t.TempDir(), "report.json")
+	data, err := json.Marshal(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := caseIDsFromReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{3, 4, 7, 12}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got case IDs %v, want %v", ids, want)
+	}
+}
+
+func TestCaseIDsFromReportWithoutCases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(`[{"SpecReports":[]}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := caseIDsFromReport(path); err == nil {
+		t.Error("expected an error for a report without Qase cases")
+	}
+}
+
+func TestCreateRunWithCases(t *testing.T) {
+	fake, client := newFakeQase(t)
+	t.Setenv("QASE_RUN_NAME", "Rancher v2.11 LEVEL1")
+	t.Setenv("QASE_RUN_DESCRIPTION", "")
+	t.Setenv("QASE_ENVIRONMENT_ID", "5")
+
+	id, err := createRunWithCases(context.Background(), client, []int64{3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Errorf("got run ID %d, want 1", id)
+	}
+	want := reporting.NewRun{
+		Title:         "Rancher v2.11 LEVEL1",
+		Description:   "Ginkgo automated run",
+		EnvironmentID: 5,
+		Cases:         []int64{3, 4},
+		IsAutotest:    true,
+	}
+	if len(fake.created) != 1 || !reflect.DeepEqual(fake.created[0], want) {
+		t.Errorf("got created runs %+v, want %+v", fake.created, want)
+	}
+}
+
+func TestAttachFilesToResult(t *testing.T) {
+	fake, client := newFakeQase(t)
+	ctx := context.Background()
+	hash, err := client.CreateResult(ctx, 1, reporting.Result{CaseID: 3, Status: reporting.Failed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "junit.xml")
+	if err := os.WriteFile(file, []byte("<testsuites/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := attachFiles(ctx, &out, client, 1, hash, []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.attachments[hash]; !reflect.DeepEqual(got, []string{"attachment-1"}) {
+		t.Errorf("got result attachments %v, want [attachment-1]", got)
+	}
+	if got := fake.uploads["attachment-1"]; got != "<testsuites/>" {
+		t.Errorf("got uploaded content %q", got)
+	}
+	if !strings.Contains(out.String(), "https://qase.test/attachment-1") {
+		t.Errorf("output does not link the attachment: %q", out.String())
+	}
+}
+
+func TestAttachFilesToRun(t *testing.T) {
+	fake, client := newFakeQase(t)
+	ctx := context.Background()
+	runID, err := client.CreateRun(ctx, reporting.NewRun{Title: "run", Description: "https://github.com/rancher/observability-e2e/actions/runs/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"diagnostics.tar.gz", "backup-inspection.json"} {
+		files = append(files, filepath.Join(dir, name))
+		if err := os.WriteFile(files[len(files)-1], []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := attachFiles(ctx, io.Discard, client, runID, "", files); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://github.com/rancher/observability-e2e/actions/runs/1\n\nAttachments:\n" +
+		"- [diagnostics.tar.gz](https://qase.test/attachment-1)\n" +
+		"- [backup-inspection.json](https://qase.test/attachment-2)"
+	if got := fake.runs[runID].Description; got != want {
+		t.Errorf("got run description %q, want %q", got, want)
+	}
+}
+
+func TestAttachMissingFile(t *testing.T) {
+	_, client := newFakeQase(t)
+	err := attachFiles(context.Background(), io.Discard, client, 1, "", []string{filepath.Join(t.TempDir(), "missing")})
+	if err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	fake, client := newFakeQase(t)
+	ctx := context.Background()
+	runID, err := client.CreateRun(ctx, reporting.NewRun{Title: "nightly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.runs[runID].TimeSpent = 90_000
+	fake.runs[runID].Stats = reporting.RunStats{Total: 4, Passed: 1, Failed: 2, Skipped: 1}
+	for _, result := range []reporting.Result{
+		{CaseID: 12, Status: reporting.Failed},
+		{CaseID: 3, Status: reporting.Passed},
+		{CaseID: 7, Status: reporting.Failed},
+		{CaseID: 12, Status: reporting.Failed},
+	} {
+		if _, err := client.CreateResult(ctx, runID, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := printSummary(ctx, &out, client, runID); err != nil {
+		t.Fatal(err)
+	}
+	want := "Run 1: nightly (active)\n" +
+		"Cases: 4 total, 1 passed, 2 failed, 0 blocked, 1 skipped, 0 invalid, 0 untested\n" +
+		"Time spent: 1m30s\n" +
+		"Failed cases: 12, 7\n"
+	if out.String() != want {
+		t.Errorf("got summary\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	_, client := newFakeQase(t)
+	client.Token = "wrong"
+	_, err := client.GetRun(context.Background(), 1)
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("got error %v, want Unauthorized", err)
+	}
+}
+
+func TestReplayJournal(t *testing.T) {
+	fake, client := newFakeQase(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "diagnostics.tar.gz")
+	if err := os.WriteFile(bundle, []byte("bundle"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "results.jsonl")
+	journal := reporting.NewJournal(path)
+	for _, entry := range []reporting.JournalEntry{
+		// Two cases of a failed spec, written while offline
+		{Key: "a", Spec: "[QASE-3,4] logging", Result: reporting.Result{CaseID: 3, Status: reporting.Failed}, Files: []string{bundle}, Logs: map[string]string{"ginkgo-writer.log": "output"}},
+		{Key: "b", Spec: "[QASE-3,4] logging", Result: reporting.Result{CaseID: 4, Status: reporting.Failed}, Files: []string{bundle}, Logs: map[string]string{"ginkgo-writer.log": "output"}},
+		// A result published by the suite
+		{Key: "c", RunID: 9, Spec: "[QASE-7] alerts", Result: reporting.Result{CaseID: 7, Status: reporting.Passed}},
+		{Key: "c", RunID: 9, Spec: "[QASE-7] alerts", Result: reporting.Result{CaseID: 7, Status: reporting.Passed}, Hash: "published"},
+	} {
+		if err := journal.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := replayJournal(ctx, &out, client, path, 5); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("Published 2 results of %s, 1 were already published\n", path); out.String() != want {
+		t.Errorf("got output %q, want %q", out.String(), want)
+	}
+	if got := len(fake.results[5]); got != 2 {
+		t.Fatalf("got %d results in run 5, want 2", got)
+	}
+	if len(fake.uploads) != 2 {
+		t.Errorf("got %d uploads, want the bundle and the log once each", len(fake.uploads))
+	}
+	for _, result := range fake.results[5] {
+		if got := fake.attachments[result.Hash]; !reflect.DeepEqual(got, []string{"attachment-1", "attachment-2"}) {
+			t.Errorf("got attachments %v of case %d", got, result.CaseID)
+		}
+	}
+
+	// Replaying again publishes nothing twice
+	out.Reset()
+	if err := replayJournal(ctx, &out, client, path, 5); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("Published 0 results of %s, 3 were already published\n", path); out.String() != want {
+		t.Errorf("got output %q, want %q", out.String(), want)
+	}
+	if got := len(fake.results[5]); got != 2 {
+		t.Errorf("got %d results in run 5 after the second replay, want 2", got)
+	}
+}
+
+func TestReplayJournalWithoutRun(t *testing.T) {
+	fake, client := newFakeQase(t)
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	entry := reporting.JournalEntry{Key: "a", Spec: "[QASE-3] logging", Result: reporting.Result{CaseID: 3, Status: reporting.Passed}}
+	if err := reporting.NewJournal(path).Append(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replayJournal(context.Background(), io.Discard, client, path, 0); err == nil {
+		t.Error("expected an error for results without a run")
+	}
+	if len(fake.results) != 0 {
+		t.Errorf("got results %v, want none", fake.results)
+	}
+}
+
+func TestReplayJournalUnreachable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	journal := reporting.NewJournal(path)
+	entry := reporting.JournalEntry{Key: "a", RunID: 5, Spec: "[QASE-3] logging", Result: reporting.Result{CaseID: 3, Status: reporting.Passed}}
+	if err := journal.Append(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	client := reporting.NewClient(server.URL, fakeToken, fakeProject)
+	if err := replayJournal(context.Background(), io.Discard, client, path, 0); err == nil {
+		t.Error("expected an error for an unreachable Qase")
+	}
+	entries, err := journal.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Hash != "" {
+		t.Errorf("got entries %+v, want the result unpublished", entries)
+	}
+}
@@ -0,0 +1,203 @@
+package reporting
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/shepherd/clients/rancher"
+	"github.com/rancher/shepherd/extensions/clusters"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// diagnosticsTimeout bounds the collection of a bundle, so an unreachable cluster cannot stall the suite.
+	diagnosticsTimeout = 2 * time.Minute
+	// diagnosticsLogLines is the number of log lines kept for each container.
+	diagnosticsLogLines = int64(500)
+)
+
+// CollectDiagnostics writes a diagnostics bundle of the namespaces when the running spec failed, and attaches it to
+// the result of the spec with Attach. The bundle holds the installed charts, and the pods, events and last log lines
+// of every container of each namespace of the cluster, the local cluster when cluster is nil. Suites call it from a
+// JustAfterEach node, before the cleanup of the spec deletes what failed:
+//
+//	var _ = JustAfterEach(func() {
+//		reporting.CollectDiagnostics(client, cluster, charts.RancherMonitoringNamespace)
+//	})
+//
+// What cannot be read is written to the bundle instead of failing the spec.
+func CollectDiagnostics(client *rancher.Client, cluster *clusters.ClusterMeta, namespaces ...string) {
+	report := ginkgo.CurrentSpecReport()
+	if !report.Failed() || client == nil {
+		return
+	}
+
+	clusterID := kube.LocalCluster
+	if cluster != nil {
+		clusterID = cluster.ID
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+	defer cancel()
+	files := diagnostics(ctx, client, clusterID, namespaces)
+
+	key := sha256.Sum256([]byte(report.StartTime.Format(time.RFC3339Nano) + "\x00" + report.FullText()))
+	path := filepath.Join(resultsDir(), "diagnostics", fmt.Sprintf("diagnostics-%d-%s.tar.gz", ginkgo.GinkgoRandomSeed(), hex.EncodeToString(key[:6])))
+	if err := writeBundle(path, files); err != nil {
+		ginkgo.GinkgoWriter.Printf("Failed to write the diagnostics bundle: %v\n", err)
+		return
+	}
+	ginkgo.GinkgoWriter.Printf("Diagnostics bundle written to %s\n", path)
+	Attach(path)
+}
+
+// diagnostics returns the files of a bundle by name.
+func diagnostics(ctx context.Context, client *rancher.Client, clusterID string, namespaces []string) map[string]string {
+	files := map[string]string{}
+	kubeClient, err := kube.NewClient(client, clusterID)
+	if err != nil {
+		files["errors.txt"] = err.Error()
+		return files
+	}
+
+	var charts strings.Builder
+	if installed, err := installedCharts(client, clusterID); err != nil {
+		fmt.Fprintf(&charts, "failed to list the Apps: %v\n", err)
+	} else {
+		for _, chart := range installed {
+			fmt.Fprintf(&charts, "%s/%s\t%s\t%s\n", chart.Namespace, chart.Name, chart.Chart, chart.Version)
+		}
+	}
+	files["charts.txt"] = charts.String()
+
+	seen := map[string]bool{}
+	for _, namespace := range namespaces {
+		if namespace == "" || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		for name, content := range namespaceDiagnostics(ctx, kubeClient, namespace) {
+			files[namespace+"/"+name] = content
+		}
+	}
+	return files
+}
+
+// namespaceDiagnostics returns the pods, events and container logs of a namespace by file name.
+func namespaceDiagnostics(ctx context.Context, kubeClient *kube.Client, namespace string) map[string]string {
+	files := map[string]string{}
+
+	var events strings.Builder
+	if list, err := kubeClient.Clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		fmt.Fprintf(&events, "failed to list the events: %v\n", err)
+	} else {
+		items := list.Items
+		sort.SliceStable(items, func(i, j int) bool { return eventTime(items[i]).Before(eventTime(items[j])) })
+		for _, event := range items {
+			fmt.Fprintf(&events, "%s\t%s\t%s\t%s/%s\t%s\n", eventTime(event).Format(time.RFC3339), event.Type, event.Reason,
+				event.InvolvedObject.Kind, event.InvolvedObject.Name, strings.TrimSpace(event.Message))
+		}
+	}
+	files["events.txt"] = events.String()
+
+	var pods strings.Builder
+	list, err := kubeClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fmt.Fprintf(&pods, "failed to list the pods: %v\n", err)
+		files["pods.txt"] = pods.String()
+		return files
+	}
+	for _, pod := range list.Items {
+		fmt.Fprintf(&pods, "%s\t%s\tnode=%s\n", pod.Name, pod.Status.Phase, pod.Spec.NodeName)
+		for _, status := range pod.Status.ContainerStatuses {
+			fmt.Fprintf(&pods, "  %s\tready=%t\trestarts=%d\t%s\n", status.Name, status.Ready, status.RestartCount, containerState(status.State))
+		}
+		for _, container := range pod.Spec.Containers {
+			tail := diagnosticsLogLines
+			logs, err := kubeClient.Logs(ctx, namespace, pod.Name, corev1.PodLogOptions{Container: container.Name, TailLines: &tail})
+			if err != nil {
+				logs = err.Error() + "\n"
+			}
+			files[fmt.Sprintf("logs/%s_%s.log", pod.Name, container.Name)] = logs
+		}
+	}
+	files["pods.txt"] = pods.String()
+	return files
+}
+
+// eventTime returns when an event last happened.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// containerState describes the state of a container as kubectl shows it.
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "running"
+	case state.Waiting != nil:
+		return "waiting: " + state.Waiting.Reason
+	case state.Terminated != nil:
+		return fmt.Sprintf("terminated: %s (exit code %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	default:
+		return "unknown"
+	}
+}
+
+// writeBundle writes the files to a gzipped tarball at path, sorted by name.
+func writeBundle(path string, files map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	now := time.Now()
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
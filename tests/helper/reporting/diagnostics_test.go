@@ -0,0 +1,59 @@
+package reporting
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "diagnostics", "bundle.tar.gz")
+	files := map[string]string{
+		"charts.txt": "cattle-monitoring-system/rancher-monitoring\n",
+		"cattle-monitoring-system/logs/prometheus_prometheus.log": "level=error\n",
+		"cattle-monitoring-system/events.txt":                     "",
+	}
+	if err := writeBundle(path, files); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	got := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		got[header.Name] = string(content)
+	}
+
+	wantNames := []string{"cattle-monitoring-system/events.txt", "cattle-monitoring-system/logs/prometheus_prometheus.log", "charts.txt"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("got files %v, want %v", names, wantNames)
+	}
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("got contents %v, want %v", got, files)
+	}
+}
@@ -0,0 +1,132 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/shepherd/clients/rancher"
+	"github.com/rancher/shepherd/extensions/clusters"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// EnvironmentEntry is the name of the report entry that RecordEnvironment adds.
+const EnvironmentEntry = "environment"
+
+// Environment is the Rancher installation a suite ran against, as the suite reports show it.
+type Environment struct {
+	RancherURL     string  `json:"rancherURL"`
+	RancherVersion string  `json:"rancherVersion"`
+	GitCommit      string  `json:"gitCommit"`
+	Prime          bool    `json:"prime"`
+	Charts         []Chart `json:"charts"`
+	// Errors are the parts of the environment that could not be read.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Chart is a chart installed as a Rancher App.
+type Chart struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Chart     string `json:"chart"`
+	Version   string `json:"version"`
+}
+
+// RecordEnvironment reads the Rancher version and the charts installed as Apps in the cluster, the local cluster
+// when cluster is nil, and adds them to the report of the running node for the suite reports. Suites call it at the
+// end of the suite, once the charts under test are installed. What cannot be read is recorded as an error instead
+// of failing the suite.
+func RecordEnvironment(client *rancher.Client, cluster *clusters.ClusterMeta) {
+	env := Environment{}
+	if client == nil {
+		env.Errors = append(env.Errors, "no Rancher client")
+		addEnvironment(env)
+		return
+	}
+
+	env.RancherURL = "https://" + client.RancherConfig.Host
+	if config, err := utils.RequestRancherVersion(client.RancherConfig.Host); err != nil {
+		env.Errors = append(env.Errors, fmt.Sprintf("failed to get the Rancher version: %v", err))
+	} else {
+		env.RancherVersion = config.RancherVersion
+		env.GitCommit = config.GitCommit
+		env.Prime = config.IsPrime
+	}
+
+	clusterID := kube.LocalCluster
+	if cluster != nil {
+		clusterID = cluster.ID
+	}
+	charts, err := installedCharts(client, clusterID)
+	if err != nil {
+		env.Errors = append(env.Errors, fmt.Sprintf("failed to list the Apps of cluster %s: %v", clusterID, err))
+	}
+	env.Charts = charts
+	addEnvironment(env)
+}
+
+// addEnvironment adds env to the report as JSON, so it reaches the first process of a parallel run intact.
+func addEnvironment(env Environment) {
+	data, err := json.Marshal(env)
+	if err != nil {
+		ginkgo.GinkgoWriter.Printf("Failed to record the environment: %v\n", err)
+		return
+	}
+	ginkgo.AddReportEntry(EnvironmentEntry, string(data), ginkgo.ReportEntryVisibilityNever)
+}
+
+// installedCharts returns the charts installed as Apps in a cluster, sorted by namespace and name.
+func installedCharts(client *rancher.Client, clusterID string) ([]Chart, error) {
+	kubeClient, err := kube.NewClient(client, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := kubeClient.List(context.TODO(), kube.AppsGVR, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	charts := make([]Chart, 0, len(apps))
+	for _, app := range apps {
+		chart, _, _ := unstructured.NestedString(app.Object, "spec", "chart", "metadata", "name")
+		chartVersion, _, _ := unstructured.NestedString(app.Object, "spec", "chart", "metadata", "version")
+		charts = append(charts, Chart{
+			Cluster:   clusterID,
+			Namespace: app.GetNamespace(),
+			Name:      app.GetName(),
+			Chart:     chart,
+			Version:   chartVersion,
+		})
+	}
+	sort.Slice(charts, func(i, j int) bool {
+		if charts[i].Namespace != charts[j].Namespace {
+			return charts[i].Namespace < charts[j].Namespace
+		}
+		return charts[i].Name < charts[j].Name
+	})
+	return charts, nil
+}
+
+// environmentOf returns the environment recorded last in the report of a suite, or nil when none was recorded.
+func environmentOf(report types.Report) *Environment {
+	var env *Environment
+	for _, spec := range report.SpecReports {
+		for _, entry := range spec.ReportEntries {
+			if entry.Name != EnvironmentEntry {
+				continue
+			}
+			recorded := &Environment{}
+			if err := json.Unmarshal([]byte(entry.Value.String()), recorded); err == nil {
+				env = recorded
+			}
+		}
+	}
+	return env
+}
@@ -0,0 +1,152 @@
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// htmlReport is the data of the HTML report.
+type htmlReport struct {
+	Suite       string
+	Path        string
+	LabelFilter string
+	Seed        int64
+	Start       time.Time
+	Duration    time.Duration
+	Succeeded   bool
+	Environment *Environment
+	Specs       []specResult
+	Counts      map[Status]int
+	QaseProject string
+}
+
+var htmlFuncs = template.FuncMap{
+	"inc": func(i int) int {
+		return i + 1
+	},
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	},
+}
+
+// htmlTemplate is the HTML report. It has no external resources, so it can be opened from a CI artifact as is.
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Suite }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+pre { background: #f7f7f7; padding: 8px; overflow-x: auto; white-space: pre-wrap; }
+details { margin: 4px 0; }
+.passed { color: #1a7f37; } .failed { color: #cf222e; } .skipped, .blocked { color: #6e7781; } .invalid { color: #9a6700; }
+.status { font-weight: bold; text-transform: uppercase; }
+.label { display: inline-block; background: #ddf4ff; border-radius: 4px; padding: 0 4px; margin: 1px; font-size: 90%; }
+</style>
+</head>
+<body>
+<h1>{{ .Suite }}</h1>
+<p class="status {{ if .Succeeded }}passed{{ else }}failed{{ end }}">{{ if .Succeeded }}passed{{ else }}failed{{ end }}</p>
+<table>
+<tr><th>Package</th><td>{{ .Path }}</td></tr>
+<tr><th>Started</th><td>{{ time .Start }}</td></tr>
+<tr><th>Duration</th><td>{{ duration .Duration }}</td></tr>
+<tr><th>Label filter</th><td>{{ .LabelFilter }}</td></tr>
+<tr><th>Random seed</th><td>{{ .Seed }}</td></tr>
+<tr><th>Specs</th><td>{{ range $status, $count := .Counts }}<span class="{{ $status }}">{{ $count }} {{ $status }}</span> {{ end }}</td></tr>
+</table>
+
+<h2>Environment</h2>
+{{ with .Environment }}
+<table>
+<tr><th>Rancher</th><td>{{ .RancherURL }}</td></tr>
+<tr><th>Version</th><td>{{ .RancherVersion }}{{ if .Prime }} (Prime){{ end }}</td></tr>
+<tr><th>Git commit</th><td>{{ .GitCommit }}</td></tr>
+</table>
+{{ if .Charts }}
+<table>
+<tr><th>Cluster</th><th>Namespace</th><th>App</th><th>Chart</th><th>Version</th></tr>
+{{ range .Charts }}<tr><td>{{ .Cluster }}</td><td>{{ .Namespace }}</td><td>{{ .Name }}</td><td>{{ .Chart }}</td><td>{{ .Version }}</td></tr>
+{{ end }}</table>
+{{ end }}
+{{ range .Errors }}<p class="invalid">{{ . }}</p>
+{{ end }}
+{{ else }}
+<p>The suite did not record its environment.</p>
+{{ end }}
+
+<h2>Specs</h2>
+<table>
+<tr><th>Status</th><th>Spec</th><th>Qase</th><th>Labels</th><th>Duration</th></tr>
+{{ range .Specs }}
+<tr>
+<td class="status {{ .Status }}">{{ .State }}</td>
+<td>
+{{ .Text }}{{ if ne .NodeType "It" }} <em>({{ .NodeType }})</em>{{ end }}
+<br><small>{{ .Location }}</small>
+{{ if .Failure }}<pre>{{ .Failure }}{{ if .FailureLocation }}
+at {{ .FailureLocation }}{{ end }}</pre>{{ end }}
+{{ if .Steps }}<details><summary>{{ len .Steps }} steps</summary>
+<table>
+<tr><th>#</th><th>Step</th><th>Status</th><th>Duration</th></tr>
+{{ range $i, $step := .Steps }}<tr><td>{{ inc $i }}</td><td>{{ $step.Text }}</td><td class="{{ $step.Status }}">{{ $step.Status }}</td><td>{{ duration $step.Duration }}</td></tr>
+{{ end }}</table>
+</details>{{ end }}
+{{ if .Attachments }}<details open><summary>Attachments</summary>
+<ul>{{ range .Attachments }}<li><a href="{{ . }}">{{ . }}</a></li>{{ end }}</ul>
+</details>{{ end }}
+{{ if .Output }}<details><summary>Output</summary><pre>{{ .Output }}</pre></details>{{ end }}
+</td>
+<td>{{ range .CaseIDs }}{{ if $.QaseProject }}<a href="https://app.qase.io/case/{{ $.QaseProject }}-{{ . }}">{{ $.QaseProject }}-{{ . }}</a>{{ else }}{{ . }}{{ end }} {{ end }}</td>
+<td>{{ range .Labels }}<span class="label">{{ . }}</span>{{ end }}</td>
+<td>{{ duration .Duration }}</td>
+</tr>
+{{ end }}
+</table>
+</body>
+</html>
+`))
+
+// writeHTML writes the HTML report of a suite.
+func writeHTML(path string, report types.Report, specs []specResult, env *Environment) error {
+	data := htmlReport{
+		Suite:       report.SuiteDescription,
+		Path:        report.SuitePath,
+		LabelFilter: report.SuiteConfig.LabelFilter,
+		Seed:        report.SuiteConfig.RandomSeed,
+		Start:       report.StartTime,
+		Duration:    report.RunTime,
+		Succeeded:   report.SuiteSucceeded,
+		Environment: env,
+		Specs:       specs,
+		Counts:      map[Status]int{},
+		QaseProject: os.Getenv("QASE_PROJECT_CODE"),
+	}
+	for _, spec := range specs {
+		data.Counts[spec.Status]++
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to render the HTML report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
@@ -0,0 +1,55 @@
+package reporting
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+)
+
+var (
+	// qaseTag matches [QASE-123] and [QASE-123,456] in spec texts.
+	qaseTag = regexp.MustCompile(`\[QASE-([\d,\s]+)\]`)
+	// qaseLabel matches the QASE-123 labels that charts.QaseEntry adds.
+	qaseLabel = regexp.MustCompile(`^QASE-(\d+)$`)
+)
+
+// ExtractIDs returns the Qase case IDs of the [QASE-…] tags in text, in order. A tag may list several IDs, as in
+// [QASE-123,456], and text may hold several tags.
+func ExtractIDs(text string) []int64 {
+	var ids []int64
+	for _, match := range qaseTag.FindAllStringSubmatch(text, -1) {
+		for _, field := range strings.Split(match[1], ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err == nil && id > 0 {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// CaseIDs returns the sorted Qase case IDs of a spec, taken from the [QASE-…] tags of the spec and its containers
+// and from QASE-<id> labels. Each ID is returned once.
+func CaseIDs(report ginkgo.SpecReport) []int64 {
+	seen := map[int64]bool{}
+	for _, id := range ExtractIDs(report.FullText()) {
+		seen[id] = true
+	}
+	for _, label := range report.Labels() {
+		if match := qaseLabel.FindStringSubmatch(label); match != nil {
+			if id, err := strconv.ParseInt(match[1], 10, 64); err == nil && id > 0 {
+				seen[id] = true
+			}
+		}
+	}
+
+	ids := make([]int64, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
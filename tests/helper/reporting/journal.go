@@ -0,0 +1,117 @@
+package reporting
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+)
+
+// DefaultResultsDir is where the suites write their results when RESULTS_DIR is unset, relative to the package of
+// the suite.
+const DefaultResultsDir = "results"
+
+// JournalEntry is the result of a Qase case with all that is needed to publish it later.
+type JournalEntry struct {
+	// Key identifies the result of a case in one execution of a spec, so a result is published only once.
+	Key   string    `json:"key"`
+	RunID int64     `json:"run_id,omitempty"`
+	Spec  string    `json:"spec"`
+	Time  time.Time `json:"time"`
+	// Result is the result without its attachments, they are uploaded when it is published.
+	Result Result `json:"result"`
+	// Files are the paths of the files attached with Attach.
+	Files []string `json:"files,omitempty"`
+	// Logs are the captured outputs of a failed spec, by file name.
+	Logs map[string]string `json:"logs,omitempty"`
+	// Hash is the hash of the published result, empty until it is published.
+	Hash string `json:"hash,omitempty"`
+}
+
+// Journal is a JSONL file of results. Entries are only appended: publishing a result appends it again with its hash.
+// Each entry is written at once, so the parallel processes of a run can share a journal.
+type Journal struct {
+	path string
+}
+
+// NewJournal returns the journal at path. The file is created on the first append.
+func NewJournal(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// DefaultJournalPath returns the journal of the running suite in RESULTS_DIR. The journal is named after the random
+// seed, which all parallel processes of a run share.
+func DefaultJournalPath() string {
+	return filepath.Join(resultsDir(), fmt.Sprintf("results-%d.jsonl", ginkgo.GinkgoRandomSeed()))
+}
+
+// resultsDir returns RESULTS_DIR, or DefaultResultsDir when it is unset.
+func resultsDir() string {
+	if dir := os.Getenv("RESULTS_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultResultsDir
+}
+
+// Path returns the path of the journal file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append writes entry at the end of the journal.
+func (j *Journal) Append(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write journal %s: %w", j.path, err)
+	}
+	return f.Close()
+}
+
+// Entries returns the results of the journal in the order they were first written. A result written several
+// times is returned once, as published if it was, else as last written.
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	index := map[string]int{}
+	scanner := bufio.NewScanner(f)
+	// Captured outputs of failed specs make long lines
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", j.path, line, err)
+		}
+		if i, ok := index[entry.Key]; ok {
+			if entries[i].Hash == "" {
+				entries[i] = entry
+			}
+			continue
+		}
+		index[entry.Key] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,111 @@
+package reporting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+func TestJournalEntries(t *testing.T) {
+	journal := NewJournal(filepath.Join(t.TempDir(), "nested", "results.jsonl"))
+	first := JournalEntry{Key: "a", Spec: "first", Result: Result{CaseID: 1, Status: Failed}}
+	second := JournalEntry{Key: "b", Spec: "second", Result: Result{CaseID: 2, Status: Passed}}
+
+	published := first
+	published.Hash = "hash-a"
+	rewritten := second
+	rewritten.Result.Comment = "written again"
+	for _, entry := range []JournalEntry{first, second, published, first, rewritten} {
+		if err := journal.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := journal.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Key != "a" || entries[1].Key != "b" {
+		t.Errorf("got keys %s and %s, want the order of the first writes", entries[0].Key, entries[1].Key)
+	}
+	if entries[0].Hash != "hash-a" {
+		t.Errorf("got hash %q for a published result written again, want it to stay published", entries[0].Hash)
+	}
+	if entries[1].Result.Comment != "written again" {
+		t.Errorf("got comment %q for an unpublished result, want the last write", entries[1].Result.Comment)
+	}
+}
+
+func TestJournalEntriesErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewJournal(filepath.Join(dir, "missing.jsonl")).Entries(); err == nil {
+		t.Error("Entries of a missing journal succeeded, want an error")
+	}
+
+	path := filepath.Join(dir, "results.jsonl")
+	if err := os.WriteFile(path, []byte("{\"key\":\"a\"}\n\n{\"key\":\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewJournal(path).Entries()
+	if err == nil || !strings.Contains(err.Error(), path+":3") {
+		t.Errorf("got error %v, want one naming line 3", err)
+	}
+}
+
+func TestJournalEntriesOfSpec(t *testing.T) {
+	report := specReport(types.SpecStateFailed, []string{"Suite"}, "[QASE-10,11] spec")
+	report.CapturedGinkgoWriterOutput = "writer output"
+	report.ReportEntries = types.ReportEntries{
+		{Name: AttachmentEntry, Value: types.WrapEntryValue("/tmp/bundle.tar.gz"), Visibility: types.ReportEntryVisibilityNever},
+	}
+
+	entries := journalEntries(report, 3)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want one for each case", len(entries))
+	}
+	if entries[0].Key == entries[1].Key {
+		t.Errorf("the results of both cases have the key %s", entries[0].Key)
+	}
+	if again := journalEntries(report, 3); again[0].Key != entries[0].Key {
+		t.Errorf("got key %s for the same execution, want %s", again[0].Key, entries[0].Key)
+	}
+	for i, entry := range entries {
+		if entry.Result.CaseID != int64(10+i) || entry.RunID != 3 || entry.Spec != "Suite [QASE-10,11] spec" {
+			t.Errorf("got case %d of %q in run %d", entry.Result.CaseID, entry.Spec, entry.RunID)
+		}
+		if entry.Result.Status != Failed || entry.Result.TimeMs != time.Second.Milliseconds() {
+			t.Errorf("got %s in %dms, want failed in 1000ms", entry.Result.Status, entry.Result.TimeMs)
+		}
+		if len(entry.Files) != 1 || entry.Files[0] != "/tmp/bundle.tar.gz" {
+			t.Errorf("got files %v, want the attachment", entry.Files)
+		}
+		if entry.Logs[ginkgoWriterLog] != "writer output" {
+			t.Errorf("got logs %v, want the GinkgoWriter output", entry.Logs)
+		}
+	}
+
+	rerun := report
+	rerun.StartTime = rerun.StartTime.Add(time.Minute)
+	if journalEntries(rerun, 3)[0].Key == entries[0].Key {
+		t.Error("a later execution of the spec has the key of the first one")
+	}
+
+	filtered := specReport(types.SpecStateSkipped, nil, "[QASE-12] spec")
+	filtered.StartTime, filtered.RunTime = time.Time{}, 0
+	if entries := journalEntries(filtered, 3); entries != nil {
+		t.Errorf("got %+v for a spec that never ran, want none", entries)
+	}
+	if entries := journalEntries(specReport(types.SpecStatePassed, nil, "spec"), 3); entries != nil {
+		t.Errorf("got %+v for a spec without a case, want none", entries)
+	}
+	if entries := journalEntries(specReport(types.SpecStatePassed, nil, "[QASE-13] spec"), 3); entries[0].Logs != nil {
+		t.Errorf("got logs %v for a passed spec, want none", entries[0].Logs)
+	}
+}
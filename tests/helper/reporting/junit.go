@@ -0,0 +1,168 @@
+package reporting
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// The JUnit types follow the schema Ginkgo writes, with properties on test cases for the Qase cases and labels.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Package    string          `xml:"package,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       float64         `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Status     string          `xml:"status,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Skipped    *junitMessage   `xml:"skipped,omitempty"`
+	Error      *junitMessage   `xml:"error,omitempty"`
+	Failure    *junitMessage   `xml:"failure,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+	SystemErr  string          `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message     string `xml:"message,attr"`
+	Type        string `xml:"type,attr,omitempty"`
+	Description string `xml:",chardata"`
+}
+
+// writeJUnit writes the JUnit XML report of a suite. The steps of a spec and their durations are its system-out,
+// and its Qase cases, labels and attachments are properties of its test case.
+func writeJUnit(path string, report types.Report, specs []specResult, env *Environment) error {
+	suite := junitTestSuite{
+		Name:       report.SuiteDescription,
+		Package:    report.SuitePath,
+		Time:       report.RunTime.Seconds(),
+		Timestamp:  report.StartTime.Format("2006-01-02T15:04:05"),
+		Properties: environmentProperties(report, env),
+	}
+	for _, spec := range specs {
+		testCase := junitTestCase{
+			Name:       spec.Text,
+			Classname:  report.SuiteDescription,
+			Status:     spec.State,
+			Time:       spec.Duration.Seconds(),
+			Properties: specProperties(spec),
+			SystemOut:  stepsText(spec.Steps),
+			SystemErr:  spec.Output,
+		}
+		message := &junitMessage{Message: spec.Failure, Description: spec.FailureLocation}
+		switch {
+		case spec.Status == Skipped || spec.Status == Blocked:
+			message.Message = strings.TrimSpace(spec.State + " " + spec.Failure)
+			testCase.Skipped = message
+			suite.Skipped++
+		case spec.State == types.SpecStatePanicked.String() || spec.State == types.SpecStateInterrupted.String():
+			message.Type = spec.State
+			testCase.Error = message
+			suite.Errors++
+		case spec.Status == Failed:
+			message.Type = spec.State
+			testCase.Failure = message
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suites := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0o644)
+}
+
+// environmentProperties returns the suite configuration and the environment as JUnit properties.
+func environmentProperties(report types.Report, env *Environment) []junitProperty {
+	properties := []junitProperty{
+		{Name: "SuiteSucceeded", Value: strconv.FormatBool(report.SuiteSucceeded)},
+		{Name: "LabelFilter", Value: report.SuiteConfig.LabelFilter},
+		{Name: "RandomSeed", Value: strconv.FormatInt(report.SuiteConfig.RandomSeed, 10)},
+	}
+	if env == nil {
+		return properties
+	}
+	properties = append(properties,
+		junitProperty{Name: "RancherURL", Value: env.RancherURL},
+		junitProperty{Name: "RancherVersion", Value: env.RancherVersion},
+		junitProperty{Name: "RancherGitCommit", Value: env.GitCommit},
+		junitProperty{Name: "RancherPrime", Value: strconv.FormatBool(env.Prime)},
+	)
+	for _, chart := range env.Charts {
+		properties = append(properties, junitProperty{
+			Name:  fmt.Sprintf("Chart/%s/%s/%s", chart.Cluster, chart.Namespace, chart.Name),
+			Value: chart.Chart + " " + chart.Version,
+		})
+	}
+	for _, message := range env.Errors {
+		properties = append(properties, junitProperty{Name: "EnvironmentError", Value: message})
+	}
+	return properties
+}
+
+// specProperties returns the Qase cases, labels and attachments of a spec as JUnit properties.
+func specProperties(spec specResult) []junitProperty {
+	var properties []junitProperty
+	for _, id := range spec.CaseIDs {
+		properties = append(properties, junitProperty{Name: "QaseID", Value: strconv.FormatInt(id, 10)})
+	}
+	if len(spec.Labels) > 0 {
+		properties = append(properties, junitProperty{Name: "Labels", Value: strings.Join(spec.Labels, ", ")})
+	}
+	for _, path := range spec.Attachments {
+		properties = append(properties, junitProperty{Name: "Attachment", Value: path})
+	}
+	return properties
+}
+
+// stepsText returns the steps of a spec, one per line with their status and duration.
+func stepsText(steps []Step) string {
+	var b strings.Builder
+	for i, step := range steps {
+		fmt.Fprintf(&b, "%d. [%s] %s (%s)\n", i+1, step.Status, step.Text, step.Duration.Round(time.Millisecond))
+	}
+	return b.String()
+}
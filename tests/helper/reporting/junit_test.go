@@ -0,0 +1,248 @@
+package reporting
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// suiteReport returns a report with a passed, a skipped, a pending, a failed and a panicked spec, a passed and a
+// failed suite node, and the recorded environment. The passed spec has attachments inside and outside dir.
+func suiteReport(dir string) types.Report {
+	passed := specReport(types.SpecStatePassed, []string{"Monitoring"}, "[QASE-1] passed", "LEVEL1", "QASE-2")
+	passed.ReportEntries = types.ReportEntries{
+		{Name: AttachmentEntry, Value: types.WrapEntryValue(filepath.Join(dir, "diagnostics", "bundle.tar.gz")), Visibility: types.ReportEntryVisibilityNever},
+		{Name: AttachmentEntry, Value: types.WrapEntryValue("/var/backups/inspection.json"), Visibility: types.ReportEntryVisibilityNever},
+	}
+
+	skipped := specReport(types.SpecStateSkipped, []string{"Monitoring"}, "skipped")
+	skipped.Failure = types.Failure{Message: "rancher-alerting-drivers is not installed", Location: types.CodeLocation{FileName: "alerts_test.go", LineNumber: 12}}
+
+	pending := specReport(types.SpecStatePending, []string{"Monitoring"}, "pending")
+	pending.RunTime = 0
+
+	failed := stepsReport(types.SpecStateFailed, 7)
+	failed.ContainerHierarchyTexts = []string{"Logging"}
+	failed.LeafNodeText = "[QASE-3] failed"
+	failed.Failure.Message = "Expected true to be false"
+	failed.Failure.Location = types.CodeLocation{FileName: "logging_test.go", LineNumber: 40}
+	failed.CapturedGinkgoWriterOutput = "writer output\n"
+
+	panicked := specReport(types.SpecStatePanicked, []string{"Logging"}, "panicked")
+	panicked.Failure = types.Failure{Message: "Test Panicked", Location: types.CodeLocation{FileName: "logging_test.go", LineNumber: 80}}
+
+	beforeSuite := types.SpecReport{LeafNodeType: types.NodeTypeBeforeSuite, State: types.SpecStatePassed, RunTime: time.Minute}
+
+	env, err := json.Marshal(Environment{
+		RancherURL:     "https://rancher.example.com",
+		RancherVersion: "v2.11.1",
+		GitCommit:      "abc123",
+		Charts:         []Chart{{Cluster: "local", Namespace: "cattle-monitoring-system", Name: "rancher-monitoring", Chart: "rancher-monitoring", Version: "106.1.0+up69.8.2"}},
+		Errors:         []string{"failed to list the Apps of cluster c-1"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	afterSuite := types.SpecReport{
+		LeafNodeType: types.NodeTypeAfterSuite,
+		State:        types.SpecStateFailed,
+		RunTime:      time.Second,
+		Failure:      types.Failure{Message: "Failed to delete the S3 bucket"},
+		ReportEntries: types.ReportEntries{
+			{Name: EnvironmentEntry, Value: types.WrapEntryValue(string(env)), Visibility: types.ReportEntryVisibilityNever},
+		},
+	}
+
+	return types.Report{
+		SuitePath:        "/src/tests/e2e",
+		SuiteDescription: "E2E Suite",
+		SuiteSucceeded:   false,
+		SuiteConfig:      types.SuiteConfig{LabelFilter: "LEVEL1", RandomSeed: 42},
+		StartTime:        specStart,
+		RunTime:          10 * time.Minute,
+		SpecReports:      types.SpecReports{beforeSuite, passed, skipped, pending, failed, panicked, afterSuite},
+	}
+}
+
+func TestSpecResults(t *testing.T) {
+	dir := t.TempDir()
+	results := specResults(suiteReport(dir), dir)
+
+	var texts []string
+	for _, result := range results {
+		texts = append(texts, result.Text)
+	}
+	want := []string{"Monitoring [QASE-1] passed", "Monitoring skipped", "Monitoring pending", "Logging [QASE-3] failed", "Logging panicked", "AfterSuite"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Fatalf("got specs %q, want %q", texts, want)
+	}
+
+	passed, skipped, pending, failed, panicked, afterSuite := results[0], results[1], results[2], results[3], results[4], results[5]
+	if !reflect.DeepEqual(passed.CaseIDs, []int64{1, 2}) || !reflect.DeepEqual(passed.Labels, []string{"LEVEL1", "QASE-2"}) {
+		t.Errorf("got cases %v and labels %v for the passed spec", passed.CaseIDs, passed.Labels)
+	}
+	wantAttachments := []string{filepath.Join("diagnostics", "bundle.tar.gz"), "/var/backups/inspection.json"}
+	if !reflect.DeepEqual(passed.Attachments, wantAttachments) {
+		t.Errorf("got attachments %v, want %v", passed.Attachments, wantAttachments)
+	}
+	if passed.Failure != "" || passed.Output != "" {
+		t.Errorf("got failure %q and output %q for the passed spec", passed.Failure, passed.Output)
+	}
+
+	if skipped.Status != Skipped || skipped.Failure != "rancher-alerting-drivers is not installed" || skipped.FailureLocation != "alerts_test.go:12" {
+		t.Errorf("got %s with %q at %s for the skipped spec, want skipped with its reason", skipped.Status, skipped.Failure, skipped.FailureLocation)
+	}
+	if skipped.Output != "" {
+		t.Errorf("got output %q for the skipped spec, want none", skipped.Output)
+	}
+	if pending.Status != Blocked {
+		t.Errorf("got %s for the pending spec, want blocked", pending.Status)
+	}
+
+	if failed.Status != Failed || failed.State != "failed" || failed.Failure != "Expected true to be false" {
+		t.Errorf("got %s (%s) with %q for the failed spec", failed.Status, failed.State, failed.Failure)
+	}
+	if len(failed.Steps) != 3 || failed.Steps[2].Status != Failed {
+		t.Errorf("got steps %+v for the failed spec, want the last one failed", failed.Steps)
+	}
+	if !strings.Contains(failed.Output, "writer output") {
+		t.Errorf("got output %q for the failed spec, want the GinkgoWriter output", failed.Output)
+	}
+
+	if panicked.Status != Failed || panicked.State != "panicked" || panicked.FailureLocation != "logging_test.go:80" {
+		t.Errorf("got %s (%s) at %s for the panicked spec", panicked.Status, panicked.State, panicked.FailureLocation)
+	}
+	if afterSuite.NodeType != "AfterSuite" || afterSuite.Status != Failed || afterSuite.Failure != "Failed to delete the S3 bucket" {
+		t.Errorf("got %s %s with %q for the failed suite node", afterSuite.NodeType, afterSuite.Status, afterSuite.Failure)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	dir := t.TempDir()
+	report := suiteReport(dir)
+	path := filepath.Join(dir, "junit.xml")
+	if err := writeJUnit(path, report, specResults(report, filepath.Dir(path)), environmentOf(report)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, data)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	if suites.Tests != 6 || suites.Failures != 2 || suites.Errors != 1 || suite.Skipped != 2 {
+		t.Errorf("got %d tests, %d failures, %d errors and %d skipped, want 6, 2, 1 and 2",
+			suites.Tests, suites.Failures, suites.Errors, suite.Skipped)
+	}
+	if suite.Name != "E2E Suite" || suite.Package != "/src/tests/e2e" || suite.Time != 600 {
+		t.Errorf("got suite %q of %q in %gs", suite.Name, suite.Package, suite.Time)
+	}
+
+	properties := map[string][]string{}
+	for _, property := range suite.Properties {
+		properties[property.Name] = append(properties[property.Name], property.Value)
+	}
+	wantProperties := map[string][]string{
+		"SuiteSucceeded":   {"false"},
+		"LabelFilter":      {"LEVEL1"},
+		"RandomSeed":       {"42"},
+		"RancherURL":       {"https://rancher.example.com"},
+		"RancherVersion":   {"v2.11.1"},
+		"RancherGitCommit": {"abc123"},
+		"RancherPrime":     {"false"},
+		"Chart/local/cattle-monitoring-system/rancher-monitoring": {"rancher-monitoring 106.1.0+up69.8.2"},
+		"EnvironmentError": {"failed to list the Apps of cluster c-1"},
+	}
+	if !reflect.DeepEqual(properties, wantProperties) {
+		t.Errorf("got suite properties %v, want %v", properties, wantProperties)
+	}
+
+	cases := map[string]junitTestCase{}
+	for _, testCase := range suite.TestCases {
+		cases[testCase.Name] = testCase
+	}
+
+	passed := cases["Monitoring [QASE-1] passed"]
+	if passed.Status != "passed" || passed.Skipped != nil || passed.Failure != nil || passed.Error != nil {
+		t.Errorf("got passed spec %+v", passed)
+	}
+	wantPassed := []junitProperty{
+		{Name: "QaseID", Value: "1"},
+		{Name: "QaseID", Value: "2"},
+		{Name: "Labels", Value: "LEVEL1, QASE-2"},
+		{Name: "Attachment", Value: filepath.Join("diagnostics", "bundle.tar.gz")},
+		{Name: "Attachment", Value: "/var/backups/inspection.json"},
+	}
+	if !reflect.DeepEqual(passed.Properties, wantPassed) {
+		t.Errorf("got properties %v, want %v", passed.Properties, wantPassed)
+	}
+
+	skipped := cases["Monitoring skipped"]
+	if skipped.Skipped == nil || skipped.Skipped.Message != "skipped rancher-alerting-drivers is not installed" || skipped.Failure != nil {
+		t.Errorf("got skipped spec %+v, want a skipped element with the reason", skipped)
+	}
+	if pending := cases["Monitoring pending"]; pending.Skipped == nil || pending.Skipped.Message != "pending" {
+		t.Errorf("got pending spec %+v, want a skipped element", pending)
+	}
+
+	failed := cases["Logging [QASE-3] failed"]
+	if failed.Failure == nil || failed.Failure.Message != "Expected true to be false" || failed.Failure.Type != "failed" ||
+		failed.Failure.Description != "logging_test.go:40" {
+		t.Errorf("got failed spec %+v, want a failure element", failed)
+	}
+	if !strings.Contains(failed.SystemOut, "3. [failed] three (5s)") || !strings.Contains(failed.SystemErr, "writer output") {
+		t.Errorf("got system-out %q and system-err %q, want the steps and the output", failed.SystemOut, failed.SystemErr)
+	}
+
+	panicked := cases["Logging panicked"]
+	if panicked.Error == nil || panicked.Error.Type != "panicked" || panicked.Failure != nil {
+		t.Errorf("got panicked spec %+v, want an error element", panicked)
+	}
+	if afterSuite := cases["AfterSuite"]; afterSuite.Failure == nil || afterSuite.Failure.Message != "Failed to delete the S3 bucket" {
+		t.Errorf("got failed suite node %+v, want a failure element", afterSuite)
+	}
+}
+
+func TestWriteJUnitWithoutEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "junit.xml")
+	report := types.Report{SuiteDescription: "Empty Suite", SuiteSucceeded: true, SuiteConfig: types.SuiteConfig{RandomSeed: 7}}
+	if env := environmentOf(report); env != nil {
+		t.Fatalf("got environment %+v for a suite that recorded none", env)
+	}
+	if err := writeJUnit(path, report, specResults(report, ""), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, property := range suites.Suites[0].Properties {
+		names = append(names, property.Name)
+	}
+	if want := []string{"SuiteSucceeded", "LabelFilter", "RandomSeed"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got properties %v, want %v", names, want)
+	}
+	if suites.Tests != 0 {
+		t.Errorf("got %d tests, want none", suites.Tests)
+	}
+}
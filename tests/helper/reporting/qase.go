@@ -0,0 +1,145 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the base URL of the Qase API.
+const DefaultBaseURL = "https://api.qase.io/v1"
+
+// Client talks to the Qase API of one project. It only covers what the suites and the qase helper need.
+type Client struct {
+	BaseURL    string
+	Token      string
+	Project    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client of the Qase project with the given code. An empty baseURL means DefaultBaseURL.
+func NewClient(baseURL, token, project string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		Project:    project,
+		HTTPClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Result is the result of a test case in a Qase run.
+type Result struct {
+	CaseID      int64        `json:"case_id"`
+	Status      Status       `json:"status"`
+	TimeMs      int64        `json:"time_ms"`
+	Comment     string       `json:"comment,omitempty"`
+	Stacktrace  string       `json:"stacktrace,omitempty"`
+	Attachments []string     `json:"attachments,omitempty"`
+	Steps       []ResultStep `json:"steps,omitempty"`
+}
+
+// ResultStep is the result of a step of a test case. Qase matches it to the steps of the case by position,
+// starting at 1.
+type ResultStep struct {
+	Position int    `json:"position"`
+	Status   Status `json:"status"`
+	Action   string `json:"action,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// CreateResult adds result to the run and returns the hash of the new result.
+func (c *Client) CreateResult(ctx context.Context, runID int64, result Result) (string, error) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		Hash string `json:"hash"`
+	}
+	path := fmt.Sprintf("/result/%s/%d", c.Project, runID)
+	if err := c.do(ctx, http.MethodPost, path, "application/json", bytes.NewReader(body), &created); err != nil {
+		return "", fmt.Errorf("failed to create the result of case %d in run %d: %w", result.CaseID, runID, err)
+	}
+	return created.Hash, nil
+}
+
+// Attachment is a file uploaded to the project.
+type Attachment struct {
+	Hash     string `json:"hash"`
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+}
+
+// UploadAttachment uploads content as a file with the given name. Results reference it by its hash.
+func (c *Client) UploadAttachment(ctx context.Context, name string, content io.Reader) (Attachment, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return Attachment{}, fmt.Errorf("failed to read attachment %s: %w", name, err)
+	}
+	if err := form.Close(); err != nil {
+		return Attachment{}, err
+	}
+
+	var uploaded []Attachment
+	path := "/attachment/" + c.Project
+	if err := c.do(ctx, http.MethodPost, path, form.FormDataContentType(), &body, &uploaded); err != nil {
+		return Attachment{}, fmt.Errorf("failed to upload attachment %s: %w", name, err)
+	}
+	if len(uploaded) == 0 {
+		return Attachment{}, fmt.Errorf("failed to upload attachment %s: no attachment in the response", name)
+	}
+	return uploaded[0], nil
+}
+
+// do sends a request to the API and decodes the result of the response into out.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Token", c.Token)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Status       bool            `json:"status"`
+		Result       json.RawMessage `json:"result"`
+		ErrorMessage string          `json:"errorMessage"`
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%s %s: unexpected response %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode >= http.StatusBadRequest || !envelope.Status {
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, envelope.ErrorMessage)
+	}
+	if out == nil || len(envelope.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
@@ -0,0 +1,316 @@
+package reporting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// AttachmentEntry is the name of the report entries that Attach adds.
+const AttachmentEntry = "attachment"
+
+// reportTimeout bounds the Qase calls for a single spec, so an unreachable Qase cannot stall the suite.
+const reportTimeout = 2 * time.Minute
+
+// Attach adds the file at path to the results of the running spec, such as a diagnostics bundle or a backup
+// inspection report. The file is read when the result is published, so it may still be written after Attach.
+func Attach(path string) {
+	ginkgo.AddReportEntry(AttachmentEntry, path, ginkgo.ReportEntryVisibilityNever, ginkgo.Offset(1))
+}
+
+// Reporter journals the results of specs and publishes them to a Qase run. A reporter without client only keeps
+// the journal, which the qase helper can replay later. A nil Reporter reports nothing.
+type Reporter struct {
+	client  *Client
+	runID   int64
+	journal *Journal
+}
+
+// NewReporter returns a reporter writing to journal and, with a client, adding results to the run with the
+// given ID.
+func NewReporter(client *Client, runID int64, journal *Journal) *Reporter {
+	return &Reporter{client: client, runID: runID, journal: journal}
+}
+
+// NewReporterFromEnv returns a reporter writing to DefaultJournalPath. It publishes to Qase when QASE_API_TOKEN,
+// QASE_PROJECT_CODE and QASE_RUN_ID are set.
+func NewReporterFromEnv() (*Reporter, error) {
+	journal := NewJournal(DefaultJournalPath())
+	token := os.Getenv("QASE_API_TOKEN")
+	project := os.Getenv("QASE_PROJECT_CODE")
+	run := os.Getenv("QASE_RUN_ID")
+	if token == "" || project == "" || run == "" {
+		return NewReporter(nil, 0, journal), nil
+	}
+	runID, err := strconv.ParseInt(run, 10, 64)
+	if err != nil || runID <= 0 {
+		return NewReporter(nil, 0, journal), fmt.Errorf("invalid QASE_RUN_ID %q", run)
+	}
+	return NewReporter(NewClient("", token, project), runID, journal), nil
+}
+
+// Report journals a result for every Qase case of the spec, see CaseIDs, and publishes them. The results share the
+// steps, the failure and the attachments of the spec. Specs without a case and specs that never ran are not
+// reported. A result that cannot be published stays unpublished in the journal.
+func (r *Reporter) Report(ctx context.Context, report ginkgo.SpecReport) error {
+	if r == nil {
+		return nil
+	}
+	entries := journalEntries(report, r.runID)
+	var errs []error
+	for _, entry := range entries {
+		if err := r.journal.Append(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.client == nil {
+		return errors.Join(errs...)
+	}
+
+	p := newPublisher(r.client)
+	for _, entry := range entries {
+		if err := p.publish(ctx, &entry); err != nil {
+			errs = append(errs, err)
+			if entry.Hash == "" {
+				continue
+			}
+		}
+		if err := r.journal.Append(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReportAfterEach registers a ReportAfterEach node reporting every spec of the suite, see NewReporterFromEnv.
+// Suites call it once at the top level:
+//
+//	var _ = reporting.ReportAfterEach()
+func ReportAfterEach() bool {
+	// The journal is named after the random seed, which is only known once the suite runs
+	newReporter := sync.OnceValues(NewReporterFromEnv)
+	return ginkgo.ReportAfterEach(func(report ginkgo.SpecReport) {
+		// Specs pass without running in a dry run
+		if suiteConfig, _ := ginkgo.GinkgoConfiguration(); suiteConfig.DryRun || !ran(report) {
+			return
+		}
+		reporter, err := newReporter()
+		if err != nil {
+			ginkgo.GinkgoWriter.Printf("Qase publishing is disabled: %v\n", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+		defer cancel()
+		if err := reporter.Report(ctx, report); err != nil {
+			ginkgo.GinkgoWriter.Printf("Failed to report %q, replay %s later: %v\n", report.FullText(), reporter.journal.Path(), err)
+		}
+	}, ginkgo.Offset(1))
+}
+
+// ran reports whether the body of a spec ran. Ginkgo also reports the specs that a label filter, a focus or a
+// skip of the whole suite left out, as skipped without run time. Publishing those would overwrite the result of
+// the run that did test the case. Specs skipped from within a node did run and are reported.
+func ran(report ginkgo.SpecReport) bool {
+	return report.State != types.SpecStateSkipped || (report.RunTime > 0 && !report.StartTime.IsZero())
+}
+
+// Replay publishes the unpublished results of the journal at path and marks them as published in it, so replaying
+// a journal twice publishes nothing twice. A non-zero runID publishes to that run instead of the run of the results,
+// for journals written without a run. It returns how many results were published and how many were skipped as
+// already published.
+func Replay(ctx context.Context, client *Client, path string, runID int64) (published, skipped int, err error) {
+	journal := NewJournal(path)
+	entries, err := journal.Entries()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	p := newPublisher(client)
+	var errs []error
+	for _, entry := range entries {
+		if entry.Hash != "" {
+			skipped++
+			continue
+		}
+		if runID > 0 {
+			entry.RunID = runID
+		}
+		if entry.RunID <= 0 {
+			errs = append(errs, fmt.Errorf("result of case %d of %q has no run, set QASE_RUN_ID", entry.Result.CaseID, entry.Spec))
+			continue
+		}
+		if err := p.publish(ctx, &entry); err != nil {
+			errs = append(errs, err)
+			if entry.Hash == "" {
+				continue
+			}
+		}
+		if err := journal.Append(entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		published++
+	}
+	return published, skipped, errors.Join(errs...)
+}
+
+// journalEntries returns the results of a spec, one for each of its Qase cases. A spec that never ran has none, so
+// it is neither journaled nor replayed.
+func journalEntries(report ginkgo.SpecReport, runID int64) []JournalEntry {
+	if !ran(report) {
+		return nil
+	}
+	ids := CaseIDs(report)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var files []string
+	for _, entry := range report.ReportEntries {
+		if entry.Name == AttachmentEntry {
+			files = append(files, entry.Value.String())
+		}
+	}
+	var logs map[string]string
+	if report.Failed() {
+		logs = map[string]string{}
+		if report.CapturedGinkgoWriterOutput != "" {
+			logs[ginkgoWriterLog] = report.CapturedGinkgoWriterOutput
+		}
+		if report.CapturedStdOutErr != "" {
+			logs[stdOutErrLog] = report.CapturedStdOutErr
+		}
+	}
+
+	entries := make([]JournalEntry, 0, len(ids))
+	for _, id := range ids {
+		key := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", report.StartTime.Format(time.RFC3339Nano), report.FullText(), id)))
+		entries = append(entries, JournalEntry{
+			Key:   hex.EncodeToString(key[:16]),
+			RunID: runID,
+			Spec:  report.FullText(),
+			Time:  report.StartTime,
+			Result: Result{
+				CaseID:     id,
+				Status:     SpecStatus(report),
+				TimeMs:     report.RunTime.Milliseconds(),
+				Comment:    comment(report),
+				Stacktrace: report.Failure.Location.FullStackTrace,
+				Steps:      resultSteps(report),
+			},
+			Files: files,
+			Logs:  logs,
+		})
+	}
+	return entries
+}
+
+// resultSteps returns the By() steps of a spec as Qase step results.
+func resultSteps(report ginkgo.SpecReport) []ResultStep {
+	var steps []ResultStep
+	for i, step := range Steps(report) {
+		steps = append(steps, ResultStep{
+			Position: i + 1,
+			Status:   step.Status,
+			Action:   step.Text,
+			Comment:  fmt.Sprintf("took %s", step.Duration.Round(time.Millisecond)),
+		})
+	}
+	return steps
+}
+
+// comment returns the failure of a spec followed by its visible report entries.
+func comment(report ginkgo.SpecReport) string {
+	var b strings.Builder
+	if report.Failed() {
+		fmt.Fprintf(&b, "%s at %s\n\n%s\n", report.State, report.Failure.Location, report.Failure.Message)
+	}
+	for _, entry := range report.ReportEntries {
+		if entry.Visibility == types.ReportEntryVisibilityNever {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s: %s\n", entry.Name, entry.Value)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Names of the captured outputs attached to failed results.
+const (
+	ginkgoWriterLog = "ginkgo-writer.log"
+	stdOutErrLog    = "stdout-stderr.log"
+)
+
+// publisher publishes journal entries. The results of a spec share their attachments, so each file is uploaded
+// only once.
+type publisher struct {
+	client   *Client
+	uploaded map[string]string
+}
+
+func newPublisher(client *Client) *publisher {
+	return &publisher{client: client, uploaded: map[string]string{}}
+}
+
+// publish uploads the attachments of entry, creates its result and sets its hash. An attachment that cannot be
+// uploaded does not hold back the result.
+func (p *publisher) publish(ctx context.Context, entry *JournalEntry) error {
+	var errs []error
+	result := entry.Result
+	for _, path := range entry.Files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read attachment: %w", err))
+			continue
+		}
+		hash, err := p.upload(ctx, filepath.Base(path), string(data))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Attachments = append(result.Attachments, hash)
+	}
+	for _, name := range []string{ginkgoWriterLog, stdOutErrLog} {
+		content, ok := entry.Logs[name]
+		if !ok {
+			continue
+		}
+		hash, err := p.upload(ctx, name, content)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Attachments = append(result.Attachments, hash)
+	}
+
+	hash, err := p.client.CreateResult(ctx, entry.RunID, result)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	entry.Hash = hash
+	return errors.Join(errs...)
+}
+
+// upload uploads content under name unless the same content was uploaded under that name before.
+func (p *publisher) upload(ctx context.Context, name, content string) (string, error) {
+	sum := sha256.Sum256([]byte(name + "\x00" + content))
+	key := hex.EncodeToString(sum[:])
+	if hash, ok := p.uploaded[key]; ok {
+		return hash, nil
+	}
+	attachment, err := p.client.UploadAttachment(ctx, name, strings.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	p.uploaded[key] = attachment.Hash
+	return attachment.Hash, nil
+}
@@ -0,0 +1,176 @@
+package reporting
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+var specStart = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// specReport returns the report of a spec that ran for a second in the given state.
+func specReport(state types.SpecState, containers []string, text string, labels ...string) types.SpecReport {
+	return types.SpecReport{
+		ContainerHierarchyTexts: containers,
+		LeafNodeType:            types.NodeTypeIt,
+		LeafNodeText:            text,
+		LeafNodeLabels:          labels,
+		State:                   state,
+		StartTime:               specStart,
+		EndTime:                 specStart.Add(time.Second),
+		RunTime:                 time.Second,
+	}
+}
+
+func TestCaseIDs(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []string
+		text       string
+		labels     []string
+		container  []string
+		want       []int64
+	}{
+		{name: "no case", text: "Test : Verify the pods", want: []int64{}},
+		{name: "spec tag", text: "[QASE-6826] Test : Verify the pods", want: []int64{6826}},
+		{name: "tag with several IDs", text: "[QASE-12, 7] Test : Verify the pods", want: []int64{7, 12}},
+		{name: "several tags", text: "[QASE-3] Test [QASE-1]", want: []int64{1, 3}},
+		{name: "container tag", containers: []string{"[QASE-40] Suite"}, text: "[QASE-41] spec", want: []int64{40, 41}},
+		{name: "labels", text: "spec", labels: []string{"LEVEL1", "QASE-9", "QASE-x", "QASE-0"}, want: []int64{9}},
+		{name: "container labels", text: "spec", container: []string{"QASE-5"}, labels: []string{"QASE-4"}, want: []int64{4, 5}},
+		{name: "duplicates", text: "[QASE-2] spec [QASE-2,2]", labels: []string{"QASE-2"}, want: []int64{2}},
+		{name: "invalid IDs", text: "[QASE-0] [QASE-,] [QASE-abc] spec", want: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := specReport(types.SpecStatePassed, tt.containers, tt.text, tt.labels...)
+			if tt.container != nil {
+				report.ContainerHierarchyLabels = [][]string{tt.container}
+			}
+			if got := CaseIDs(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecStatus(t *testing.T) {
+	tests := []struct {
+		state types.SpecState
+		want  Status
+	}{
+		{state: types.SpecStatePassed, want: Passed},
+		{state: types.SpecStateSkipped, want: Skipped},
+		{state: types.SpecStatePending, want: Blocked},
+		{state: types.SpecStateFailed, want: Failed},
+		{state: types.SpecStateAborted, want: Failed},
+		{state: types.SpecStatePanicked, want: Failed},
+		{state: types.SpecStateInterrupted, want: Failed},
+		{state: types.SpecStateTimedout, want: Failed},
+		{state: types.SpecStateInvalid, want: Invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state.String(), func(t *testing.T) {
+			if got := SpecStatus(types.SpecReport{State: tt.state}); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// stepsReport returns a spec with three steps: "one" at 0s, "two" at 2s with a callback of 500ms and "three" at
+// 5s, ending at 10s. The failure, if any, happens at the given timeline order.
+func stepsReport(state types.SpecState, failedAt int) types.SpecReport {
+	report := specReport(state, nil, "spec")
+	report.EndTime = specStart.Add(10 * time.Second)
+	report.SpecEvents = types.SpecEvents{
+		{SpecEventType: types.SpecEventByStart, Message: "one", TimelineLocation: types.TimelineLocation{Order: 1, Time: specStart}},
+		{SpecEventType: types.SpecEventByStart, Message: "two", TimelineLocation: types.TimelineLocation{Order: 3, Time: specStart.Add(2 * time.Second)}},
+		{SpecEventType: types.SpecEventByEnd, Message: "two", Duration: 500 * time.Millisecond, TimelineLocation: types.TimelineLocation{Order: 4}},
+		{SpecEventType: types.SpecEventByStart, Message: "three", TimelineLocation: types.TimelineLocation{Order: 5, Time: specStart.Add(5 * time.Second)}},
+	}
+	report.Failure.TimelineLocation.Order = failedAt
+	return report
+}
+
+func TestSteps(t *testing.T) {
+	tests := []struct {
+		name   string
+		report types.SpecReport
+		want   []Status
+	}{
+		{name: "passed", report: stepsReport(types.SpecStatePassed, 0), want: []Status{Passed, Passed, Passed}},
+		{name: "failed in the first step", report: stepsReport(types.SpecStateFailed, 2), want: []Status{Failed, Passed, Passed}},
+		{name: "panicked in a step with a callback", report: stepsReport(types.SpecStatePanicked, 3), want: []Status{Passed, Failed, Passed}},
+		{name: "failed in the last step", report: stepsReport(types.SpecStateFailed, 7), want: []Status{Passed, Passed, Failed}},
+		{name: "failed before the first step", report: stepsReport(types.SpecStateFailed, 0), want: []Status{Passed, Passed, Passed}},
+		{name: "skipped", report: stepsReport(types.SpecStateSkipped, 0), want: []Status{Skipped, Skipped, Skipped}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := Steps(tt.report)
+			var got []Status
+			for _, step := range steps {
+				got = append(got, step.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	steps := Steps(stepsReport(types.SpecStatePassed, 0))
+	wantText := []string{"one", "two", "three"}
+	wantDuration := []time.Duration{2 * time.Second, 500 * time.Millisecond, 5 * time.Second}
+	for i, step := range steps {
+		if step.Text != wantText[i] || step.Duration != wantDuration[i] {
+			t.Errorf("step %d is %q for %s, want %q for %s", i, step.Text, step.Duration, wantText[i], wantDuration[i])
+		}
+	}
+	if steps[1].Start != specStart.Add(2*time.Second) {
+		t.Errorf("step two started at %s, want %s", steps[1].Start, specStart.Add(2*time.Second))
+	}
+
+	if steps := Steps(specReport(types.SpecStatePassed, nil, "spec")); len(steps) != 0 {
+		t.Errorf("got %v for a spec without steps, want none", steps)
+	}
+}
+
+func TestReportSkipsSpecsThatNeverRan(t *testing.T) {
+	filtered := specReport(types.SpecStateSkipped, nil, "[QASE-1] filtered out")
+	filtered.StartTime, filtered.EndTime, filtered.RunTime = time.Time{}, time.Time{}, 0
+	skipped := specReport(types.SpecStateSkipped, nil, "[QASE-2] skipped by the spec")
+	passed := specReport(types.SpecStatePassed, nil, "[QASE-3] passed")
+
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	reporter := NewReporter(nil, 7, NewJournal(path))
+	for _, report := range []types.SpecReport{filtered, skipped, passed} {
+		if err := reporter.Report(context.Background(), report); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := NewJournal(path).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, entry := range entries {
+		got = append(got, entry.Result.CaseID)
+	}
+	if want := []int64{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("journaled cases %v, want %v:\n%s", got, want, data)
+	}
+	if entries[0].Result.Status != Skipped || entries[0].RunID != 7 {
+		t.Errorf("got %s in run %d for the skipped spec, want skipped in run 7", entries[0].Result.Status, entries[0].RunID)
+	}
+}
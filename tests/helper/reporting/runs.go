@@ -0,0 +1,134 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// resultsPageSize is the largest page of results the API returns.
+const resultsPageSize = 100
+
+// NewRun is a run to create.
+type NewRun struct {
+	Title         string  `json:"title"`
+	Description   string  `json:"description,omitempty"`
+	EnvironmentID int64   `json:"environment_id,omitempty"`
+	Cases         []int64 `json:"cases,omitempty"`
+	IsAutotest    bool    `json:"is_autotest"`
+}
+
+// Run is a test run of the project.
+type Run struct {
+	ID          int64    `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	StatusText  string   `json:"status_text"`
+	Public      bool     `json:"public"`
+	TimeSpent   int64    `json:"time_spent"`
+	Stats       RunStats `json:"stats"`
+}
+
+// RunStats counts the cases of a run by the status of their latest result.
+type RunStats struct {
+	Total      int `json:"total"`
+	Untested   int `json:"untested"`
+	Passed     int `json:"passed"`
+	Failed     int `json:"failed"`
+	Blocked    int `json:"blocked"`
+	Skipped    int `json:"skipped"`
+	Retest     int `json:"retest"`
+	InProgress int `json:"in_progress"`
+	Invalid    int `json:"invalid"`
+}
+
+// RunResult is a result of a run as the API lists it.
+type RunResult struct {
+	Hash      string `json:"hash"`
+	CaseID    int64  `json:"case_id"`
+	Status    Status `json:"status"`
+	TimeSpent int64  `json:"time_spent_ms"`
+	Comment   string `json:"comment"`
+}
+
+// CreateRun creates a run and returns its ID. A run without cases includes all cases of the project.
+func (c *Client) CreateRun(ctx context.Context, run NewRun) (int64, error) {
+	body, err := json.Marshal(run)
+	if err != nil {
+		return 0, err
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/run/"+c.Project, "application/json", bytes.NewReader(body), &created); err != nil {
+		return 0, fmt.Errorf("failed to create run %q: %w", run.Title, err)
+	}
+	return created.ID, nil
+}
+
+// GetRun returns the run with the given ID.
+func (c *Client) GetRun(ctx context.Context, runID int64) (*Run, error) {
+	run := &Run{}
+	path := fmt.Sprintf("/run/%s/%d", c.Project, runID)
+	if err := c.do(ctx, http.MethodGet, path, "", nil, run); err != nil {
+		return nil, fmt.Errorf("failed to get run %d: %w", runID, err)
+	}
+	return run, nil
+}
+
+// UpdateRunDescription replaces the description of the run.
+func (c *Client) UpdateRunDescription(ctx context.Context, runID int64, description string) error {
+	body, err := json.Marshal(map[string]string{"description": description})
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/run/%s/%d", c.Project, runID)
+	if err := c.do(ctx, http.MethodPatch, path, "application/json", bytes.NewReader(body), nil); err != nil {
+		return fmt.Errorf("failed to update run %d: %w", runID, err)
+	}
+	return nil
+}
+
+// ListResults returns all results of the run. A non-empty status only returns the results with that status.
+func (c *Client) ListResults(ctx context.Context, runID int64, status Status) ([]RunResult, error) {
+	var results []RunResult
+	for offset := 0; ; offset += resultsPageSize {
+		query := url.Values{}
+		query.Set("run", strconv.FormatInt(runID, 10))
+		query.Set("limit", strconv.Itoa(resultsPageSize))
+		query.Set("offset", strconv.Itoa(offset))
+		if status != "" {
+			query.Set("status", string(status))
+		}
+
+		var page struct {
+			Filtered int         `json:"filtered"`
+			Entities []RunResult `json:"entities"`
+		}
+		path := fmt.Sprintf("/result/%s?%s", c.Project, query.Encode())
+		if err := c.do(ctx, http.MethodGet, path, "", nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list the results of run %d: %w", runID, err)
+		}
+		results = append(results, page.Entities...)
+		if len(page.Entities) < resultsPageSize || len(results) >= page.Filtered {
+			return results, nil
+		}
+	}
+}
+
+// AddResultAttachments adds attachments, given by their hashes, to a result of the run.
+func (c *Client) AddResultAttachments(ctx context.Context, runID int64, hash string, attachments []string) error {
+	body, err := json.Marshal(map[string][]string{"attachments": attachments})
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/result/%s/%d/%s", c.Project, runID, hash)
+	if err := c.do(ctx, http.MethodPatch, path, "application/json", bytes.NewReader(body), nil); err != nil {
+		return fmt.Errorf("failed to attach files to result %s of run %d: %w", hash, runID, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package reporting
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// Status is the result of a spec or step, named as Qase names result statuses.
+type Status string
+
+const (
+	Passed  Status = "passed"
+	Failed  Status = "failed"
+	Blocked Status = "blocked"
+	Skipped Status = "skipped"
+	Invalid Status = "invalid"
+)
+
+// SpecStatus maps the state of a spec to a result status. Pending specs are blocked, and every failure state,
+// such as a panic, an interrupt or a timeout, is a failure.
+func SpecStatus(report ginkgo.SpecReport) Status {
+	switch {
+	case report.State.Is(types.SpecStateFailureStates):
+		return Failed
+	case report.State == types.SpecStatePassed:
+		return Passed
+	case report.State == types.SpecStatePending:
+		return Blocked
+	case report.State == types.SpecStateSkipped:
+		return Skipped
+	default:
+		return Invalid
+	}
+}
+
+// Step is a By() step of a spec.
+type Step struct {
+	Text   string
+	Status Status
+	Start  time.Time
+	// Duration is the time of the callback of By when it has one. Otherwise the step lasts until the next step
+	// starts or the spec ends.
+	Duration time.Duration
+}
+
+// Steps returns the By() steps of a spec in the order they ran, including the ones of setup and cleanup nodes. The
+// step the spec failed in is failed. A spec that failed before its first step has no failed step, and the steps
+// of a skipped spec are skipped.
+func Steps(report ginkgo.SpecReport) []Step {
+	var starts []types.SpecEvent
+	for _, event := range report.SpecEvents {
+		if event.SpecEventType == types.SpecEventByStart {
+			starts = append(starts, event)
+		}
+	}
+
+	failed := report.State.Is(types.SpecStateFailureStates)
+	failedAt := report.Failure.TimelineLocation.Order
+	steps := make([]Step, 0, len(starts))
+	for i, start := range starts {
+		step := Step{Text: start.Message, Status: Passed, Start: start.TimelineLocation.Time}
+
+		end := report.EndTime
+		last := i == len(starts)-1
+		if !last {
+			end = starts[i+1].TimelineLocation.Time
+		}
+		step.Duration = end.Sub(step.Start)
+		if duration, ok := byDuration(report.SpecEvents, start); ok {
+			step.Duration = duration
+		}
+
+		switch {
+		case report.State == types.SpecStateSkipped:
+			step.Status = Skipped
+		case failed && failedAt >= start.TimelineLocation.Order && (last || failedAt < starts[i+1].TimelineLocation.Order):
+			step.Status = Failed
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// byDuration returns the duration of a By step with a callback, which Ginkgo records in the end event of the step.
+func byDuration(events types.SpecEvents, start types.SpecEvent) (time.Duration, bool) {
+	for _, event := range events {
+		if event.SpecEventType == types.SpecEventByEnd && event.Message == start.Message &&
+			event.TimelineLocation.Order > start.TimelineLocation.Order {
+			return event.Duration, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package reporting
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// specResult is a spec, or a failed suite node, as the suite reports show it.
+type specResult struct {
+	Text            string
+	NodeType        string
+	Location        string
+	Status          Status
+	State           string
+	Labels          []string
+	CaseIDs         []int64
+	Start           time.Time
+	Duration        time.Duration
+	Steps           []Step
+	Failure         string
+	FailureLocation string
+	// Attachments are the paths of the files attached with Attach, relative to the report when possible.
+	Attachments []string
+	Output      string
+}
+
+// ReportAfterSuite registers a ReportAfterSuite node writing a JUnit XML and an HTML report of the suite to
+// RESULTS_DIR, named after the random seed like the result journal. Suites call it once at the top level:
+//
+//	var _ = reporting.ReportAfterSuite()
+//
+// The reports show the environment when the suite calls RecordEnvironment.
+func ReportAfterSuite() bool {
+	return ginkgo.ReportAfterSuite("JUnit and HTML reports", func(report ginkgo.Report) {
+		// Specs pass without running in a dry run
+		if report.SuiteConfig.DryRun {
+			return
+		}
+		junitPath, htmlPath := reportPaths(report.SuiteConfig.RandomSeed)
+		if err := WriteReports(report, junitPath, htmlPath); err != nil {
+			ginkgo.Fail(fmt.Sprintf("Failed to write the suite reports: %v", err))
+		}
+		ginkgo.GinkgoWriter.Printf("Suite reports written to %s and %s\n", junitPath, htmlPath)
+	}, ginkgo.Offset(1))
+}
+
+// reportPaths returns the JUnit and HTML reports of a run in RESULTS_DIR.
+func reportPaths(seed int64) (string, string) {
+	dir := resultsDir()
+	return filepath.Join(dir, fmt.Sprintf("junit-%d.xml", seed)), filepath.Join(dir, fmt.Sprintf("report-%d.html", seed))
+}
+
+// WriteReports writes the JUnit XML report of a suite to junitPath and its HTML report to htmlPath.
+func WriteReports(report types.Report, junitPath, htmlPath string) error {
+	env := environmentOf(report)
+	return errors.Join(
+		writeJUnit(junitPath, report, specResults(report, filepath.Dir(junitPath)), env),
+		writeHTML(htmlPath, report, specResults(report, filepath.Dir(htmlPath)), env),
+	)
+}
+
+// specResults returns the specs of a suite report in the order they ran, with the suite nodes that failed.
+// Attachments are made relative to dir, where the report is written.
+func specResults(report types.Report, dir string) []specResult {
+	var results []specResult
+	for _, spec := range report.SpecReports {
+		if spec.LeafNodeType != types.NodeTypeIt && !spec.Failed() {
+			continue
+		}
+
+		result := specResult{
+			Text:        spec.FullText(),
+			NodeType:    spec.LeafNodeType.String(),
+			Location:    spec.LeafNodeLocation.String(),
+			Status:      SpecStatus(spec),
+			State:       spec.State.String(),
+			Labels:      spec.Labels(),
+			CaseIDs:     CaseIDs(spec),
+			Start:       spec.StartTime,
+			Duration:    spec.RunTime,
+			Steps:       Steps(spec),
+			Attachments: attachments(spec, dir),
+		}
+		if result.Text == "" {
+			result.Text = result.NodeType
+		}
+		// Skip() records its reason as failure message
+		if spec.Failure.Message != "" {
+			result.Failure = spec.Failure.Message
+			result.FailureLocation = spec.Failure.Location.String()
+		}
+		if spec.Failed() {
+			result.Output = spec.CombinedOutput()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// attachments returns the files attached to a spec, relative to dir when they are below it.
+func attachments(spec types.SpecReport, dir string) []string {
+	var paths []string
+	for _, entry := range spec.ReportEntries {
+		if entry.Name != AttachmentEntry {
+			continue
+		}
+		path := entry.Value.String()
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+			if absDir, err := filepath.Abs(dir); err == nil {
+				if rel, err := filepath.Rel(absDir, abs); err == nil && filepath.IsLocal(rel) {
+					path = rel
+				}
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,197 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDir is where scenario files live, relative to the project root.
+const DefaultDir = "tests/scenarios"
+
+const defaultTimeout = 2 * time.Minute
+
+// Workload kinds a scenario can expect.
+const (
+	KindDeployment  = "Deployment"
+	KindDaemonSet   = "DaemonSet"
+	KindStatefulSet = "StatefulSet"
+)
+
+// Readiness rules of expected workloads.
+const (
+	// ReadinessReady requires every replica of every matching workload to be ready. It is the default.
+	ReadinessReady = "ready"
+	// ReadinessExists only requires the workloads to exist.
+	ReadinessExists = "exists"
+)
+
+// Scenario declares what a chart must have deployed on the cluster. Files are YAML or JSON.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// QaseID is reported for the generated spec when set.
+	QaseID    int64    `yaml:"qaseID"`
+	Labels    []string `yaml:"labels"`
+	Chart     string   `yaml:"chart"`
+	Namespace string   `yaml:"namespace"`
+	// Timeout bounds how long the checks are retried, as a Go duration. It defaults to two minutes.
+	Timeout   string     `yaml:"timeout"`
+	Workloads []Workload `yaml:"workloads"`
+	// CRDs are CustomResourceDefinition names, such as prometheuses.monitoring.coreos.com.
+	CRDs    []string `yaml:"crds"`
+	Targets []Target `yaml:"targets"`
+	Metrics []Metric `yaml:"metrics"`
+
+	// File is the file the scenario was loaded from.
+	File string `yaml:"-"`
+}
+
+// Workload expects workloads of a kind whose names start with Prefix in the scenario namespace.
+type Workload struct {
+	Kind   string `yaml:"kind"`
+	Prefix string `yaml:"prefix"`
+	// Count is the exact number of matching workloads when set, otherwise at least MinCount and at least one.
+	Count     *int   `yaml:"count"`
+	MinCount  int    `yaml:"minCount"`
+	Readiness string `yaml:"readiness"`
+}
+
+// Target expects Prometheus to scrape a ServiceMonitor or PodMonitor with every target up. Namespace defaults to
+// the scenario namespace.
+type Target struct {
+	ServiceMonitor string `yaml:"serviceMonitor"`
+	PodMonitor     string `yaml:"podMonitor"`
+	Namespace      string `yaml:"namespace"`
+}
+
+// Metric expects a PromQL query to return series, or none when Absent is set. Min and Max bound every returned
+// value.
+type Metric struct {
+	Query  string   `yaml:"query"`
+	Absent bool     `yaml:"absent"`
+	Min    *float64 `yaml:"min"`
+	Max    *float64 `yaml:"max"`
+}
+
+// Description is the spec text of the scenario, prefixed with its Qase ID when it has one.
+func (s *Scenario) Description() string {
+	if s.QaseID > 0 {
+		return fmt.Sprintf("[QASE-%d] %s", s.QaseID, s.Name)
+	}
+	return s.Name
+}
+
+// RetryTimeout returns the scenario timeout.
+func (s *Scenario) RetryTimeout() time.Duration {
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil || timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// Load reads and validates a scenario file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML too. Unknown fields are rejected, so a misspelled check fails instead of being skipped.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	s := &Scenario{}
+	if err := decoder.Decode(s); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("the file is empty")
+		}
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	s.File = path
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// LoadDir loads every .yaml, .yml and .json scenario of the directory, sorted by file name.
+func LoadDir(dir string) ([]*Scenario, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+
+	scenarios := make([]*Scenario, 0, len(files))
+	for _, file := range files {
+		s, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, s)
+	}
+	return scenarios, nil
+}
+
+func (s *Scenario) validate() error {
+	switch {
+	case s.Name == "":
+		return fmt.Errorf("name is required")
+	case s.Chart == "":
+		return fmt.Errorf("chart is required")
+	case s.Namespace == "":
+		return fmt.Errorf("namespace is required")
+	}
+	if s.Timeout != "" {
+		if _, err := time.ParseDuration(s.Timeout); err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+	}
+
+	for i, workload := range s.Workloads {
+		switch workload.Kind {
+		case KindDeployment, KindDaemonSet, KindStatefulSet:
+		default:
+			return fmt.Errorf("workloads[%d]: unknown kind %q", i, workload.Kind)
+		}
+		switch workload.Readiness {
+		case "", ReadinessReady, ReadinessExists:
+		default:
+			return fmt.Errorf("workloads[%d]: unknown readiness %q", i, workload.Readiness)
+		}
+		if workload.Prefix == "" {
+			return fmt.Errorf("workloads[%d]: prefix is required", i)
+		}
+	}
+	for i, target := range s.Targets {
+		if (target.ServiceMonitor == "") == (target.PodMonitor == "") {
+			return fmt.Errorf("targets[%d]: exactly one of serviceMonitor and podMonitor is required", i)
+		}
+	}
+	for i, metric := range s.Metrics {
+		if metric.Query == "" {
+			return fmt.Errorf("metrics[%d]: query is required", i)
+		}
+		if metric.Absent && (metric.Min != nil || metric.Max != nil) {
+			return fmt.Errorf("metrics[%d]: absent can not be combined with min or max", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScenario(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "monitoring.yaml",
+			content: `name: rancher-monitoring is deployed
+qaseID: 42
+labels: [monitoring]
+chart: rancher-monitoring
+namespace: cattle-monitoring-system
+timeout: 5m
+workloads:
+  - kind: Deployment
+    prefix: rancher-monitoring-operator
+    count: 1
+  - kind: DaemonSet
+    prefix: rancher-monitoring-prometheus-node-exporter
+    readiness: exists
+crds: [prometheuses.monitoring.coreos.com]
+targets:
+  - serviceMonitor: rancher-monitoring-kubelet
+    namespace: kube-system
+metrics:
+  - query: up{job="kubelet"}
+    min: 1
+`,
+		},
+		{
+			name: "json",
+			file: "monitoring.json",
+			content: `{
+  "name": "rancher-monitoring is deployed",
+  "qaseID": 42,
+  "labels": ["monitoring"],
+  "chart": "rancher-monitoring",
+  "namespace": "cattle-monitoring-system",
+  "timeout": "5m",
+  "workloads": [
+    {"kind": "Deployment", "prefix": "rancher-monitoring-operator", "count": 1},
+    {"kind": "DaemonSet", "prefix": "rancher-monitoring-prometheus-node-exporter", "readiness": "exists"}
+  ],
+  "crds": ["prometheuses.monitoring.coreos.com"],
+  "targets": [{"serviceMonitor": "rancher-monitoring-kubelet", "namespace": "kube-system"}],
+  "metrics": [{"query": "up{job=\"kubelet\"}", "min": 1}]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScenario(t, t.TempDir(), tt.file, tt.content)
+			s, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.File != path {
+				t.Errorf("got file %q, want %q", s.File, path)
+			}
+			if got, want := s.Description(), "[QASE-42] rancher-monitoring is deployed"; got != want {
+				t.Errorf("got description %q, want %q", got, want)
+			}
+			if got := s.RetryTimeout(); got != 5*time.Minute {
+				t.Errorf("got timeout %s, want 5m", got)
+			}
+			if len(s.Workloads) != 2 || s.Workloads[0].Count == nil || *s.Workloads[0].Count != 1 || s.Workloads[1].Readiness != ReadinessExists {
+				t.Errorf("got workloads %+v", s.Workloads)
+			}
+			if len(s.Targets) != 1 || s.Targets[0].ServiceMonitor != "rancher-monitoring-kubelet" || s.Targets[0].Namespace != "kube-system" {
+				t.Errorf("got targets %+v", s.Targets)
+			}
+			if len(s.Metrics) != 1 || s.Metrics[0].Query != `up{job="kubelet"}` || s.Metrics[0].Min == nil || *s.Metrics[0].Min != 1 {
+				t.Errorf("got metrics %+v", s.Metrics)
+			}
+		})
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	path := writeScenario(t, t.TempDir(), "minimal.yaml", "name: minimal\nchart: rancher-logging\nnamespace: cattle-logging-system\n")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Description(); got != "minimal" {
+		t.Errorf("got description %q, want minimal", got)
+	}
+	if got := s.RetryTimeout(); got != defaultTimeout {
+		t.Errorf("got timeout %s, want %s", got, defaultTimeout)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	const header = "name: invalid\nchart: rancher-monitoring\nnamespace: cattle-monitoring-system\n"
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "empty", file: "empty.yaml", content: "", want: "empty"},
+		{name: "malformed yaml", file: "malformed.yaml", content: "name: [", want: "failed to parse"},
+		{name: "malformed json", file: "malformed.json", content: `{"name": "invalid",`, want: "failed to parse"},
+		{name: "unknown yaml field", file: "unknown.yaml", content: header + "metric:\n  - query: up\n", want: "metric"},
+		{name: "unknown nested yaml field", file: "nested.yaml", content: header + "workloads:\n  - kind: Deployment\n    prefx: operator\n", want: "prefx"},
+		{name: "unknown json field", file: "unknown.json", content: `{"name": "invalid", "chart": "rancher-monitoring", "namespace": "cattle-monitoring-system", "target": []}`, want: "target"},
+		{name: "missing name", file: "name.yaml", content: "chart: rancher-monitoring\nnamespace: cattle-monitoring-system\n", want: "name is required"},
+		{name: "missing chart", file: "chart.json", content: `{"name": "invalid", "namespace": "cattle-monitoring-system"}`, want: "chart is required"},
+		{name: "missing namespace", file: "namespace.yaml", content: "name: invalid\nchart: rancher-monitoring\n", want: "namespace is required"},
+		{name: "invalid timeout", file: "timeout.yaml", content: header + "timeout: 5 minutes\n", want: "timeout"},
+		{name: "unknown workload kind", file: "kind.yaml", content: header + "workloads:\n  - kind: Job\n    prefix: job\n", want: `unknown kind "Job"`},
+		{name: "unknown readiness", file: "readiness.yaml", content: header + "workloads:\n  - kind: Deployment\n    prefix: operator\n    readiness: healthy\n", want: `unknown readiness "healthy"`},
+		{name: "missing workload prefix", file: "prefix.yaml", content: header + "workloads:\n  - kind: Deployment\n", want: "prefix is required"},
+		{name: "target without a monitor", file: "target.yaml", content: header + "targets:\n  - namespace: kube-system\n", want: "exactly one of"},
+		{name: "target with both monitors", file: "targets.json", content: `{"name": "invalid", "chart": "rancher-monitoring", "namespace": "cattle-monitoring-system", "targets": [{"serviceMonitor": "a", "podMonitor": "b"}]}`, want: "exactly one of"},
+		{name: "metric without a query", file: "query.yaml", content: header + "metrics:\n  - min: 1\n", want: "query is required"},
+		{name: "absent metric with bounds", file: "absent.yaml", content: header + "metrics:\n  - query: up\n    absent: true\n    max: 0\n", want: "absent can not be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScenario(t, t.TempDir(), tt.file, tt.content)
+			s, err := Load(path)
+			if err == nil {
+				t.Fatalf("Load = %+v, want an error", s)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %q, want it to contain %q", err, tt.want)
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("got error %q, want it to name the file", err)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeScenario(t, dir, "b.json", `{"name": "b", "chart": "rancher-logging", "namespace": "cattle-logging-system"}`)
+	writeScenario(t, dir, "a.yml", "name: a\nchart: rancher-monitoring\nnamespace: cattle-monitoring-system\n")
+	writeScenario(t, dir, "README.md", "not a scenario")
+	if err := os.Mkdir(filepath.Join(dir, "c.yaml"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range scenarios {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "a,b" {
+		t.Errorf("got scenarios %s, want a,b", got)
+	}
+
+	writeScenario(t, dir, "d.yaml", "name: d\n")
+	if _, err := LoadDir(dir); err == nil {
+		t.Error("LoadDir succeeded with an invalid scenario, want an error")
+	}
+}
+
+// The scenarios shipped with the repository must keep loading.
+func TestLoadDefaultDir(t *testing.T) {
+	scenarios, err := LoadDir(filepath.Join("..", "..", "..", DefaultDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) == 0 {
+		t.Errorf("no scenarios in %s", DefaultDir)
+	}
+}
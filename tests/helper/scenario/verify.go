@@ -0,0 +1,183 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/promclient"
+	"github.com/rancher/shepherd/clients/rancher"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	workloadGVRs = map[string]schema.GroupVersionResource{
+		KindDeployment:  {Group: "apps", Version: "v1", Resource: "deployments"},
+		KindDaemonSet:   {Group: "apps", Version: "v1", Resource: "daemonsets"},
+		KindStatefulSet: {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	}
+	crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+)
+
+// ChartInstalled reports whether the scenario chart is installed in the scenario namespace.
+func (s *Scenario) ChartInstalled(client *rancher.Client, clusterID string) (bool, error) {
+	status, err := extencharts.GetChartStatus(client, clusterID, s.Namespace, s.Chart)
+	if err != nil {
+		return false, err
+	}
+	return status.IsAlreadyInstalled, nil
+}
+
+// Verify runs every check of the scenario against the cluster and returns one line per unmet expectation.
+// Prometheus is only queried when the scenario has targets or metrics.
+func (s *Scenario) Verify(client *rancher.Client, clusterID string) ([]string, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, workload := range s.Workloads {
+		found, err := s.verifyWorkload(dynamicClient, workload)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+
+	for _, crd := range s.CRDs {
+		_, err := dynamicClient.Resource(crdGVR).Get(context.TODO(), crd, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			problems = append(problems, fmt.Sprintf("CRD %s is missing", crd))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.Targets) == 0 && len(s.Metrics) == 0 {
+		return problems, nil
+	}
+	prom, err := promclient.NewClient(promclient.RancherMonitoringURL(client.RancherConfig.Host, clusterID), client.RancherConfig.AdminToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var monitors []promclient.Monitor
+	var namespaces []string
+	for _, target := range s.Targets {
+		namespace := target.Namespace
+		if namespace == "" {
+			namespace = s.Namespace
+		}
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+		if target.ServiceMonitor != "" {
+			monitors = append(monitors, promclient.ServiceMonitor(namespace, target.ServiceMonitor))
+		} else {
+			monitors = append(monitors, promclient.PodMonitor(namespace, target.PodMonitor))
+		}
+	}
+	if len(monitors) > 0 {
+		// The other monitors of the same namespaces must produce targets too, even though they are not listed
+		kubeClient, err := kube.NewClient(client, clusterID)
+		if err != nil {
+			return nil, err
+		}
+		found, err := prom.CheckNamespaceMonitors(context.TODO(), kubeClient, namespaces, monitors...)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+
+	for _, metric := range s.Metrics {
+		found, err := verifyMetric(prom, metric)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+	return problems, nil
+}
+
+func (s *Scenario) verifyWorkload(dynamicClient dynamic.Interface, workload Workload) ([]string, error) {
+	list, err := dynamicClient.Resource(workloadGVRs[workload.Kind]).Namespace(s.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	matched := 0
+	for _, item := range list.Items {
+		if !strings.HasPrefix(item.GetName(), workload.Prefix) {
+			continue
+		}
+		matched++
+		if workload.Readiness == ReadinessExists {
+			continue
+		}
+		if desired, ready := readyReplicas(workload.Kind, &item); desired == 0 || ready != desired {
+			problems = append(problems, fmt.Sprintf("%s %s/%s has %d of %d replicas ready", workload.Kind, s.Namespace, item.GetName(), ready, desired))
+		}
+	}
+
+	description := fmt.Sprintf("%s %s/%s*", workload.Kind, s.Namespace, workload.Prefix)
+	switch {
+	case workload.Count != nil && matched != *workload.Count:
+		problems = append(problems, fmt.Sprintf("found %d %s, expected %d", matched, description, *workload.Count))
+	case workload.Count == nil && matched < max(workload.MinCount, 1):
+		problems = append(problems, fmt.Sprintf("found %d %s, expected at least %d", matched, description, max(workload.MinCount, 1)))
+	}
+	return problems, nil
+}
+
+// readyReplicas returns the desired and ready replicas of a workload. Daemonsets count scheduled pods.
+func readyReplicas(kind string, item *unstructured.Unstructured) (desired, ready int64) {
+	if kind == KindDaemonSet {
+		desired, _, _ = unstructured.NestedInt64(item.Object, "status", "desiredNumberScheduled")
+		ready, _, _ = unstructured.NestedInt64(item.Object, "status", "numberReady")
+		return desired, ready
+	}
+	desired, _, _ = unstructured.NestedInt64(item.Object, "spec", "replicas")
+	ready, _, _ = unstructured.NestedInt64(item.Object, "status", "readyReplicas")
+	return desired, ready
+}
+
+func verifyMetric(prom *promclient.Client, metric Metric) ([]string, error) {
+	result, err := prom.Query(metric.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	if metric.Absent {
+		if len(*result) > 0 {
+			return []string{fmt.Sprintf("query %s returned %d series, expected none", metric.Query, len(*result))}, nil
+		}
+		return nil, nil
+	}
+	if len(*result) == 0 {
+		return []string{fmt.Sprintf("query %s returned no series", metric.Query)}, nil
+	}
+
+	var problems []string
+	for _, sample := range *result {
+		value := float64(sample.Value)
+		if metric.Min != nil && value < *metric.Min {
+			problems = append(problems, fmt.Sprintf("query %s returned %v for %s, expected at least %v", metric.Query, value, sample.Metric, *metric.Min))
+		}
+		if metric.Max != nil && value > *metric.Max {
+			problems = append(problems, fmt.Sprintf("query %s returned %v for %s, expected at most %v", metric.Query, value, sample.Metric, *metric.Max))
+		}
+	}
+	return problems, nil
+}
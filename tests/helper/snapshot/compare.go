@@ -0,0 +1,311 @@
+package snapshot
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// IgnoreRule excludes expected drift from a comparison. Empty fields match anything. When FieldPaths is empty the
+// matching objects are ignored entirely, otherwise only the listed dot separated paths are removed before comparing.
+type IgnoreRule struct {
+	Group      string
+	Kind       string
+	Namespace  string
+	NameRegexp string
+	FieldPaths []string
+}
+
+// DefaultIgnoreRules cover drift that every restore produces: the API server populates the metadata of every
+// object it writes, status is owned by controllers and login tokens are created by the test client itself while the
+// restore runs.
+var DefaultIgnoreRules = []IgnoreRule{
+	{FieldPaths: []string{
+		"metadata.resourceVersion",
+		"metadata.uid",
+		"metadata.managedFields",
+		"metadata.creationTimestamp",
+		"metadata.deletionTimestamp",
+		"metadata.deletionGracePeriodSeconds",
+		"metadata.generation",
+		"metadata.selfLink",
+	}},
+	{FieldPaths: []string{"status"}},
+	{FieldPaths: []string{"metadata.annotations.kubectl\\.kubernetes\\.io/last-applied-configuration"}},
+	{Group: "management.cattle.io", Kind: "Token"},
+	{Group: "management.cattle.io", Kind: "UserAttribute"},
+}
+
+// GroupKey groups report entries by kind and namespace.
+type GroupKey struct {
+	GVK       string
+	Namespace string
+}
+
+// ChangedObject describes an object present in both snapshots whose content differs.
+type ChangedObject struct {
+	Name   string
+	Fields []string
+}
+
+// GroupDiff lists the differences found for one kind in one namespace.
+type GroupDiff struct {
+	Missing []string
+	Extra   []string
+	Changed []ChangedObject
+}
+
+// Report is the outcome of comparing a pre-backup snapshot with a post-restore snapshot.
+type Report struct {
+	Groups map[GroupKey]*GroupDiff
+}
+
+// Compare returns the objects that are missing, extra or changed in after when compared with before.
+func Compare(before, after *Snapshot, ignore []IgnoreRule) (*Report, error) {
+	matchers, err := compileIgnoreRules(ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Groups: map[GroupKey]*GroupDiff{}}
+
+	for _, key := range before.Keys() {
+		ref := before.Refs[key]
+		if skipObject(matchers, ref) {
+			continue
+		}
+		afterObj, found := after.Objects[key]
+		if !found {
+			diff := report.group(ref)
+			diff.Missing = append(diff.Missing, ref.Name)
+			continue
+		}
+
+		beforeObj := stripIgnoredFields(matchers, ref, before.Objects[key])
+		afterObj = stripIgnoredFields(matchers, ref, afterObj)
+		if fields := diffFields("", beforeObj, afterObj); len(fields) > 0 {
+			diff := report.group(ref)
+			diff.Changed = append(diff.Changed, ChangedObject{Name: ref.Name, Fields: fields})
+		}
+	}
+
+	for _, key := range after.Keys() {
+		ref := after.Refs[key]
+		if skipObject(matchers, ref) {
+			continue
+		}
+		if _, found := before.Objects[key]; !found {
+			diff := report.group(ref)
+			diff.Extra = append(diff.Extra, ref.Name)
+		}
+	}
+	return report, nil
+}
+
+func (r *Report) group(ref ObjectRef) *GroupDiff {
+	key := GroupKey{GVK: ref.TypeString(), Namespace: ref.Namespace}
+	if _, ok := r.Groups[key]; !ok {
+		r.Groups[key] = &GroupDiff{}
+	}
+	return r.Groups[key]
+}
+
+// Missing returns every object present before the backup that is gone after the restore.
+func (r *Report) Missing() []string {
+	return r.collect(func(d *GroupDiff) []string { return d.Missing })
+}
+
+// Extra returns every object present after the restore that did not exist before the backup.
+func (r *Report) Extra() []string {
+	return r.collect(func(d *GroupDiff) []string { return d.Extra })
+}
+
+// Changed returns every object whose content differs between the two snapshots.
+func (r *Report) Changed() []string {
+	return r.collect(func(d *GroupDiff) []string {
+		var names []string
+		for _, changed := range d.Changed {
+			names = append(names, changed.Name)
+		}
+		return names
+	})
+}
+
+func (r *Report) collect(pick func(*GroupDiff) []string) []string {
+	var result []string
+	for _, key := range r.sortedKeys() {
+		for _, name := range pick(r.Groups[key]) {
+			result = append(result, formatName(key, name))
+		}
+	}
+	return result
+}
+
+// Empty reports whether the two snapshots matched.
+func (r *Report) Empty() bool {
+	return len(r.Groups) == 0
+}
+
+// String renders the report grouped by kind and namespace.
+func (r *Report) String() string {
+	if r.Empty() {
+		return "no differences found"
+	}
+	var sb strings.Builder
+	for _, key := range r.sortedKeys() {
+		diff := r.Groups[key]
+		if key.Namespace == "" {
+			fmt.Fprintf(&sb, "%s:\n", key.GVK)
+		} else {
+			fmt.Fprintf(&sb, "%s in namespace %s:\n", key.GVK, key.Namespace)
+		}
+		for _, name := range diff.Missing {
+			fmt.Fprintf(&sb, "  missing: %s\n", name)
+		}
+		for _, name := range diff.Extra {
+			fmt.Fprintf(&sb, "  extra:   %s\n", name)
+		}
+		for _, changed := range diff.Changed {
+			fmt.Fprintf(&sb, "  changed: %s (%s)\n", changed.Name, strings.Join(changed.Fields, ", "))
+		}
+	}
+	return sb.String()
+}
+
+func (r *Report) sortedKeys() []GroupKey {
+	keys := make([]GroupKey, 0, len(r.Groups))
+	for key := range r.Groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].GVK != keys[j].GVK {
+			return keys[i].GVK < keys[j].GVK
+		}
+		return keys[i].Namespace < keys[j].Namespace
+	})
+	return keys
+}
+
+func formatName(key GroupKey, name string) string {
+	if key.Namespace == "" {
+		return fmt.Sprintf("%s %s", key.GVK, name)
+	}
+	return fmt.Sprintf("%s %s/%s", key.GVK, key.Namespace, name)
+}
+
+type ignoreMatcher struct {
+	rule       IgnoreRule
+	nameRegexp *regexp.Regexp
+}
+
+func compileIgnoreRules(rules []IgnoreRule) ([]ignoreMatcher, error) {
+	matchers := make([]ignoreMatcher, 0, len(rules))
+	for _, rule := range rules {
+		re, err := compileOptional(rule.NameRegexp)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, ignoreMatcher{rule: rule, nameRegexp: re})
+	}
+	return matchers, nil
+}
+
+func (m ignoreMatcher) matches(ref ObjectRef) bool {
+	if m.rule.Group != "" && m.rule.Group != ref.Group {
+		return false
+	}
+	if m.rule.Kind != "" && m.rule.Kind != ref.Kind {
+		return false
+	}
+	if m.rule.Namespace != "" && m.rule.Namespace != ref.Namespace {
+		return false
+	}
+	if m.nameRegexp != nil && !m.nameRegexp.MatchString(ref.Name) {
+		return false
+	}
+	return true
+}
+
+func skipObject(matchers []ignoreMatcher, ref ObjectRef) bool {
+	for _, m := range matchers {
+		if len(m.rule.FieldPaths) == 0 && m.matches(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripIgnoredFields(matchers []ignoreMatcher, ref ObjectRef, obj map[string]interface{}) map[string]interface{} {
+	stripped := obj
+	copied := false
+	for _, m := range matchers {
+		if len(m.rule.FieldPaths) == 0 || !m.matches(ref) {
+			continue
+		}
+		if !copied {
+			stripped = (&unstructured.Unstructured{Object: obj}).DeepCopy().Object
+			copied = true
+		}
+		for _, path := range m.rule.FieldPaths {
+			unstructured.RemoveNestedField(stripped, splitFieldPath(path)...)
+		}
+	}
+	return stripped
+}
+
+// splitFieldPath splits a dot separated path, treating "\." as a literal dot inside a key.
+func splitFieldPath(path string) []string {
+	const placeholder = "\x00"
+	escaped := strings.ReplaceAll(path, `\.`, placeholder)
+	parts := strings.Split(escaped, ".")
+	for i := range parts {
+		parts[i] = strings.ReplaceAll(parts[i], placeholder, ".")
+	}
+	return parts
+}
+
+// diffFields returns the paths at which before and after differ. A missing map equals an empty one, as left behind
+// when an ignore rule removes its only key.
+func diffFields(prefix string, before, after interface{}) []string {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if before == nil && afterIsMap && len(afterMap) == 0 || after == nil && beforeIsMap && len(beforeMap) == 0 {
+		return nil
+	}
+	if !beforeIsMap || !afterIsMap {
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+		if prefix == "" {
+			return []string{"."}
+		}
+		return []string{prefix}
+	}
+
+	keys := map[string]struct{}{}
+	for key := range beforeMap {
+		keys[key] = struct{}{}
+	}
+	for key := range afterMap {
+		keys[key] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var fields []string
+	for _, key := range sortedKeys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fields = append(fields, diffFields(path, beforeMap[key], afterMap[key])...)
+	}
+	return fields
+}
@@ -0,0 +1,262 @@
+package snapshot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// object returns the content of an object with the given name and extra top level fields.
+func object(namespace, name string, fields map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{"name": name, "resourceVersion": "1"}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	content := map[string]interface{}{"metadata": metadata}
+	for key, value := range fields {
+		content[key] = value
+	}
+	return content
+}
+
+// entry is an object of a snapshot.
+type entry struct {
+	ref     ObjectRef
+	content map[string]interface{}
+}
+
+// snapshotOf returns a snapshot of the objects.
+func snapshotOf(objects ...entry) *Snapshot {
+	s := &Snapshot{Objects: map[string]map[string]interface{}{}, Refs: map[string]ObjectRef{}}
+	for _, object := range objects {
+		s.Refs[object.ref.String()] = object.ref
+		s.Objects[object.ref.String()] = object.content
+	}
+	return s
+}
+
+var (
+	userRef   = ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "User", Resource: "users", Name: "u-1"}
+	secretRef = ObjectRef{Version: "v1", Kind: "Secret", Resource: "secrets", Namespace: "cattle-global-data", Name: "creds"}
+	tokenRef  = ObjectRef{Group: "management.cattle.io", Version: "v3", Kind: "Token", Resource: "tokens", Name: "token-1"}
+)
+
+func TestCompare(t *testing.T) {
+	user := entry{userRef, object("", "u-1", map[string]interface{}{"username": "admin", "enabled": true})}
+	secret := entry{secretRef, object("cattle-global-data", "creds", map[string]interface{}{"data": map[string]interface{}{"key": "dmFsdWU="}})}
+
+	tests := []struct {
+		name    string
+		before  []entry
+		after   []entry
+		ignore  []IgnoreRule
+		missing []string
+		extra   []string
+		changed map[string][]string
+	}{
+		{
+			name:   "identical",
+			before: []entry{user, secret},
+			after:  []entry{user, secret},
+		},
+		{
+			name:    "missing object",
+			before:  []entry{user, secret},
+			after:   []entry{user},
+			missing: []string{"v1/Secret cattle-global-data/creds"},
+		},
+		{
+			name:   "extra object",
+			before: []entry{user},
+			after:  []entry{user, secret},
+			extra:  []string{"v1/Secret cattle-global-data/creds"},
+		},
+		{
+			name:   "changed nested field",
+			before: []entry{secret},
+			after: []entry{
+				{secretRef, object("cattle-global-data", "creds", map[string]interface{}{"data": map[string]interface{}{"key": "b3RoZXI=", "new": "eA=="}})},
+			},
+			changed: map[string][]string{"creds": {"data.key", "data.new"}},
+		},
+		{
+			name:    "changed type",
+			before:  []entry{user},
+			after:   []entry{{userRef, object("", "u-1", map[string]interface{}{"username": "admin", "enabled": "true"})}},
+			changed: map[string][]string{"u-1": {"enabled"}},
+		},
+		{
+			name:   "metadata, status and last applied configuration ignored",
+			before: []entry{{userRef, object("", "u-1", map[string]interface{}{"username": "admin", "status": map[string]interface{}{"ready": false}})}},
+			after: []entry{{userRef, map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":            "u-1",
+					"resourceVersion": "2",
+					"uid":             "b",
+					"managedFields":   []interface{}{map[string]interface{}{"manager": "backup-restore-operator"}},
+					"annotations":     map[string]interface{}{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+				},
+				"username": "admin",
+				"status":   map[string]interface{}{"ready": true},
+			}}},
+			ignore: DefaultIgnoreRules,
+		},
+		{
+			name:    "status compared without rules",
+			before:  []entry{user},
+			after:   []entry{{userRef, object("", "u-1", map[string]interface{}{"username": "admin", "enabled": true, "status": map[string]interface{}{"ready": true}})}},
+			changed: map[string][]string{"u-1": {"status"}},
+		},
+		{
+			name:   "ignored kind",
+			before: []entry{{tokenRef, object("", "token-1", nil)}},
+			after:  []entry{},
+			ignore: DefaultIgnoreRules,
+		},
+		{
+			name:   "field path with dots",
+			before: []entry{{userRef, object("", "u-1", map[string]interface{}{"data": map[string]interface{}{"a.b": "1", "a": map[string]interface{}{"b": "1"}}})}},
+			after:  []entry{{userRef, object("", "u-1", map[string]interface{}{"data": map[string]interface{}{"a.b": "2", "a": map[string]interface{}{"b": "2"}}})}},
+			ignore: []IgnoreRule{{FieldPaths: []string{`data.a\.b`}}},
+			// The escaped path only removes the key holding a dot, the nested a.b is still compared.
+			changed: map[string][]string{"u-1": {"data.a.b"}},
+		},
+		{
+			name:    "rule scoped by namespace and name",
+			before:  []entry{user, secret},
+			after:   []entry{},
+			ignore:  []IgnoreRule{{Namespace: "cattle-global-data", NameRegexp: "^cred"}},
+			missing: []string{"management.cattle.io/v3/User u-1"},
+		},
+		{
+			name:    "rule scoped by group",
+			before:  []entry{user, secret},
+			after:   []entry{},
+			ignore:  []IgnoreRule{{Group: "management.cattle.io", Kind: "Secret"}},
+			missing: []string{"management.cattle.io/v3/User u-1", "v1/Secret cattle-global-data/creds"},
+		},
+		{
+			name:    "field rule scoped by kind",
+			before:  []entry{{userRef, object("", "u-1", map[string]interface{}{"spec": "a"})}, {secretRef, object("cattle-global-data", "creds", map[string]interface{}{"spec": "a"})}},
+			after:   []entry{{userRef, object("", "u-1", map[string]interface{}{"spec": "b"})}, {secretRef, object("cattle-global-data", "creds", map[string]interface{}{"spec": "b"})}},
+			ignore:  []IgnoreRule{{Kind: "User", FieldPaths: []string{"spec"}}},
+			changed: map[string][]string{"creds": {"spec"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Compare(snapshotOf(tt.before...), snapshotOf(tt.after...), tt.ignore)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := report.Missing(); !reflect.DeepEqual(got, tt.missing) {
+				t.Errorf("got missing %q, want %q", got, tt.missing)
+			}
+			if got := report.Extra(); !reflect.DeepEqual(got, tt.extra) {
+				t.Errorf("got extra %q, want %q", got, tt.extra)
+			}
+			var changed map[string][]string
+			for _, diff := range report.Groups {
+				for _, object := range diff.Changed {
+					if changed == nil {
+						changed = map[string][]string{}
+					}
+					changed[object.Name] = object.Fields
+				}
+			}
+			if !reflect.DeepEqual(changed, tt.changed) {
+				t.Errorf("got changed %v, want %v", changed, tt.changed)
+			}
+			if want := tt.missing == nil && tt.extra == nil && tt.changed == nil; report.Empty() != want {
+				t.Errorf("got empty %t, want %t for report:\n%s", report.Empty(), want, report)
+			}
+		})
+	}
+}
+
+func TestCompareKeepsSnapshots(t *testing.T) {
+	content := object("", "u-1", map[string]interface{}{"status": map[string]interface{}{"ready": true}})
+	before := snapshotOf(entry{userRef, content})
+	if _, err := Compare(before, before, DefaultIgnoreRules); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := content["status"]; !found {
+		t.Error("Compare removed an ignored field from the snapshot")
+	}
+}
+
+func TestCompareInvalidRule(t *testing.T) {
+	if _, err := Compare(snapshotOf(), snapshotOf(), []IgnoreRule{{NameRegexp: "("}}); err == nil {
+		t.Error("Compare succeeded with an invalid name regexp, want an error")
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report, err := Compare(
+		snapshotOf(entry{userRef, object("", "u-1", nil)}, entry{secretRef, object("cattle-global-data", "creds", nil)}),
+		snapshotOf(entry{userRef, object("", "u-1", map[string]interface{}{"enabled": false})}),
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := report.String()
+	for _, want := range []string{
+		"management.cattle.io/v3/User:\n  changed: u-1 (enabled)\n",
+		"v1/Secret in namespace cattle-global-data:\n  missing: creds\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got report:\n%s\nwant it to contain:\n%s", got, want)
+		}
+	}
+	if got := (&Report{Groups: map[GroupKey]*GroupDiff{}}).String(); got != "no differences found" {
+		t.Errorf("got %q for an empty report", got)
+	}
+}
+
+func TestSplitFieldPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "status", want: []string{"status"}},
+		{path: "spec.template.spec", want: []string{"spec", "template", "spec"}},
+		{path: `metadata.annotations.kubectl\.kubernetes\.io/last-applied-configuration`, want: []string{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"}},
+		{path: `data.a\.b.c`, want: []string{"data", "a.b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := splitFieldPath(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after interface{}
+		want          []string
+	}{
+		{name: "equal", before: map[string]interface{}{"a": []interface{}{"x"}}, after: map[string]interface{}{"a": []interface{}{"x"}}},
+		{name: "scalar root", before: "a", after: "b", want: []string{"."}},
+		{name: "removed key", before: map[string]interface{}{"a": 1, "b": 2}, after: map[string]interface{}{"a": 1}, want: []string{"b"}},
+		{name: "list", before: map[string]interface{}{"rules": []interface{}{"get"}}, after: map[string]interface{}{"rules": []interface{}{"get", "list"}}, want: []string{"rules"}},
+		{
+			name:   "nested keys in order",
+			before: map[string]interface{}{"spec": map[string]interface{}{"b": 1, "a": map[string]interface{}{"c": 1}}},
+			after:  map[string]interface{}{"spec": map[string]interface{}{"b": 2, "a": map[string]interface{}{"c": 2}}},
+			want:   []string{"spec.a.c", "spec.b"},
+		},
+		{name: "missing and empty maps", before: map[string]interface{}{"a": map[string]interface{}{}}, after: map[string]interface{}{"b": map[string]interface{}{}}},
+		{name: "map replaced by scalar", before: map[string]interface{}{"spec": map[string]interface{}{}}, after: map[string]interface{}{"spec": "x"}, want: []string{"spec"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffFields("", tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
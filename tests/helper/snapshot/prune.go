@@ -0,0 +1,235 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// SentinelLabel marks every object created by CreatePruneSentinels.
+const SentinelLabel = "observability-e2e/prune-sentinel"
+
+// Sentinel is an object created after a backup to observe what a restore does with it.
+type Sentinel struct {
+	GVR             schema.GroupVersionResource
+	Kind            string
+	Namespace       string
+	Name            string
+	ResourceVersion string
+}
+
+// String formats the sentinel as kind namespace/name.
+func (s Sentinel) String() string {
+	if s.Namespace == "" {
+		return fmt.Sprintf("%s %s", s.Kind, s.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", s.Kind, s.Namespace, s.Name)
+}
+
+// PruneSentinels groups the sentinels by whether the resource set covers them.
+type PruneSentinels struct {
+	ClusterID string
+	Covered   []Sentinel
+	Outside   []Sentinel
+}
+
+// sentinelCandidate describes an object that may be used as a sentinel.
+type sentinelCandidate struct {
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	prefix    string
+	body      map[string]interface{}
+}
+
+// coveredCandidates are kinds the Rancher resource sets back up. Candidates the selected resource set does not
+// cover are dropped, so the list can be broader than any single set.
+var coveredCandidates = []sentinelCandidate{
+	{
+		gvr:    schema.GroupVersionResource{Group: "management.cattle.io", Version: "v3", Resource: "globalroles"},
+		kind:   "GlobalRole",
+		prefix: "prune-sentinel-gr",
+		body:   map[string]interface{}{"displayName": "prune sentinel", "rules": []interface{}{}},
+	},
+	{
+		gvr:    schema.GroupVersionResource{Group: "management.cattle.io", Version: "v3", Resource: "roletemplates"},
+		kind:   "RoleTemplate",
+		prefix: "prune-sentinel-rt",
+		body:   map[string]interface{}{"displayName": "prune sentinel", "context": "cluster", "rules": []interface{}{}},
+	},
+	{
+		gvr:       schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+		kind:      "Secret",
+		namespace: "cattle-global-data",
+		prefix:    "prune-sentinel-secret",
+		body:      map[string]interface{}{"type": "Opaque", "stringData": map[string]interface{}{"sentinel": "true"}},
+	},
+}
+
+// outsideCandidates are objects no Rancher resource set selects; a restore must leave them alone.
+var outsideCandidates = []sentinelCandidate{
+	{
+		gvr:       schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		kind:      "ConfigMap",
+		namespace: "default",
+		prefix:    "prune-sentinel-cm",
+		body:      map[string]interface{}{"data": map[string]interface{}{"sentinel": "true"}},
+	},
+}
+
+// CreatePruneSentinels creates sentinel objects inside and outside the named resource set. It is meant to be
+// called after the backup has completed and before the restore is created. The sentinels are returned even on error
+// so that whatever was created can still be deleted with DeletePruneSentinels.
+func CreatePruneSentinels(client *rancher.Client, clusterID, resourceSetName string) (*PruneSentinels, error) {
+	sentinels := &PruneSentinels{ClusterID: clusterID}
+	resourceSet, err := GetResourceSet(client, clusterID, resourceSetName)
+	if err != nil {
+		return sentinels, err
+	}
+
+	for _, candidate := range coveredCandidates {
+		name := namegen.AppendRandomString(candidate.prefix)
+		covered, err := resourceSet.Covers(candidate.gvr, candidate.kind, candidate.namespace, name, map[string]string{SentinelLabel: "true"})
+		if err != nil {
+			return sentinels, err
+		}
+		if !covered {
+			e2e.Logf("Resource set %s does not cover %s, not using it as a sentinel", resourceSetName, candidate.kind)
+			continue
+		}
+		sentinel, err := createSentinel(client, clusterID, candidate, name)
+		if err != nil {
+			return sentinels, err
+		}
+		sentinels.Covered = append(sentinels.Covered, sentinel)
+	}
+	if len(sentinels.Covered) == 0 {
+		return sentinels, fmt.Errorf("resource set %s does not cover any of the sentinel kinds", resourceSetName)
+	}
+
+	for _, candidate := range outsideCandidates {
+		name := namegen.AppendRandomString(candidate.prefix)
+		covered, err := resourceSet.Covers(candidate.gvr, candidate.kind, candidate.namespace, name, map[string]string{SentinelLabel: "true"})
+		if err != nil {
+			return sentinels, err
+		}
+		if covered {
+			return sentinels, fmt.Errorf("resource set %s unexpectedly covers %s in namespace %s", resourceSetName, candidate.kind, candidate.namespace)
+		}
+		sentinel, err := createSentinel(client, clusterID, candidate, name)
+		if err != nil {
+			return sentinels, err
+		}
+		sentinels.Outside = append(sentinels.Outside, sentinel)
+	}
+
+	e2e.Logf("Created %d covered and %d outside prune sentinels", len(sentinels.Covered), len(sentinels.Outside))
+	return sentinels, nil
+}
+
+func createSentinel(client *rancher.Client, clusterID string, candidate sentinelCandidate, name string) (Sentinel, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return Sentinel{}, fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range candidate.body {
+		obj.Object[key] = value
+	}
+	obj.SetAPIVersion(candidate.gvr.GroupVersion().String())
+	obj.SetKind(candidate.kind)
+	obj.SetName(name)
+	obj.SetNamespace(candidate.namespace)
+	obj.SetLabels(map[string]string{SentinelLabel: "true"})
+
+	created, err := dynamicClient.Resource(candidate.gvr).Namespace(candidate.namespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		return Sentinel{}, fmt.Errorf("failed to create sentinel %s %s: %w", candidate.kind, name, err)
+	}
+
+	return Sentinel{
+		GVR:             candidate.gvr,
+		Kind:            candidate.kind,
+		Namespace:       created.GetNamespace(),
+		Name:            created.GetName(),
+		ResourceVersion: created.GetResourceVersion(),
+	}, nil
+}
+
+// VerifyPruneSemantics checks the sentinels after a restore: covered sentinels must be gone when prune is true
+// and still present otherwise, and sentinels outside the resource set must be unchanged either way. Covered
+// sentinels are only checked for existence since Rancher controllers annotate the objects they own.
+func VerifyPruneSemantics(client *rancher.Client, sentinels *PruneSentinels, prune bool) error {
+	dynamicClient, err := client.GetDownStreamClusterClient(sentinels.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	var errs []error
+	for _, sentinel := range sentinels.Covered {
+		obj, err := dynamicClient.Resource(sentinel.GVR).Namespace(sentinel.Namespace).Get(context.TODO(), sentinel.Name, metav1.GetOptions{})
+		switch {
+		case prune && err == nil:
+			errs = append(errs, fmt.Errorf("sentinel %s survived a restore with prune enabled", sentinel))
+		case prune && k8serrors.IsNotFound(err):
+			e2e.Logf("Sentinel %s was pruned as expected", sentinel)
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to get sentinel %s: %w", sentinel, err))
+		default:
+			e2e.Logf("Sentinel %s survived as expected (resourceVersion %s)", sentinel, obj.GetResourceVersion())
+		}
+	}
+
+	for _, sentinel := range sentinels.Outside {
+		obj, err := dynamicClient.Resource(sentinel.GVR).Namespace(sentinel.Namespace).Get(context.TODO(), sentinel.Name, metav1.GetOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sentinel %s outside the resource set was affected by the restore: %w", sentinel, err))
+			continue
+		}
+		errs = append(errs, checkUntouched(sentinel, obj))
+	}
+	return errors.Join(errs...)
+}
+
+func checkUntouched(sentinel Sentinel, obj *unstructured.Unstructured) error {
+	if obj.GetResourceVersion() != sentinel.ResourceVersion {
+		return fmt.Errorf("sentinel %s was modified by the restore (resourceVersion %s -> %s)",
+			sentinel, sentinel.ResourceVersion, obj.GetResourceVersion())
+	}
+	e2e.Logf("Sentinel %s is untouched", sentinel)
+	return nil
+}
+
+// DeletePruneSentinels removes any sentinel left behind, ignoring the ones already pruned. It accepts the partial
+// result of a failed CreatePruneSentinels.
+func DeletePruneSentinels(client *rancher.Client, sentinels *PruneSentinels) error {
+	if sentinels == nil {
+		return nil
+	}
+	dynamicClient, err := client.GetDownStreamClusterClient(sentinels.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	all := make([]Sentinel, 0, len(sentinels.Covered)+len(sentinels.Outside))
+	all = append(all, sentinels.Covered...)
+	all = append(all, sentinels.Outside...)
+
+	var errs []error
+	for _, sentinel := range all {
+		err := dynamicClient.Resource(sentinel.GVR).Namespace(sentinel.Namespace).Delete(context.TODO(), sentinel.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete sentinel %s: %w", sentinel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
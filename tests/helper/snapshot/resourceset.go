@@ -0,0 +1,252 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+// ResourceSetGVR is the cluster scoped resource the backup-restore operator reads its selectors from.
+var ResourceSetGVR = schema.GroupVersionResource{Group: "resources.cattle.io", Version: "v1", Resource: "resourcesets"}
+
+// ResourceSelector mirrors the selector of a ResourceSet. It is declared locally, rather than taken from the
+// vendored operator API, so that fields added by newer operator releases (exclusions, field selectors) are not
+// silently dropped when the object is read from the cluster.
+type ResourceSelector struct {
+	APIVersion                string                `json:"apiVersion"`
+	Kinds                     []string              `json:"kinds,omitempty"`
+	KindsRegexp               string                `json:"kindsRegexp,omitempty"`
+	ExcludeKinds              []string              `json:"excludeKinds,omitempty"`
+	ResourceNames             []string              `json:"resourceNames,omitempty"`
+	ResourceNameRegexp        string                `json:"resourceNameRegexp,omitempty"`
+	ExcludeResourceNameRegexp string                `json:"excludeResourceNameRegexp,omitempty"`
+	Namespaces                []string              `json:"namespaces,omitempty"`
+	NamespaceRegexp           string                `json:"namespaceRegexp,omitempty"`
+	LabelSelectors            *metav1.LabelSelector `json:"labelSelectors,omitempty"`
+}
+
+// ResourceSet is the subset of the ResourceSet object needed to work out which objects a backup covers.
+type ResourceSet struct {
+	Name              string             `json:"-"`
+	ResourceSelectors []ResourceSelector `json:"resourceSelectors"`
+}
+
+// resolvedSelector is a ResourceSelector with its regular expressions compiled and its kinds resolved
+// against the API server.
+type resolvedSelector struct {
+	selector       ResourceSelector
+	resources      []metav1.APIResource
+	gv             schema.GroupVersion
+	nameRegexp     *regexp.Regexp
+	excludeName    *regexp.Regexp
+	namespaceRegex *regexp.Regexp
+	labelSelector  labels.Selector
+}
+
+// GetResourceSet fetches the named ResourceSet from the given cluster.
+func GetResourceSet(client *rancher.Client, clusterID, name string) (*ResourceSet, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	obj, err := dynamicClient.Resource(ResourceSetGVR).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource set %s: %w", name, err)
+	}
+
+	resourceSet := &ResourceSet{Name: name}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, resourceSet); err != nil {
+		return nil, fmt.Errorf("failed to convert resource set %s: %w", name, err)
+	}
+	return resourceSet, nil
+}
+
+// Covers reports whether an object with the given identity would be picked up by the resource set.
+func (r *ResourceSet) Covers(gvr schema.GroupVersionResource, kind, namespace, name string, objLabels map[string]string) (bool, error) {
+	for _, selector := range r.ResourceSelectors {
+		gv, err := schema.ParseGroupVersion(selector.APIVersion)
+		if err != nil {
+			return false, fmt.Errorf("invalid apiVersion %q in resource set %s: %w", selector.APIVersion, r.Name, err)
+		}
+		if gv != gvr.GroupVersion() {
+			continue
+		}
+
+		kindMatched, err := matchesKind(selector, metav1.APIResource{Name: gvr.Resource, Kind: kind})
+		if err != nil {
+			return false, err
+		}
+		if !kindMatched {
+			continue
+		}
+
+		resolved, err := compileSelector(selector)
+		if err != nil {
+			return false, err
+		}
+		matched, err := resolved.matchesObject(namespace, name, objLabels)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// resolve compiles every selector of the resource set and expands kinds into API resources using discovery.
+func (r *ResourceSet) resolve(restConfig *rest.Config) ([]resolvedSelector, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	var resolved []resolvedSelector
+	for _, selector := range r.ResourceSelectors {
+		compiled, err := compileSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+
+		resourceList, err := discoveryClient.ServerResourcesForGroupVersion(selector.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover resources for %s: %w", selector.APIVersion, err)
+		}
+
+		for _, resource := range resourceList.APIResources {
+			// Subresources such as deployments/status are never backed up on their own.
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			if !containsVerb(resource.Verbs, "list") {
+				continue
+			}
+			matched, err := matchesKind(selector, resource)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				compiled.resources = append(compiled.resources, resource)
+			}
+		}
+		resolved = append(resolved, compiled)
+	}
+	return resolved, nil
+}
+
+func compileSelector(selector ResourceSelector) (resolvedSelector, error) {
+	gv, err := schema.ParseGroupVersion(selector.APIVersion)
+	if err != nil {
+		return resolvedSelector{}, fmt.Errorf("invalid apiVersion %q: %w", selector.APIVersion, err)
+	}
+	compiled := resolvedSelector{selector: selector, gv: gv}
+
+	if compiled.nameRegexp, err = compileOptional(selector.ResourceNameRegexp); err != nil {
+		return resolvedSelector{}, err
+	}
+	if compiled.excludeName, err = compileOptional(selector.ExcludeResourceNameRegexp); err != nil {
+		return resolvedSelector{}, err
+	}
+	if compiled.namespaceRegex, err = compileOptional(selector.NamespaceRegexp); err != nil {
+		return resolvedSelector{}, err
+	}
+	if selector.LabelSelectors != nil {
+		if compiled.labelSelector, err = metav1.LabelSelectorAsSelector(selector.LabelSelectors); err != nil {
+			return resolvedSelector{}, fmt.Errorf("invalid label selector for %s: %w", selector.APIVersion, err)
+		}
+	}
+	return compiled, nil
+}
+
+func compileOptional(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp %q: %w", expr, err)
+	}
+	return re, nil
+}
+
+// matchesKind follows the operator: kinds and kindsRegexp are compared against both the kind and the
+// plural resource name, and a selector without either matches every resource of its group version.
+func matchesKind(selector ResourceSelector, resource metav1.APIResource) (bool, error) {
+	for _, excluded := range selector.ExcludeKinds {
+		if excluded == resource.Kind || excluded == resource.Name {
+			return false, nil
+		}
+	}
+	if selector.KindsRegexp == "" && len(selector.Kinds) == 0 {
+		return true, nil
+	}
+	for _, kind := range selector.Kinds {
+		if kind == resource.Kind || kind == resource.Name {
+			return true, nil
+		}
+	}
+	if selector.KindsRegexp != "" {
+		re, err := regexp.Compile(selector.KindsRegexp)
+		if err != nil {
+			return false, fmt.Errorf("invalid kindsRegexp %q: %w", selector.KindsRegexp, err)
+		}
+		return re.MatchString(resource.Kind) || re.MatchString(resource.Name), nil
+	}
+	return false, nil
+}
+
+func (s resolvedSelector) matchesObject(namespace, name string, objLabels map[string]string) (bool, error) {
+	if len(s.selector.Namespaces) > 0 || s.namespaceRegex != nil {
+		if !containsString(s.selector.Namespaces, namespace) &&
+			(s.namespaceRegex == nil || !s.namespaceRegex.MatchString(namespace)) {
+			return false, nil
+		}
+	}
+	if len(s.selector.ResourceNames) > 0 || s.nameRegexp != nil {
+		if !containsString(s.selector.ResourceNames, name) &&
+			(s.nameRegexp == nil || !s.nameRegexp.MatchString(name)) {
+			return false, nil
+		}
+	}
+	if s.excludeName != nil && s.excludeName.MatchString(name) {
+		return false, nil
+	}
+	if s.labelSelector != nil && !s.labelSelector.Matches(labels.Set(objLabels)) {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s resolvedSelector) matches(obj *unstructured.Unstructured) (bool, error) {
+	return s.matchesObject(obj.GetNamespace(), obj.GetName(), obj.GetLabels())
+}
+
+func containsVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
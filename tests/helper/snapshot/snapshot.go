@@ -0,0 +1,201 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// ObjectRef identifies a single object inside a snapshot.
+type ObjectRef struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Labels are the labels of the object when it was referenced, which label-scoped resource sets select on.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GVK returns the group/version/kind of the referenced object.
+func (o ObjectRef) GVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: o.Group, Version: o.Version, Kind: o.Kind}
+}
+
+// GVR returns the group/version/resource used to reach the object with the dynamic client.
+func (o ObjectRef) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: o.Group, Version: o.Version, Resource: o.Resource}
+}
+
+// TypeString formats the group, version and kind as "management.cattle.io/v3/User".
+func (o ObjectRef) TypeString() string {
+	return o.GVK().GroupVersion().String() + "/" + o.Kind
+}
+
+// String formats the reference as its type followed by namespace/name.
+func (o ObjectRef) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s", o.TypeString(), o.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", o.TypeString(), o.Namespace, o.Name)
+}
+
+// Snapshot holds the normalized content of every object selected by a ResourceSet at a point in time.
+type Snapshot struct {
+	ResourceSetName string                            `json:"resourceSetName"`
+	ClusterID       string                            `json:"clusterID"`
+	Objects         map[string]map[string]interface{} `json:"objects"`
+	Refs            map[string]ObjectRef              `json:"refs"`
+}
+
+// Capture lists every object selected by the named ResourceSet on the given cluster and stores a normalized copy of it.
+func Capture(client *rancher.Client, clusterID, resourceSetName string) (*Snapshot, error) {
+	resourceSet, err := GetResourceSet(client, clusterID, resourceSetName)
+	if err != nil {
+		return nil, err
+	}
+
+	selectors, err := resourceSet.resolve(restConfigForCluster(client, clusterID))
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		ResourceSetName: resourceSetName,
+		ClusterID:       clusterID,
+		Objects:         map[string]map[string]interface{}{},
+		Refs:            map[string]ObjectRef{},
+	}
+
+	for _, selector := range selectors {
+		for _, resource := range selector.resources {
+			if err := snapshot.collect(dynamicClient, selector, resource); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	e2e.Logf("Captured %d objects covered by resource set %s", len(snapshot.Objects), resourceSetName)
+	return snapshot, nil
+}
+
+func (s *Snapshot) collect(dynamicClient dynamic.Interface, selector resolvedSelector, resource metav1.APIResource) error {
+	gvr := selector.gv.WithResource(resource.Name)
+	namespaces := []string{metav1.NamespaceAll}
+	if resource.Namespaced && len(selector.selector.Namespaces) > 0 && selector.namespaceRegex == nil {
+		namespaces = selector.selector.Namespaces
+	}
+
+	for _, namespace := range namespaces {
+		list, err := dynamicClient.Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", gvr.String(), err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			matched, err := selector.matches(obj)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+
+			ref := ObjectRef{
+				Group:     selector.gv.Group,
+				Version:   selector.gv.Version,
+				Kind:      resource.Kind,
+				Resource:  resource.Name,
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+				Labels:    obj.GetLabels(),
+			}
+			key := ref.String()
+			s.Refs[key] = ref
+			s.Objects[key] = normalize(obj)
+		}
+	}
+	return nil
+}
+
+// normalize copies the content of an object. Fields that are expected to change on every restore are left to the
+// ignore rules of Compare, except the owner uids, which sit inside a list no field path can reach.
+func normalize(obj *unstructured.Unstructured) map[string]interface{} {
+	content := obj.DeepCopy().Object
+
+	// Owner references carry the uid of the owner, which is regenerated when the owner is restored.
+	if owners, found, _ := unstructured.NestedSlice(content, "metadata", "ownerReferences"); found {
+		for _, owner := range owners {
+			if ownerMap, ok := owner.(map[string]interface{}); ok {
+				delete(ownerMap, "uid")
+			}
+		}
+		_ = unstructured.SetNestedSlice(content, owners, "metadata", "ownerReferences")
+	}
+	return content
+}
+
+// Keys returns the object keys of the snapshot in a stable order.
+func (s *Snapshot) Keys() []string {
+	keys := make([]string, 0, len(s.Objects))
+	for key := range s.Objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteFile stores the snapshot as JSON, which is handy to attach to a failed run.
+func (s *Snapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadFile loads a snapshot previously stored with WriteFile.
+func ReadFile(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// restConfigForCluster builds a rest config that reaches the cluster through the Rancher proxy using the admin token.
+func restConfigForCluster(client *rancher.Client, clusterID string) *rest.Config {
+	insecure := true
+	if client.RancherConfig.Insecure != nil {
+		insecure = *client.RancherConfig.Insecure
+	}
+	return &rest.Config{
+		Host:        fmt.Sprintf("https://%s/k8s/clusters/%s", client.RancherConfig.Host, clusterID),
+		BearerToken: client.RancherConfig.AdminToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: insecure,
+			CAFile:   client.RancherConfig.CAFile,
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// VerifyPresent checks that every referenced object covered by the named resource set exists on the cluster.
+// References the resource set does not back up are skipped, since a restore makes no promise about them. Coverage
+// is decided on the labels of the live object, or on the labels of the reference when the object is missing.
+func VerifyPresent(client *rancher.Client, clusterID, resourceSetName string, refs []ObjectRef) error {
+	resourceSet, err := GetResourceSet(client, clusterID, resourceSetName)
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get downstream client: %w", err)
+	}
+
+	var errs []error
+	checked := 0
+	for _, ref := range refs {
+		obj, getErr := dynamicClient.Resource(ref.GVR()).Namespace(ref.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		objLabels := ref.Labels
+		if getErr == nil {
+			objLabels = obj.GetLabels()
+		}
+		covered, err := resourceSet.Covers(ref.GVR(), ref.Kind, ref.Namespace, ref.Name, objLabels)
+		if err != nil {
+			return err
+		}
+		if !covered {
+			continue
+		}
+		checked++
+		if getErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref, getErr))
+		}
+	}
+
+	e2e.Logf("Verified %d of %d objects covered by resource set %s", checked, len(refs), resourceSetName)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+package upgrade
+
+import (
+	"fmt"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/observability-e2e/tests/helper/monitoring"
+	"github.com/rancher/observability-e2e/tests/helper/utils"
+	"github.com/rancher/shepherd/clients/rancher"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	clusterFlowSteveType   = "logging.banzaicloud.io.clusterflow"
+	clusterOutputSteveType = "logging.banzaicloud.io.clusteroutput"
+	loggingFixturePath     = "tests/helper/yamls/clusterOutputandClusterFlow.template.yaml"
+	// syslogHost is the syslog service of the installation suite. The output only has to exist, so it does not
+	// matter whether it is deployed.
+	syslogHost = "syslog-ng-service.cattle-logging-system.svc"
+)
+
+// The chart values used by the matrix are the ones of the existing before/after upgrade specs.
+var (
+	monitoringOpts = &charts.RancherMonitoringOpts{
+		IngressNginx:           true,
+		ControllerManager:      true,
+		Etcd:                   true,
+		Proxy:                  true,
+		Scheduler:              true,
+		PrometheusStorageClass: charts.LocalStorageClassName,
+	}
+	loggingOpts             = &charts.RancherLoggingOpts{AdditionalLoggingSources: true}
+	alertingOpts            = &charts.RancherAlertingOpts{SMS: true, Teams: false}
+	prometheusFederatorOpts = &charts.PrometheusFederatorOpts{EnablePodSecurity: false}
+)
+
+// MonitoringChart upgrades rancher-monitoring with a continuity fixture as seeded data: rules, alertmanager and
+// scrape configuration, a dashboard and recorded samples that must all survive the upgrade.
+func MonitoringChart() Chart {
+	var fixture *monitoring.ContinuityFixture
+	return Chart{
+		Name:        charts.RancherMonitoringName,
+		Namespace:   charts.RancherMonitoringNamespace,
+		Releases:    []string{charts.RancherMonitoringName},
+		CRDReleases: []string{charts.RancherMonitoringCRDName},
+		Install: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			if err := charts.DeployLocalStorage(client); err != nil {
+				return err
+			}
+			return charts.InstallRancherMonitoringChart(client, installOptions, monitoringOpts)
+		},
+		Upgrade: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.UpgradeRancherMonitoringChart(client, installOptions, monitoringOpts)
+		},
+		Seed: func(client *rancher.Client, clusterID string) error {
+			var err error
+			fixture, err = monitoring.BeforeUpgrade(client, clusterID)
+			if fixture != nil {
+				// Each hop creates its own fixture, which is deleted even when the hop fails before Verify
+				seeded := fixture
+				ginkgo.DeferCleanup(func() error {
+					return seeded.Cleanup(client)
+				})
+			}
+			return err
+		},
+		Verify: func(client *rancher.Client, clusterID string) error {
+			if err := verifyWorkloads(client, clusterID, charts.RancherMonitoringNamespace, true); err != nil {
+				return err
+			}
+			if err := fixture.AfterUpgrade(client); err != nil {
+				return err
+			}
+			return fixture.Cleanup(client)
+		},
+	}
+}
+
+// LoggingChart upgrades rancher-logging with a ClusterOutput and ClusterFlow as seeded data.
+func LoggingChart() Chart {
+	var fixture *utils.Fixture
+	return Chart{
+		Name:        charts.RancherLoggingName,
+		Namespace:   charts.RancherLoggingNamespace,
+		Releases:    []string{charts.RancherLoggingName},
+		CRDReleases: []string{charts.RancherLoggingCRDName},
+		Install: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.InstallRancherLoggingChart(client, installOptions, loggingOpts)
+		},
+		Upgrade: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.UpgradeRancherLoggingChart(client, installOptions, loggingOpts)
+		},
+		Seed: func(client *rancher.Client, clusterID string) error {
+			fixture = utils.NewFixture("upgrade-logging")
+			fixture.Values["host"] = syslogHost
+			// Each hop creates its own fixture, which is deleted even when the hop fails before Verify. Objects
+			// that were not created are skipped by the delete.
+			seeded := fixture
+			ginkgo.DeferCleanup(func() error {
+				return utils.DeleteYamlTemplate(client, utils.GetYamlPath(loggingFixturePath), seeded, "")
+			})
+			return utils.DeployLoggingClusterOutputAndClusterFlow(client, utils.GetYamlPath(loggingFixturePath), fixture)
+		},
+		Verify: func(client *rancher.Client, clusterID string) error {
+			if err := verifyWorkloads(client, clusterID, charts.RancherLoggingNamespace, true); err != nil {
+				return err
+			}
+			objectID := charts.RancherLoggingNamespace + "/" + fixture.Name
+			if err := verifyExists(client, clusterOutputSteveType, objectID); err != nil {
+				return err
+			}
+			if err := verifyExists(client, clusterFlowSteveType, objectID); err != nil {
+				return err
+			}
+			return utils.DeleteYamlTemplate(client, utils.GetYamlPath(loggingFixturePath), fixture, "")
+		},
+	}
+}
+
+// AlertingChart upgrades rancher-alerting-drivers. It has no user data of its own, so only the drivers are checked.
+func AlertingChart() Chart {
+	return Chart{
+		Name:      charts.RancherAlertingName,
+		Namespace: charts.RancherAlertingNamespace,
+		Releases:  []string{charts.RancherAlertingName},
+		Install: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.InstallRancherAlertingChart(client, installOptions, alertingOpts)
+		},
+		Upgrade: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.UpgradeRancherAlertingChart(client, installOptions, alertingOpts)
+		},
+		Verify: func(client *rancher.Client, clusterID string) error {
+			return verifyWorkloads(client, clusterID, charts.RancherAlertingNamespace, false)
+		},
+	}
+}
+
+// PrometheusFederatorChart upgrades prometheus-federator. It needs rancher-monitoring to be installed.
+func PrometheusFederatorChart() Chart {
+	return Chart{
+		Name:      charts.PrometheusFederatorName,
+		Namespace: charts.PrometheusFederatorNamespace,
+		Releases:  []string{charts.PrometheusFederatorName},
+		Install: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.InstallPrometheusFederatorChart(client, installOptions, prometheusFederatorOpts)
+		},
+		Upgrade: func(client *rancher.Client, installOptions *charts.InstallOptions) error {
+			return charts.UpgradePrometheusFederatorChart(client, installOptions, prometheusFederatorOpts)
+		},
+		Verify: func(client *rancher.Client, clusterID string) error {
+			return verifyWorkloads(client, clusterID, charts.PrometheusFederatorNamespace, false)
+		},
+	}
+}
+
+// verifyWorkloads waits for the deployments, and optionally the daemonsets, of the namespace to be available.
+func verifyWorkloads(client *rancher.Client, clusterID, namespace string, daemonSets bool) error {
+	if err := extencharts.WatchAndWaitDeployments(client, clusterID, namespace, metav1.ListOptions{}); err != nil {
+		return fmt.Errorf("deployments in %s are not available: %w", namespace, err)
+	}
+	if daemonSets {
+		if err := extencharts.WatchAndWaitDaemonSets(client, clusterID, namespace, metav1.ListOptions{}); err != nil {
+			return fmt.Errorf("daemonsets in %s are not available: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+// verifyExists checks that seeded data survived the upgrade.
+func verifyExists(client *rancher.Client, steveType, id string) error {
+	if _, err := client.Steve.SteveType(steveType).ByID(id); err != nil {
+		return fmt.Errorf("%s %s is missing after the upgrade: %w", steveType, id, err)
+	}
+	return nil
+}
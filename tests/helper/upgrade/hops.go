@@ -0,0 +1,113 @@
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rancher/observability-e2e/tests/helper/version"
+)
+
+// Strategies select which older versions are upgraded to the latest one.
+const (
+	// StrategyPreviousMinor upgrades from the newest release of the previous minor line (N-1 -> N).
+	StrategyPreviousMinor = "n-1"
+	// StrategySecondPreviousMinor upgrades from the newest release two minor lines back (N-2 -> N).
+	StrategySecondPreviousMinor = "n-2"
+	// StrategyPatches upgrades from every older patch release of the latest minor line.
+	StrategyPatches = "patches"
+
+	// HopsEnvVar is the comma separated list of strategies to run.
+	HopsEnvVar = "UPGRADE_HOPS"
+)
+
+// DefaultStrategies keeps the single hop the upgrade suite has always covered.
+var DefaultStrategies = []string{StrategyPreviousMinor}
+
+// Hop is one upgrade from an older chart version to a newer one.
+type Hop struct {
+	Strategy string
+	From     version.Version
+	To       version.Version
+}
+
+// String describes the hop, e.g. "n-1: 105.1.3+up61.3.2 -> 106.0.2+up69.8.2".
+func (h Hop) String() string {
+	return fmt.Sprintf("%s: %s -> %s", h.Strategy, h.From, h.To)
+}
+
+// StrategiesFromEnv reads the strategies from UPGRADE_HOPS, falling back to DefaultStrategies.
+func StrategiesFromEnv() []string {
+	value := os.Getenv(HopsEnvVar)
+	if value == "" {
+		return DefaultStrategies
+	}
+	var strategies []string
+	for _, strategy := range strings.Split(value, ",") {
+		if strategy = strings.TrimSpace(strategy); strategy != "" {
+			strategies = append(strategies, strategy)
+		}
+	}
+	return strategies
+}
+
+// PlanHops returns the hops for the given strategies, all ending at the latest stable version. Strategies that
+// have no source version, such as n-2 for a chart with only two minor lines, are left out; duplicate hops are
+// only returned once.
+func PlanHops(versions []version.Version, strategies []string) ([]Hop, error) {
+	latest, err := version.Latest(versions)
+	if err != nil {
+		return nil, err
+	}
+
+	var hops []Hop
+	seen := map[string]bool{}
+	add := func(strategy string, from version.Version) {
+		if seen[from.Original] {
+			return
+		}
+		seen[from.Original] = true
+		hops = append(hops, Hop{Strategy: strategy, From: from, To: latest})
+	}
+
+	for _, strategy := range strategies {
+		switch strategy {
+		case StrategyPreviousMinor:
+			if from, err := version.PreviousMinor(versions); err == nil {
+				add(strategy, from)
+			}
+		case StrategySecondPreviousMinor:
+			previous, err := version.PreviousMinor(versions)
+			if err != nil {
+				continue
+			}
+			if from, err := version.PreviousMinor(olderLines(versions, previous)); err == nil {
+				add(strategy, from)
+			}
+		case StrategyPatches:
+			for n := 1; ; n++ {
+				from, err := version.PreviousPatch(versions, n)
+				if err != nil {
+					break
+				}
+				add(strategy, from)
+			}
+		default:
+			return nil, fmt.Errorf("unknown upgrade strategy %q, expected %s, %s or %s",
+				strategy, StrategyPreviousMinor, StrategySecondPreviousMinor, StrategyPatches)
+		}
+	}
+	return hops, nil
+}
+
+// olderLines keeps the versions of the release line of v and the lines before it, so that PreviousMinor on the
+// result steps back one more line.
+func olderLines(versions []version.Version, v version.Version) []version.Version {
+	var result []version.Version
+	for _, candidate := range versions {
+		if version.SameLine(candidate, v) || candidate.LessThan(v) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
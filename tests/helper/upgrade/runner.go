@@ -0,0 +1,169 @@
+package upgrade
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rancher/observability-e2e/tests/helper/charts"
+	"github.com/rancher/shepherd/clients/rancher"
+	extencharts "github.com/rancher/shepherd/extensions/charts"
+	"github.com/rancher/shepherd/extensions/clusters"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// Phases of a hop, recorded in HopResult so a failure points at the step that broke.
+const (
+	PhaseUninstall = "uninstall"
+	PhaseInstall   = "install"
+	PhaseSeed      = "seed"
+	PhaseUpgrade   = "upgrade"
+	PhaseVerify    = "verify"
+)
+
+// Chart describes how the matrix installs, upgrades and checks one chart.
+type Chart struct {
+	Name      string
+	Namespace string
+	// Releases are uninstalled in order before each hop so the source version can be installed.
+	Releases []string
+	// CRDReleases are kept between hops, the install action of the next hop moves them to its version in place.
+	// Uninstall removes them once the matrix is done.
+	CRDReleases []string
+	Install     func(client *rancher.Client, installOptions *charts.InstallOptions) error
+	Upgrade     func(client *rancher.Client, installOptions *charts.InstallOptions) error
+	// Seed creates workload data before the upgrade. It is optional.
+	Seed func(client *rancher.Client, clusterID string) error
+	// Verify checks the chart and the seeded data after the upgrade.
+	Verify func(client *rancher.Client, clusterID string) error
+}
+
+// HopResult is the outcome of one hop, added to the suite report.
+type HopResult struct {
+	Chart    string        `json:"chart"`
+	Strategy string        `json:"strategy"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Passed   bool          `json:"passed"`
+	Phase    string        `json:"phase,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// String summarizes the result for logs and the report.
+func (r HopResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("%s %s -> %s (%s): passed in %v", r.Chart, r.From, r.To, r.Strategy, r.Duration.Round(time.Second))
+	}
+	return fmt.Sprintf("%s %s -> %s (%s): failed during %s: %s", r.Chart, r.From, r.To, r.Strategy, r.Phase, r.Error)
+}
+
+// Runner runs upgrade hops against one cluster.
+type Runner struct {
+	Client    *rancher.Client
+	Cluster   *clusters.ClusterMeta
+	ProjectID string
+}
+
+// Run installs the source version of the hop, seeds data, upgrades to the target version and verifies the result.
+// The chart is left installed at the target version so the next hop, or the next spec, starts from a known state.
+func (r *Runner) Run(chart Chart, hop Hop) HopResult {
+	result := HopResult{Chart: chart.Name, Strategy: hop.Strategy, From: hop.From.String(), To: hop.To.String()}
+	start := time.Now()
+	fail := func(phase string, err error) HopResult {
+		result.Phase = phase
+		result.Error = err.Error()
+		result.Duration = time.Since(start)
+		e2e.Logf("Upgrade hop %s", result)
+		return result
+	}
+
+	e2e.Logf("Upgrading %s, %s", chart.Name, hop)
+	if err := r.uninstall(chart, chart.Releases); err != nil {
+		return fail(PhaseUninstall, err)
+	}
+
+	if err := chart.Install(r.Client, r.installOptions(hop.From.Original)); err != nil {
+		return fail(PhaseInstall, err)
+	}
+
+	if chart.Seed != nil {
+		if err := chart.Seed(r.Client, r.Cluster.ID); err != nil {
+			return fail(PhaseSeed, err)
+		}
+	}
+
+	if err := chart.Upgrade(r.Client, r.installOptions(hop.To.Original)); err != nil {
+		return fail(PhaseUpgrade, err)
+	}
+
+	if err := verifyInstalledVersion(r.Client, r.Cluster.ID, chart, hop.To.Original); err != nil {
+		return fail(PhaseVerify, err)
+	}
+	if chart.Verify != nil {
+		if err := chart.Verify(r.Client, r.Cluster.ID); err != nil {
+			return fail(PhaseVerify, err)
+		}
+	}
+
+	result.Passed = true
+	result.Duration = time.Since(start)
+	e2e.Logf("Upgrade hop %s", result)
+	return result
+}
+
+func (r *Runner) installOptions(chartVersion string) *charts.InstallOptions {
+	return &charts.InstallOptions{
+		Cluster:   r.Cluster,
+		Version:   chartVersion,
+		ProjectID: r.ProjectID,
+	}
+}
+
+// Installed reports whether the chart is installed, so a matrix can leave the cluster as it found it.
+func (r *Runner) Installed(chart Chart) (bool, error) {
+	status, err := extencharts.GetChartStatus(r.Client, r.Cluster.ID, chart.Namespace, chart.Name)
+	if err != nil {
+		return false, err
+	}
+	return status.IsAlreadyInstalled, nil
+}
+
+// Uninstall removes the releases of the chart and then its CRD releases, at the end of the matrix.
+func (r *Runner) Uninstall(chart Chart) error {
+	return r.uninstall(chart, append(append([]string{}, chart.Releases...), chart.CRDReleases...))
+}
+
+func (r *Runner) uninstall(chart Chart, releases []string) error {
+	for _, release := range releases {
+		status, err := extencharts.GetChartStatus(r.Client, r.Cluster.ID, chart.Namespace, release)
+		if err != nil {
+			return err
+		}
+		if !status.IsAlreadyInstalled {
+			continue
+		}
+		if err := charts.UninstallChart(r.Client, r.Cluster.ID, release, chart.Namespace); err != nil {
+			return fmt.Errorf("failed to uninstall %s: %w", release, err)
+		}
+	}
+	return nil
+}
+
+// verifyInstalledVersion checks that the app reports the target chart version after the upgrade.
+func verifyInstalledVersion(client *rancher.Client, clusterID string, chart Chart, expected string) error {
+	status, err := extencharts.GetChartStatus(client, clusterID, chart.Namespace, chart.Name)
+	if err != nil {
+		return err
+	}
+	if !status.IsAlreadyInstalled {
+		return fmt.Errorf("%s is not installed after the upgrade", chart.Name)
+	}
+	app := status.ChartDetails
+	if app == nil || app.Spec.Chart == nil || app.Spec.Chart.Metadata == nil {
+		return fmt.Errorf("%s app does not report its chart version", chart.Name)
+	}
+	if installed := app.Spec.Chart.Metadata.Version; installed != expected {
+		return fmt.Errorf("%s is at version %s after the upgrade, expected %s", chart.Name, installed, expected)
+	}
+	return nil
+}
@@ -2,12 +2,13 @@ package utils
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -19,11 +20,13 @@ import (
 
 	"github.com/creasty/defaults"
 	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/rancher/observability-e2e/tests/helper/kube"
+	"github.com/rancher/observability-e2e/tests/helper/version"
 	rancher "github.com/rancher/shepherd/clients/rancher"
-	management "github.com/rancher/shepherd/clients/rancher/generated/management/v3"
-	"github.com/rancher/shepherd/extensions/kubectl"
 	shepherdConfig "github.com/rancher/shepherd/pkg/config"
+	namegen "github.com/rancher/shepherd/pkg/namegenerator"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
 )
 
@@ -35,130 +38,110 @@ type Config struct {
 	Registry       string `json:"registry" yaml:"registry"`
 }
 
-func DeployPrometheusRule(mySession *rancher.Client, yamlPath string) error {
-
-	yamlContent, err := os.ReadFile(yamlPath)
-	if err != nil {
-		log.Fatalf("Failed to read file %s: %v", yamlPath, err)
-	}
+// Fixture is the data a fixture manifest template is rendered with. Name has a random suffix, so specs running in
+// parallel, or objects left behind by an aborted run, never share an object.
+type Fixture struct {
+	Name string
+	// Values holds the values of a single fixture, such as the syslog host of the logging fixture.
+	Values map[string]string
+}
 
-	importYamlInput := &management.ImportClusterYamlInput{
-		YAML: string(yamlContent),
-	}
+// NewFixture returns a fixture named prefix followed by a random suffix.
+func NewFixture(prefix string) *Fixture {
+	return &Fixture{Name: namegen.AppendRandomString(prefix), Values: map[string]string{}}
+}
 
-	apply := []string{"kubectl", "apply", "-f", "/root/.kube/my-pod.yaml"}
-	prometheusRuleApply, err := kubectl.Command(mySession, importYamlInput, "local", apply, "")
-	if err != nil {
-		return err
-	}
-	e2e.Logf("Successfully: %v", prometheusRuleApply)
+func DeployPrometheusRule(mySession *rancher.Client, yamlPath string, fixture *Fixture) error {
+	return DeployYamlTemplate(mySession, yamlPath, fixture, "")
+}
 
-	return nil
+func DeployAlertManagerConfig(mySession *rancher.Client, yamlPath string, fixture *Fixture) error {
+	return DeployYamlTemplate(mySession, yamlPath, fixture, "")
 }
 
-func DeployAlertManagerConfig(mySession *rancher.Client, yamlPath string) error {
+func DeployLoggingClusterOutputAndClusterFlow(mySession *rancher.Client, yamlPath string, fixture *Fixture) error {
+	return DeployYamlTemplate(mySession, yamlPath, fixture, "")
+}
 
-	yamlContent, err := os.ReadFile(yamlPath)
-	if err != nil {
-		log.Fatalf("Failed to read file %s: %v", yamlPath, err)
-	}
+func DeploySyslogResources(mySession *rancher.Client, yamlPath string) error {
+	return applyYamlFile(mySession, yamlPath, "")
+}
 
-	importYamlInput := &management.ImportClusterYamlInput{
-		YAML: string(yamlContent),
-	}
+func DeployYamlResource(mySession *rancher.Client, yamlPath string, namespace string) error {
+	return applyYamlFile(mySession, yamlPath, namespace)
+}
 
-	apply := []string{"kubectl", "apply", "-f", "/root/.kube/my-pod.yaml"}
-	alertManagerConfigApply, err := kubectl.Command(mySession, importYamlInput, "local", apply, "")
+func DeleteYamlResource(mySession *rancher.Client, yamlPath string, namespace string) error {
+	kubeClient, err := kube.NewClient(mySession, kube.LocalCluster)
 	if err != nil {
 		return err
 	}
-	e2e.Logf("Successfully: %v", alertManagerConfigApply)
+	if err := kubeClient.DeleteFile(context.TODO(), yamlPath, namespace); err != nil {
+		return err
+	}
+	e2e.Logf("Successfully deleted the resources of %s", yamlPath)
 
 	return nil
 }
 
-func DeployLoggingClusterOutputAndClusterFlow(mySession *rancher.Client, yamlPath string) error {
-
-	yamlContent, err := os.ReadFile(yamlPath)
+// DeployYamlTemplate renders the manifest template at templatePath with data and applies it like DeployYamlResource.
+func DeployYamlTemplate(mySession *rancher.Client, templatePath string, data any, namespace string) error {
+	manifest, err := RenderYAMLTemplate(templatePath, data)
 	if err != nil {
-		log.Fatalf("Failed to read file %s: %v", yamlPath, err)
+		return err
 	}
-
-	importYamlInput := &management.ImportClusterYamlInput{
-		YAML: string(yamlContent),
+	kubeClient, err := kube.NewClient(mySession, kube.LocalCluster)
+	if err != nil {
+		return err
 	}
-
-	apply := []string{"kubectl", "apply", "-f", "/root/.kube/my-pod.yaml"}
-	loggingResources, err := kubectl.Command(mySession, importYamlInput, "local", apply, "")
+	applied, err := kubeClient.Apply(context.TODO(), manifest, namespace)
 	if err != nil {
 		return err
 	}
-	e2e.Logf("Successfully : %v", loggingResources)
+	logApplied(applied)
 
 	return nil
 }
 
-func DeploySyslogResources(mySession *rancher.Client, yamlPath string) error {
-
-	yamlContent, err := os.ReadFile(yamlPath)
+// DeleteYamlTemplate deletes the resources of the manifest template at templatePath rendered with data, the
+// counterpart of DeployYamlTemplate.
+func DeleteYamlTemplate(mySession *rancher.Client, templatePath string, data any, namespace string) error {
+	manifest, err := RenderYAMLTemplate(templatePath, data)
 	if err != nil {
-		log.Fatalf("Failed to read file %s: %v", yamlPath, err)
-	}
-
-	importYamlInput := &management.ImportClusterYamlInput{
-		YAML: string(yamlContent),
+		return err
 	}
-
-	apply := []string{"kubectl", "apply", "-f", "/root/.kube/my-pod.yaml"}
-	syslogResources, err := kubectl.Command(mySession, importYamlInput, "local", apply, "")
+	kubeClient, err := kube.NewClient(mySession, kube.LocalCluster)
 	if err != nil {
 		return err
 	}
-	e2e.Logf("Successfully : %v", syslogResources)
+	if err := kubeClient.DeleteManifest(context.TODO(), manifest, namespace); err != nil {
+		return err
+	}
+	e2e.Logf("Successfully deleted the resources of %s", templatePath)
 
 	return nil
 }
 
-func DeployYamlResource(mySession *rancher.Client, yamlPath string, namespace string) error {
-
-	yamlContent, err := os.ReadFile(yamlPath)
+// applyYamlFile applies the manifest at yamlPath to the local cluster, putting namespaced objects without a
+// namespace into namespace.
+func applyYamlFile(mySession *rancher.Client, yamlPath string, namespace string) error {
+	kubeClient, err := kube.NewClient(mySession, kube.LocalCluster)
 	if err != nil {
-		log.Fatalf("Failed to read file %s: %v", yamlPath, err)
-	}
-
-	importYamlInput := &management.ImportClusterYamlInput{
-		YAML: string(yamlContent),
+		return err
 	}
-
-	apply := []string{"kubectl", "apply", "-f", "/root/.kube/my-pod.yaml", "-n", namespace}
-	yamlApply, err := kubectl.Command(mySession, importYamlInput, "local", apply, "")
+	applied, err := kubeClient.ApplyFile(context.TODO(), yamlPath, namespace)
 	if err != nil {
 		return err
 	}
-	e2e.Logf("Successfully fetchall: %v", yamlApply)
+	logApplied(applied)
 
 	return nil
 }
 
-func DeleteYamlResource(mySession *rancher.Client, yamlPath string, namespace string) error {
-
-	yamlContent, err := os.ReadFile(yamlPath)
-	if err != nil {
-		log.Fatalf("Failed to read file %s: %v", yamlPath, err)
+func logApplied(applied []*unstructured.Unstructured) {
+	for _, object := range applied {
+		e2e.Logf("Successfully applied %s %s", object.GetKind(), object.GetName())
 	}
-
-	importYamlInput := &management.ImportClusterYamlInput{
-		YAML: string(yamlContent),
-	}
-
-	apply := []string{"kubectl", "delete", "-f", "/root/.kube/my-pod.yaml", "-n", namespace}
-	yamlApply, err := kubectl.Command(mySession, importYamlInput, "local", apply, "")
-	if err != nil {
-		return err
-	}
-	e2e.Logf("Successfully fetchall: %v", yamlApply)
-
-	return nil
 }
 
 // LoadConfigIntoStruct loads a config file and unmarshals it into the given struct.
@@ -244,7 +227,8 @@ func RequestRancherVersion(rancherURL string) (*Config, error) {
 	return configObject, nil
 }
 
-// GetRancherVersion returns the Rancher version in X.Y format (major.minor)
+// GetRancherVersion returns the Rancher version in X.Y format (major.minor). Use version.Parse on
+// RequestRancherVersion for comparisons that need the patch or pre-release.
 // Handles formats like:
 //   - "rancher/rancher:v2.10.3"          → "2.10"
 //   - "v2.11-abc123-head"                → "2.11"
@@ -256,41 +240,11 @@ func GetRancherVersion(clientWithSession *rancher.Client) (string, error) {
 		return "", fmt.Errorf("failed to get Rancher version: %w", err)
 	}
 
-	image := strings.TrimSpace(rancherConfig.RancherVersion)
-	if image == "" {
-		return "", fmt.Errorf("empty Rancher version string")
-	}
-
-	// Extract version part, e.g., "v2.11-abc123-head" or "2.10.3"
-	var versionWithV string
-	if strings.Contains(image, ":") {
-		parts := strings.Split(image, ":")
-		if len(parts) != 2 {
-			return "", fmt.Errorf("unexpected version format: %s", image)
-		}
-		versionWithV = parts[1]
-	} else {
-		versionWithV = image
-	}
-
-	// Trim leading 'v' if present
-	version := strings.TrimPrefix(versionWithV, "v")
-
-	// Remove any suffix like "-head" or "-<commit>"
-	if dashIdx := strings.Index(version, "-"); dashIdx != -1 {
-		version = version[:dashIdx]
-	}
-
-	// Split into major.minor.patch
-	parts := strings.Split(version, ".")
-	if len(parts) < 2 {
-		return "", fmt.Errorf("invalid version format: %s", version)
+	rancherVersion, err := version.Parse(rancherConfig.RancherVersion)
+	if err != nil {
+		return "", err
 	}
-
-	major := parts[0]
-	minor := parts[1]
-
-	return fmt.Sprintf("%s.%s", major, minor), nil
+	return rancherVersion.MajorMinor(), nil
 }
 
 func CreateTempDir(dirName string) (string, error) {
@@ -392,18 +346,25 @@ func GetYamlPath(relativeYamlPath string) string {
 }
 
 func GenerateYAMLFromTemplate(templateFile, outputFile string, data any) error {
-	tmpl, err := template.ParseFiles(templateFile)
+	manifest, err := RenderYAMLTemplate(templateFile, data)
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(outputFile, manifest, 0644)
+}
 
-	output, err := os.Create(outputFile)
+// RenderYAMLTemplate renders the manifest template at templateFile with data. A value missing from data is an
+// error, so a fixture never reaches the cluster with an empty name.
+func RenderYAMLTemplate(templateFile string, data any) ([]byte, error) {
+	tmpl, err := template.New(filepath.Base(templateFile)).Option("missingkey=error").ParseFiles(templateFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer output.Close()
-
-	return tmpl.Execute(output, data)
+	var manifest bytes.Buffer
+	if err := tmpl.Execute(&manifest, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", templateFile, err)
+	}
+	return manifest.Bytes(), nil
 }
 
 // SafeCleanup wraps a cleanup function to ensure it only runs once.
@@ -424,9 +385,7 @@ func SafeCleanup(description string, cleanupFunc func()) func() {
 
 // CheckIfRancherIsPrime checks if the Rancher deployment image is from Prime registries
 func CheckIfRancherIsPrime(client *rancher.Client) (bool, string) {
-	getRancherImage := []string{"kubectl", "-n", "cattle-system", "get", "deploy", "rancher", "-o", "jsonpath={.spec.template.spec.containers[0].image}"}
-	imageOutput, err := kubectl.Command(client, nil, "local", getRancherImage, "")
-
+	imageOutput, err := rancherImage(client)
 	if err != nil {
 		e2e.Logf("Failed to get Rancher deployment image: %v", err)
 		ginkgo.Fail(fmt.Sprintf("Failed to get Rancher deployment image: %v", err))
@@ -443,6 +402,19 @@ func CheckIfRancherIsPrime(client *rancher.Client) (bool, string) {
 	return isPrime, imageRegistry
 }
 
+// rancherImage returns the image of the first container of the rancher deployment.
+func rancherImage(client *rancher.Client) (string, error) {
+	kubeClient, err := kube.NewClient(client, kube.LocalCluster)
+	if err != nil {
+		return "", err
+	}
+	deployment, err := kubeClient.Get(context.TODO(), kube.DeploymentsGVR, "cattle-system", "rancher")
+	if err != nil {
+		return "", err
+	}
+	return kube.JSONPath(deployment, "{.spec.template.spec.containers[0].image}")
+}
+
 // CheckDashboardEndpoint verifies if the SCC registration dashboard endpoint is accessible
 func CheckDashboardEndpoint(url string, client *rancher.Client) (int, error) {
 	// Skip TLS verification for self-signed certificates
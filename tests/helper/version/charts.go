@@ -0,0 +1,126 @@
+package version
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rancher/shepherd/clients/rancher"
+	"github.com/rancher/shepherd/clients/rancher/catalog"
+)
+
+// ParseAll parses every version and returns them sorted from newest to oldest.
+func ParseAll(raw []string) ([]Version, error) {
+	versions := make([]Version, 0, len(raw))
+	for _, r := range raw {
+		v, err := Parse(r)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[j].LessThan(versions[i])
+	})
+	return versions, nil
+}
+
+// ListChartVersions returns the versions of a chart in the Rancher charts repository, newest first.
+func ListChartVersions(client *rancher.Client, chartName string) ([]Version, error) {
+	raw, err := client.Catalog.GetListChartVersions(chartName, catalog.RancherChartRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of chart %s: %w", chartName, err)
+	}
+	return ParseAll(raw)
+}
+
+// stable drops pre-release versions, which the pickers never select.
+func stable(versions []Version) []Version {
+	var result []Version
+	for _, v := range versions {
+		if !v.IsPrerelease() {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Latest returns the newest stable version.
+func Latest(versions []Version) (Version, error) {
+	candidates := stable(versions)
+	if len(candidates) == 0 {
+		return Version{}, fmt.Errorf("no stable versions in %v", versions)
+	}
+	return newest(candidates), nil
+}
+
+// rancherChartMajor is the lowest major number of Rancher chart versions, whose major follows the Rancher minor.
+const rancherChartMajor = 100
+
+// SameLine reports whether a and b belong to the same release line. For Rancher charts, where the major number
+// follows the Rancher minor (105.x for 2.9, 106.x for 2.10), the line is the major number, so 106.1.0 and 106.0.2
+// are one line. For other versions the line is the major and minor number.
+func SameLine(a, b Version) bool {
+	if a.Major != b.Major {
+		return false
+	}
+	return a.Major >= rancherChartMajor || a.Minor == b.Minor
+}
+
+// PreviousMinor returns the newest stable version of the release line before the latest one, see SameLine. For
+// Rancher charts 106.0.0 is preceded by the newest 105.x.
+func PreviousMinor(versions []Version) (Version, error) {
+	latest, err := Latest(versions)
+	if err != nil {
+		return Version{}, err
+	}
+
+	var candidates []Version
+	for _, v := range stable(versions) {
+		if v.LessThan(latest) && !SameLine(v, latest) {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) == 0 {
+		return Version{}, fmt.Errorf("no minor release before %s", latest)
+	}
+	return newest(candidates), nil
+}
+
+// PreviousPatch returns the stable version n releases before the latest one in the same release line, so n=1
+// picks N-1. Build metadata is ignored, so two chart versions that only differ in "+up" count as one release.
+func PreviousPatch(versions []Version, n int) (Version, error) {
+	latest, err := Latest(versions)
+	if err != nil {
+		return Version{}, err
+	}
+
+	sorted := stable(versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[j].LessThan(sorted[i])
+	})
+
+	seen := map[string]bool{latest.Release().Original: true}
+	var candidates []Version
+	for _, v := range sorted {
+		release := v.Release().Original
+		if !SameLine(v, latest) || seen[release] {
+			continue
+		}
+		seen[release] = true
+		candidates = append(candidates, v)
+	}
+	if n < 1 || n > len(candidates) {
+		return Version{}, fmt.Errorf("no patch release %d before %s", n, latest)
+	}
+	return candidates[n-1], nil
+}
+
+func newest(versions []Version) Version {
+	result := versions[0]
+	for _, v := range versions[1:] {
+		if result.LessThan(v) {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// Range is a set of version constraints such as ">=2.10 <2.13" or "<2.9 || >=2.11". Comparators separated by
+// spaces must all match, alternatives are separated by "||".
+type Range struct {
+	expr  string
+	match semver.Range
+}
+
+// comparatorVersion matches the version of a single comparator so short forms like "2.10" can be padded.
+var comparatorVersion = regexp.MustCompile(`^([<>=!]*)v?(\d+(?:\.\d+){0,2})(.*)$`)
+
+// ParseRange parses a range expression. Versions may omit the minor or patch number.
+func ParseRange(expr string) (Range, error) {
+	var alternatives []string
+	for _, alternative := range strings.Split(expr, "||") {
+		var comparators []string
+		for _, comparator := range strings.Fields(alternative) {
+			parts := comparatorVersion.FindStringSubmatch(comparator)
+			if parts == nil {
+				return Range{}, fmt.Errorf("invalid comparator %q in range %q", comparator, expr)
+			}
+			core := parts[2]
+			for strings.Count(core, ".") < 2 {
+				core += ".0"
+			}
+			comparators = append(comparators, parts[1]+core+parts[3])
+		}
+		if len(comparators) == 0 {
+			return Range{}, fmt.Errorf("empty alternative in range %q", expr)
+		}
+		alternatives = append(alternatives, strings.Join(comparators, " "))
+	}
+
+	match, err := semver.ParseRange(strings.Join(alternatives, " || "))
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid version range %q: %w", expr, err)
+	}
+	return Range{expr: expr, match: match}, nil
+}
+
+// Contains reports whether v is in the range. Pre-release and head builds are treated as the release they lead up
+// to, so a 2.13.0-alpha1 or v2.13-head build satisfies ">=2.13".
+func (r Range) Contains(v Version) bool {
+	return r.match(v.Release().Version)
+}
+
+// String returns the expression the range was parsed from.
+func (r Range) String() string {
+	return r.expr
+}
+
+// Satisfies parses both arguments and reports whether the version is in the range.
+func Satisfies(raw, expr string) (bool, error) {
+	v, err := Parse(raw)
+	if err != nil {
+		return false, err
+	}
+	r, err := ParseRange(expr)
+	if err != nil {
+		return false, err
+	}
+	return r.Contains(v), nil
+}
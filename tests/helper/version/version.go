@@ -0,0 +1,145 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// Version is a parsed Rancher, Kubernetes distribution or chart version. Original keeps the string it was parsed
+// from so it can be passed back to the catalog unchanged.
+type Version struct {
+	semver.Version
+	Original string
+}
+
+var numericWithLeadingZero = regexp.MustCompile(`^0\d+$`)
+
+// Parse accepts the version formats seen in Rancher environments:
+//   - image tags: "rancher/rancher:v2.10.3", "registry.example.com:5000/rancher/rancher:v2.12.0-rc3"
+//   - development builds: "v2.11-abc123-head", "2.13.0-alpha1"
+//   - distribution versions: "v1.31.4+rke2r1", "v1.30.2+k3s1"
+//   - chart versions with upstream metadata: "106.0.2+up6.0.1"
+//
+// A missing patch or minor number is treated as zero.
+func Parse(raw string) (Version, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return Version{}, fmt.Errorf("empty version string")
+	}
+	// Image references may contain a registry port, so only the text after the last colon is the tag.
+	if idx := strings.LastIndex(value, ":"); idx != -1 {
+		value = value[idx+1:]
+	}
+	value = strings.TrimPrefix(value, "v")
+
+	build := ""
+	if idx := strings.Index(value, "+"); idx != -1 {
+		value, build = value[:idx], value[idx+1:]
+	}
+	core, pre, _ := strings.Cut(value, "-")
+
+	switch strings.Count(core, ".") {
+	case 0:
+		core += ".0.0"
+	case 1:
+		core += ".0"
+	}
+
+	normalized := core
+	if pre != "" {
+		normalized += "-" + sanitizeIdentifiers(pre)
+	}
+	if build != "" {
+		normalized += "+" + build
+	}
+
+	parsed, err := semver.Parse(normalized)
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid version %q: %w", raw, err)
+	}
+	return Version{Version: parsed, Original: raw}, nil
+}
+
+// MustParse is Parse for versions known to be valid, such as constants in test code.
+func MustParse(raw string) Version {
+	v, err := Parse(raw)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// sanitizeIdentifiers makes pre-release identifiers valid semver. Commit hashes can be all digits with a leading
+// zero, which semver rejects as a numeric identifier, so those are prefixed with "g" as git describe does.
+func sanitizeIdentifiers(pre string) string {
+	identifiers := strings.Split(pre, ".")
+	for i, identifier := range identifiers {
+		if numericWithLeadingZero.MatchString(identifier) {
+			identifiers[i] = "g" + identifier
+		}
+	}
+	return strings.Join(identifiers, ".")
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than other. Build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	return v.Version.Compare(other.Version)
+}
+
+// LessThan reports whether v is lower than other.
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// IsHead reports whether v is a development build from a -head tag.
+func (v Version) IsHead() bool {
+	for _, pre := range v.Pre {
+		if strings.HasSuffix(pre.VersionStr, "head") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrerelease reports whether v is a -head, -rc, -alpha or other pre-release build.
+func (v Version) IsPrerelease() bool {
+	return len(v.Pre) > 0
+}
+
+// Release returns v without pre-release and build metadata.
+func (v Version) Release() Version {
+	release := v.Version
+	release.Pre = nil
+	release.Build = nil
+	return Version{Version: release, Original: release.String()}
+}
+
+// Upstream returns the upstream application version of a Rancher chart, e.g. 6.0.1 for "106.0.2+up6.0.1".
+func (v Version) Upstream() (Version, bool) {
+	// semver splits build metadata on dots, so "up6.0.1" arrives as "up6", "0", "1".
+	metadata := strings.Join(v.Build, ".")
+	if !strings.HasPrefix(metadata, "up") {
+		return Version{}, false
+	}
+	upstream, err := Parse(strings.TrimPrefix(metadata, "up"))
+	if err != nil {
+		return Version{}, false
+	}
+	return upstream, true
+}
+
+// MajorMinor returns v in "X.Y" form.
+func (v Version) MajorMinor() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// String returns the original version string.
+func (v Version) String() string {
+	if v.Original != "" {
+		return v.Original
+	}
+	return v.Version.String()
+}
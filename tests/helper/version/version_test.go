@@ -0,0 +1,227 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw        string
+		want       string
+		prerelease bool
+		head       bool
+	}{
+		{raw: "v2.10.3", want: "2.10.3"},
+		{raw: "2.11", want: "2.11.0"},
+		{raw: "v3", want: "3.0.0"},
+		{raw: "rancher/rancher:v2.10.3", want: "2.10.3"},
+		{raw: "registry.example.com:5000/rancher/rancher:v2.12.0-rc3", want: "2.12.0-rc3", prerelease: true},
+		{raw: "v2.11-abc123-head", want: "2.11.0-abc123-head", prerelease: true, head: true},
+		{raw: "v2.11.0-0123", want: "2.11.0-g0123", prerelease: true},
+		{raw: "2.13.0-alpha1", want: "2.13.0-alpha1", prerelease: true},
+		{raw: "v1.31.4+rke2r1", want: "1.31.4+rke2r1"},
+		{raw: "106.0.2+up6.0.1", want: "106.0.2+up6.0.1"},
+		{raw: " v2.9.1 ", want: "2.9.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			v, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Version.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if v.Original != tt.raw {
+				t.Errorf("got original %q, want %q", v.Original, tt.raw)
+			}
+			if v.IsPrerelease() != tt.prerelease {
+				t.Errorf("got prerelease %t, want %t", v.IsPrerelease(), tt.prerelease)
+			}
+			if v.IsHead() != tt.head {
+				t.Errorf("got head %t, want %t", v.IsHead(), tt.head)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{"", "  ", "latest", "v2.x", "rancher/rancher:head"} {
+		if v, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", raw, v)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "2.10.0", b: "2.9.9", want: 1},
+		{a: "2.9.9", b: "2.10.0", want: -1},
+		{a: "v2.10", b: "2.10.0", want: 0},
+		{a: "2.12.0-rc1", b: "2.12.0", want: -1},
+		{a: "2.12.0-rc.2", b: "2.12.0-rc.10", want: -1},
+		{a: "106.0.2+up6.0.1", b: "106.0.2+up6.0.2", want: 0},
+		{a: "106.1.0+up6.1.0", b: "106.0.9+up6.0.9", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := MustParse(tt.a).Compare(MustParse(tt.b)); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstream(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{raw: "106.0.2+up6.0.1", want: "6.0.1", ok: true},
+		{raw: "105.1.0+up0.8.0-rancher.1", want: "0.8.0-rancher.1", ok: true},
+		{raw: "v1.31.4+rke2r1"},
+		{raw: "2.10.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			upstream, ok := MustParse(tt.raw).Upstream()
+			if ok != tt.ok {
+				t.Fatalf("got ok %t, want %t", ok, tt.ok)
+			}
+			if ok && upstream.Version.String() != tt.want {
+				t.Errorf("got %s, want %s", upstream.Version.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		expr    string
+		version string
+		want    bool
+	}{
+		{expr: ">=2.11", version: "2.11.0", want: true},
+		{expr: ">=2.11", version: "2.10.9", want: false},
+		{expr: "<2.11", version: "2.10.9", want: true},
+		{expr: ">=2.10 <2.13", version: "2.12.4", want: true},
+		{expr: ">=2.10 <2.13", version: "2.13.0", want: false},
+		{expr: "<2.9 || >=2.11", version: "2.10.0", want: false},
+		{expr: "<2.9 || >=2.11", version: "2.8.5", want: true},
+		{expr: ">=2.13", version: "2.13.0-alpha1", want: true},
+		{expr: ">=2.13", version: "v2.13-abc123-head", want: true},
+		{expr: ">=105", version: "106.0.2+up6.0.1", want: true},
+		{expr: ">=105", version: "104.1.0+up5.0.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.version, func(t *testing.T) {
+			got, err := Satisfies(tt.version, tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, expr := range []string{"", ">=2.11 ||", "~>x", ">=2.x"} {
+		if _, err := ParseRange(expr); err == nil {
+			t.Errorf("ParseRange(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestSameLine(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "2.10.3", b: "2.10.0", want: true},
+		{a: "2.10.3", b: "2.9.3", want: false},
+		{a: "3.0.0", b: "2.0.0", want: false},
+		{a: "106.1.0+up6.1.0", b: "106.0.2+up6.0.1", want: true},
+		{a: "106.0.0+up6.0.0", b: "105.2.0+up5.2.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := SameLine(MustParse(tt.a), MustParse(tt.b)); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickers(t *testing.T) {
+	rancher := []string{"2.11.0-rc1", "2.10.3", "2.10.2", "2.10.1", "2.9.5", "2.9.4", "2.8.9"}
+	charts := []string{
+		"107.0.0-rc1", "106.1.0+up6.1.0", "106.0.2+up6.0.2", "106.0.2+up6.0.1", "106.0.1+up6.0.1",
+		"105.2.0+up5.2.0", "105.1.1+up5.1.1", "104.0.0+up4.0.0",
+	}
+
+	tests := []struct {
+		name     string
+		versions []string
+		pick     func([]Version) (Version, error)
+		want     string
+	}{
+		{name: "latest rancher", versions: rancher, pick: Latest, want: "2.10.3"},
+		{name: "latest chart", versions: charts, pick: Latest, want: "106.1.0+up6.1.0"},
+		{name: "previous minor rancher", versions: rancher, pick: PreviousMinor, want: "2.9.5"},
+		{name: "previous minor chart skips the minors of the latest major", versions: charts, pick: PreviousMinor, want: "105.2.0+up5.2.0"},
+		{name: "N-1 rancher", versions: rancher, pick: previousPatch(1), want: "2.10.2"},
+		{name: "N-2 rancher", versions: rancher, pick: previousPatch(2), want: "2.10.1"},
+		{name: "N-1 chart crosses minors of the line", versions: charts, pick: previousPatch(1), want: "106.0.2+up6.0.2"},
+		{name: "N-2 chart ignores build metadata", versions: charts, pick: previousPatch(2), want: "106.0.1+up6.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, err := ParseAll(tt.versions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.pick(versions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickersWithoutCandidates(t *testing.T) {
+	only, err := ParseAll([]string{"106.1.0+up6.1.0", "106.0.2+up6.0.2", "2.12.0-rc1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := PreviousMinor(only); err == nil {
+		t.Errorf("PreviousMinor = %s, want an error without an older line", v)
+	}
+	if v, err := PreviousPatch(only, 2); err == nil {
+		t.Errorf("PreviousPatch(2) = %s, want an error with one older release", v)
+	}
+	if v, err := PreviousPatch(only, 0); err == nil {
+		t.Errorf("PreviousPatch(0) = %s, want an error", v)
+	}
+	prereleases, err := ParseAll([]string{"2.12.0-rc1", "2.12.0-alpha2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := Latest(prereleases); err == nil {
+		t.Errorf("Latest = %s, want an error without stable versions", v)
+	}
+}
+
+func previousPatch(n int) func([]Version) (Version, error) {
+	return func(versions []Version) (Version, error) {
+		return PreviousPatch(versions, n)
+	}
+}